@@ -0,0 +1,241 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel // import "src.tricot.io/public/bazel2x/bazel"
+
+import (
+	"fmt"
+	"strings"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// analyzeTargets analyzes the targets of user-defined rules, running their rules' implementation
+// functions (with the targets that they depend on analyzed first), and records the results in
+// AnalysisResults and the actions in Actions. Targets with unresolved attributes are skipped.
+// Genrule targets are also analyzed (see analyzeGenrule), since their actions are recorded too.
+func (self *Build) analyzeTargets() error {
+	// results contains the results for all the labels analyzed so far (including those of
+	// source files and of targets of native rules), and inProgress contains the labels of the
+	// targets being analyzed (to detect cycles).
+	results := map[core.Label]*analysis.Result{}
+	inProgress := map[core.Label]bool{}
+
+	var analyze func(label core.Label) (*analysis.Result, error)
+	analyze = func(label core.Label) (*analysis.Result, error) {
+		if result, ok := results[label]; ok {
+			return result, nil
+		}
+		if inProgress[label] {
+			return nil, fmt.Errorf("%v: cycle in dependency graph", label)
+		}
+
+		var result *analysis.Result
+		target, _ := self.getTarget(label)
+		switch target := target.(type) {
+		case nil, *core.SourceFileTarget:
+			result = analysis.NewSourceFileResult(label)
+		case *core.OutputFileTarget:
+			// The generating target must be analyzed first (so that its actions are
+			// registered).
+			inProgress[label] = true
+			if _, err := analyze(target.GeneratingRule.Label()); err != nil {
+				return nil, err
+			}
+			delete(inProgress, label)
+			result = analysis.NewOutputFileResult(label)
+		case *rules.StarlarkRuleTarget:
+			if len(target.Configurables()) > 0 {
+				result = analysis.NewOpaqueResult(label)
+				break
+			}
+
+			// The labels given by the target's output attributes (its predeclared
+			// outputs) aren't dependencies, but any other references to them are (and
+			// are reported as cycles).
+			outputs := map[core.Label]int{}
+			for _, output := range target.OutputLabels() {
+				outputs[output]++
+			}
+			inProgress[label] = true
+			for _, dep := range rules.TargetLabels(target) {
+				if outputs[dep] > 0 {
+					outputs[dep]--
+					continue
+				}
+				if _, err := analyze(dep); err != nil {
+					return nil, err
+				}
+			}
+			delete(inProgress, label)
+
+			thread := createThread(self, label, core.FileTypeBzl)
+			var err error
+			result, err = analysis.AnalyzeTarget(thread, target,
+				func(l core.Label) *analysis.Result { return results[l] })
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", label, withBacktrace(err))
+			}
+			for _, action := range result.Actions {
+				if err := self.Actions.Add(action); err != nil {
+					return nil, fmt.Errorf("%v: %v", label, err)
+				}
+			}
+			self.AnalysisResults[label] = result
+		case *rules.GenruleTarget:
+			var err error
+			inProgress[label] = true
+			result, err = self.analyzeGenrule(target, analyze)
+			if err != nil {
+				return nil, err
+			}
+			delete(inProgress, label)
+		case core.GeneratingTarget:
+			result = analysis.NewGeneratedFilesResult(label, target.OutputLabels())
+		case *rules.CcBinaryTarget, *rules.CcTestTarget:
+			result = analysis.NewExecutableResult(label)
+		case *rules.GenericRuleTarget:
+			var err error
+			inProgress[label] = true
+			result, err = self.analyzeGeneric(target, analyze)
+			if err != nil {
+				return nil, err
+			}
+			delete(inProgress, label)
+		default:
+			result = analysis.NewOpaqueResult(label)
+		}
+		if result.Kind == "" {
+			result.Kind = target.Kind()
+		}
+		results[label] = result
+		return result, nil
+	}
+
+	return self.forEachTarget(func(target core.Target) error {
+		switch target.(type) {
+		case *rules.StarlarkRuleTarget, *rules.GenruleTarget:
+			_, err := analyze(target.Label())
+			return err
+		default:
+			return nil
+		}
+	})
+}
+
+// analyzeGenrule returns the result for a genrule target, analyzing the targets that it depends
+// on using analyze, and records its action (which runs its cmd_bash or cmd, with "Make" variables
+// expanded) in Actions. Genrules with unresolved attributes, or with neither cmd_bash nor cmd,
+// get no action.
+func (self *Build) analyzeGenrule(target *rules.GenruleTarget,
+	analyze func(label core.Label) (*analysis.Result, error)) (*analysis.Result, error) {
+
+	label := target.Label()
+	result := analysis.NewGeneratedFilesResult(label, target.OutputLabels())
+	attrName, command := "cmd_bash", target.GetCmdBash()
+	if command == "" {
+		attrName, command = "cmd", target.GetCmd()
+	}
+	if len(target.Configurables()) > 0 || command == "" {
+		return result, nil
+	}
+
+	// filesOf analyzes the given targets and returns their files.
+	filesOf := func(labels []core.Label) ([]core.Label, error) {
+		rv := []core.Label{}
+		for _, l := range labels {
+			depResult, err := analyze(l)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range depResult.Files() {
+				rv = append(rv, file.Label())
+			}
+		}
+		return rv, nil
+	}
+	inputs, err := filesOf(target.GetSrcs())
+	if err != nil {
+		return nil, err
+	}
+	tools, err := filesOf(append(target.GetTools(), target.GetExecTools()...))
+	if err != nil {
+		return nil, err
+	}
+
+	expander := self.NewMakeVariableExpander(target, NewBazelPathMapper(self))
+	expanded, err := expander.Expand(attrName, command)
+	if err != nil {
+		return nil, err
+	}
+	action := &core.Action{
+		Owner:           label,
+		Mnemonic:        "Genrule",
+		Inputs:          inputs,
+		Outputs:         target.GetOuts(),
+		Tools:           tools,
+		Argv:            []string{"/bin/bash", "-c", expanded},
+		Command:         expanded,
+		ProgressMessage: target.GetMessage(),
+	}
+	if err := self.Actions.Add(action); err != nil {
+		return nil, fmt.Errorf("%v: %v", label, err)
+	}
+	result.Actions = []*core.Action{action}
+	return result, nil
+}
+
+// analyzeGeneric returns the result for a target of a native rule that we don't model, analyzing
+// the targets that it depends on using analyze. Only filegroup and alias targets are really
+// supported, though the targets of *_binary and *_test rules are assumed to produce an executable
+// named after the target: targets of other kinds get opaque results, and are recorded as not
+// implemented (which fails in strict mode).
+func (self *Build) analyzeGeneric(target *rules.GenericRuleTarget,
+	analyze func(label core.Label) (*analysis.Result, error)) (*analysis.Result, error) {
+
+	label := target.Label()
+	kind := target.Kind()
+	if strings.HasSuffix(kind, "_binary") || strings.HasSuffix(kind, "_test") {
+		return analysis.NewExecutableResult(label), nil
+	}
+	if len(target.Configurables()) > 0 {
+		return analysis.NewOpaqueResult(label), nil
+	}
+	switch kind {
+	case "filegroup":
+		srcs, _ := target.Attr("srcs")
+		labels, _ := srcs.([]core.Label)
+		srcResults := make([]*analysis.Result, len(labels))
+		for i, src := range labels {
+			srcResult, err := analyze(src)
+			if err != nil {
+				return nil, err
+			}
+			srcResults[i] = srcResult
+		}
+		return analysis.NewFilegroupResult(label, srcResults), nil
+	case "alias":
+		actual, _ := target.Attr("actual")
+		actualLabel, ok := actual.(core.Label)
+		if !ok {
+			return nil, fmt.Errorf("%v: alias has no actual target", label)
+		}
+		actualResult, err := analyze(actualLabel)
+		if err != nil {
+			return nil, err
+		}
+		return analysis.NewAliasResult(label, actualResult), nil
+	}
+	call := core.NotImplementedCall{
+		Builtin: fmt.Sprintf("depending on %v targets", kind),
+	}
+	if provenance := target.Provenance(); provenance != nil {
+		call.Location = provenance.Location()
+	}
+	if err := self.recordNotImplementedCall(call); err != nil {
+		return nil, fmt.Errorf("%v: %v", label, err)
+	}
+	return analysis.NewOpaqueResult(label), nil
+}
@@ -0,0 +1,642 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel_test
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	. "src.tricot.io/public/bazel2x/bazel"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// describeAction describes an action (giving only the fields that are set), for comparison in
+// tests.
+func describeAction(action *core.Action) string {
+	parts := []string{fmt.Sprintf("%v: %v", action.Owner, action.Mnemonic)}
+	if len(action.Inputs) > 0 {
+		parts = append(parts, fmt.Sprintf("inputs=%v", action.Inputs))
+	}
+	parts = append(parts, fmt.Sprintf("outputs=%v", action.Outputs))
+	if len(action.Tools) > 0 {
+		parts = append(parts, fmt.Sprintf("tools=%v", action.Tools))
+	}
+	if len(action.Argv) > 0 {
+		parts = append(parts, fmt.Sprintf("argv=%q", action.Argv))
+	}
+	if action.Content != "" {
+		parts = append(parts, fmt.Sprintf("content=%q", action.Content))
+	}
+	if len(action.Substitutions) > 0 {
+		parts = append(parts, fmt.Sprintf("substitutions=%v", action.Substitutions))
+	}
+	if action.IsExecutable {
+		parts = append(parts, "executable")
+	}
+	return strings.Join(parts, " ")
+}
+
+// analysisTestRule is the format of the .bzl file for analysis tests, which defines the rule r
+// (given the body of its implementation function and additional arguments to rule()).
+const analysisTestRule = `
+MyInfo = provider(fields = ["v"])
+
+def _impl(ctx):
+%v
+
+r = rule(implementation = _impl, attrs = {
+    "srcs": attr.label_list(allow_files = True),
+    "tool": attr.label(allow_single_file = True),
+    "template": attr.label(allow_single_file = True),
+    "bin": attr.label(executable = True, cfg = "exec"),
+    "out": attr.output(),
+    "outs": attr.output_list(),
+}%v)
+`
+
+func TestBuild_Analyze(t *testing.T) {
+	testCases := []struct {
+		name string
+		// impl is the body of r's implementation function, and ruleArgs are additional
+		// arguments to rule().
+		impl     string
+		ruleArgs string
+		build    string
+
+		// err is a substring of the expected error (empty if valid). The other fields
+		// give the expected results for //pkg:x (and the descriptions of all the actions,
+		// in order).
+		err        string
+		actions    []string
+		files      []string
+		executable string
+		providers  []string
+	}{
+		{
+			name: "write",
+			impl: `
+    out = ctx.actions.declare_file("x.txt")
+    ctx.actions.write(out, "hello", is_executable = True)
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `r(name = "x")`,
+			actions: []string{
+				`//pkg:x: FileWrite outputs=[//pkg:x.txt] content="hello" ` +
+					`executable`,
+			},
+			files:     []string{"pkg/x.txt"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "declare_file with sibling",
+			impl: `
+    a = ctx.actions.declare_file("sub/a.txt")
+    b = ctx.actions.declare_file("b.txt", sibling = a)
+    ctx.actions.write(a, "a")
+    ctx.actions.write(b, "b")
+    return [DefaultInfo(files = depset([a, b]))]`,
+			build: `r(name = "x")`,
+			actions: []string{
+				`//pkg:x: FileWrite outputs=[//pkg:sub/a.txt] content="a"`,
+				`//pkg:x: FileWrite outputs=[//pkg:sub/b.txt] content="b"`,
+			},
+			files:     []string{"pkg/sub/a.txt", "pkg/sub/b.txt"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "run",
+			impl: `
+    out = ctx.actions.declare_file("x.out")
+    ctx.actions.run(outputs = [out], inputs = ctx.files.srcs, executable = ctx.file.tool,
+                    arguments = ["-o", out.path], mnemonic = "Tool")
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `r(name = "x", srcs = ["a.txt"], tool = "tool.sh")`,
+			actions: []string{
+				`//pkg:x: Tool inputs=[//pkg:a.txt] outputs=[//pkg:x.out] ` +
+					`tools=[//pkg:tool.sh] ` +
+					`argv=["pkg/tool.sh" "-o" "bazel-out/bin/pkg/x.out"]`,
+			},
+			files:     []string{"pkg/x.out"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "run_shell",
+			impl: `
+    out = ctx.actions.declare_file("x.out")
+    ctx.actions.run_shell(outputs = [out], inputs = ctx.files.srcs,
+                          command = "cat $1 > $2",
+                          arguments = [ctx.files.srcs[0].path, out.path])
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `r(name = "x", srcs = ["a.txt"])`,
+			actions: []string{
+				`//pkg:x: Action inputs=[//pkg:a.txt] outputs=[//pkg:x.out] ` +
+					`argv=["/bin/bash" "-c" "cat $1 > $2" "" "pkg/a.txt" ` +
+					`"bazel-out/bin/pkg/x.out"]`,
+			},
+			files:     []string{"pkg/x.out"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "expand_template",
+			impl: `
+    out = ctx.actions.declare_file("x.h")
+    ctx.actions.expand_template(template = ctx.file.template, output = out,
+                                substitutions = {"@X@": "1"})
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `r(name = "x", template = "x.h.in")`,
+			actions: []string{
+				`//pkg:x: TemplateExpand inputs=[//pkg:x.h.in] ` +
+					`outputs=[//pkg:x.h] substitutions=map[@X@:1]`,
+			},
+			files:     []string{"pkg/x.h"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "symlink",
+			impl: `
+    out = ctx.actions.declare_file("x.lnk")
+    ctx.actions.symlink(output = out, target_file = ctx.files.srcs[0])
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `r(name = "x", srcs = ["a.txt"])`,
+			actions: []string{
+				`//pkg:x: Symlink inputs=[//pkg:a.txt] outputs=[//pkg:x.lnk] ` +
+					`argv=["ln" "-s" "pkg/a.txt" "bazel-out/bin/pkg/x.lnk"]`,
+			},
+			files:     []string{"pkg/x.lnk"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "predeclared outputs",
+			impl: `
+    ctx.actions.write(ctx.outputs.out, "o")
+    for f in ctx.outputs.outs:
+        ctx.actions.write(f, f.basename)`,
+			build: `r(name = "x", out = "o.txt", outs = ["p.txt", "q.txt"])`,
+			actions: []string{
+				`//pkg:x: FileWrite outputs=[//pkg:o.txt] content="o"`,
+				`//pkg:x: FileWrite outputs=[//pkg:p.txt] content="p.txt"`,
+				`//pkg:x: FileWrite outputs=[//pkg:q.txt] content="q.txt"`,
+			},
+			files:     []string{"pkg/o.txt", "pkg/p.txt", "pkg/q.txt"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "executable",
+			impl: `
+    ctx.actions.write(ctx.outputs.executable, "#!/bin/sh", is_executable = True)`,
+			ruleArgs: `, executable = True`,
+			build:    `r(name = "x")`,
+			actions: []string{
+				`//pkg:x: FileWrite outputs=[//pkg:x] content="#!/bin/sh" ` +
+					`executable`,
+			},
+			files:      []string{"pkg/x"},
+			executable: "pkg/x",
+			providers:  []string{"DefaultInfo"},
+		},
+		{
+			name: "native executables",
+			impl: `
+    out = ctx.actions.declare_file("x.out")
+    ctx.actions.run(outputs = [out], executable = ctx.executable.bin)
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `cc_binary(name = "tool", srcs = ["tool.cc"])
+r(name = "x", bin = ":tool")`,
+			actions: []string{
+				`//pkg:x: Action outputs=[//pkg:x.out] tools=[//pkg:tool] ` +
+					`argv=["bazel-out/bin/pkg/tool"]`,
+			},
+			files:     []string{"pkg/x.out"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "native test and generic executables",
+			impl: `
+    out = ctx.actions.declare_file("x.out")
+    ctx.actions.run(outputs = [out], executable = ctx.executable.bin,
+                    arguments = [f.short_path for f in ctx.files.srcs])
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `cc_test(name = "t", srcs = ["t.cc"])
+sh_binary(name = "s", srcs = ["s.sh"])
+r(name = "x", srcs = [":t", ":s"], bin = ":s")`,
+			actions: []string{
+				`//pkg:x: Action outputs=[//pkg:x.out] tools=[//pkg:s] ` +
+					`argv=["bazel-out/bin/pkg/s" "pkg/t" "pkg/s"]`,
+			},
+			files:     []string{"pkg/x.out"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "providers",
+			impl: `
+    return [MyInfo(v = 1), DefaultInfo()]`,
+			build:     `r(name = "x")`,
+			actions:   []string{},
+			files:     []string{},
+			providers: []string{"MyInfo", "DefaultInfo"},
+		},
+		{
+			name: "duplicate providers",
+			impl: `
+    return [MyInfo(v = 1), MyInfo(v = 2)]`,
+			build: `r(name = "x")`,
+			err:   "multiple instances of MyInfo",
+		},
+		{
+			name: "legacy struct with providers",
+			impl: `
+    return struct(providers = [MyInfo(v = 1)])`,
+			build:     `r(name = "x")`,
+			actions:   []string{},
+			files:     []string{},
+			providers: []string{"MyInfo", "DefaultInfo"},
+		},
+		{
+			name: "legacy struct",
+			impl: `
+    return struct(v = 1)`,
+			build:     `r(name = "x")`,
+			actions:   []string{},
+			files:     []string{},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "declared output without action",
+			impl: `
+    out = ctx.actions.declare_file("x.txt")
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `r(name = "x")`,
+			err:   "declared output pkg/x.txt is not generated by any action",
+		},
+		{
+			name: "predeclared output without action",
+			impl: `
+    pass`,
+			build: `r(name = "x", out = "o.txt")`,
+			err:   "declared output pkg/o.txt is not generated by any action",
+		},
+		{
+			name: "cycle",
+			impl: `
+    pass`,
+			build: `r(name = "x", srcs = [":y"])
+r(name = "y", srcs = [":x"])`,
+			err: "cycle in dependency graph",
+		},
+		{
+			name: "genrule outs",
+			impl: `
+    out = ctx.actions.declare_file("x.out")
+    ctx.actions.run_shell(outputs = [out], inputs = ctx.files.srcs, command = "true")
+    return [DefaultInfo(files = depset([out]))]`,
+			build: `genrule(name = "g", outs = ["g1.h", "g2.h"], cmd = "touch $(OUTS)")
+r(name = "x", srcs = [":g", ":g2.h"])`,
+			actions: []string{
+				`//pkg:g: Genrule outputs=[//pkg:g1.h //pkg:g2.h] ` +
+					`argv=["/bin/bash" "-c" ` +
+					`"touch bazel-out/bin/pkg/g1.h bazel-out/bin/pkg/g2.h"]`,
+				`//pkg:x: Action inputs=[//pkg:g1.h //pkg:g2.h //pkg:g2.h] ` +
+					`outputs=[//pkg:x.out] argv=["/bin/bash" "-c" "true"]`,
+			},
+			files:     []string{"pkg/x.out"},
+			providers: []string{"DefaultInfo"},
+		},
+		{
+			name: "dependency on a user-defined rule",
+			impl: `
+    out = ctx.actions.declare_file(ctx.attr.name + ".out")
+    ctx.actions.write(out, str([f.short_path for f in ctx.files.srcs]))
+    return [DefaultInfo(files = depset([out])), MyInfo(v = len(ctx.attr.srcs))]`,
+			build: `r(name = "y", srcs = ["a.txt", "b.txt"])
+r(name = "x", srcs = [":y"])`,
+			actions: []string{
+				`//pkg:y: FileWrite outputs=[//pkg:y.out] ` +
+					`content="[\"pkg/a.txt\", \"pkg/b.txt\"]"`,
+				`//pkg:x: FileWrite outputs=[//pkg:x.out] ` +
+					`content="[\"pkg/y.out\"]"`,
+			},
+			files:     []string{"pkg/x.out"},
+			providers: []string{"DefaultInfo", "MyInfo"},
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//pkg:defs.bzl": fmt.Sprintf(analysisTestRule, testCase.impl,
+				testCase.ruleArgs),
+			"//pkg:BUILD":   "load(\":defs.bzl\", \"r\")\n" + testCase.build + "\n",
+			"//pkg:a.txt":   "",
+			"//pkg:b.txt":   "",
+			"//pkg:s.sh":    "",
+			"//pkg:t.cc":    "",
+			"//pkg:tool.cc": "",
+			"//pkg:tool.sh": "",
+			"//pkg:x.h.in":  "",
+		}
+		build, err := execFakeBuild(files, "//pkg:BUILD")
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.name, err)
+			continue
+		}
+		build.Configuration = &Configuration{}
+		err = build.Analyze()
+		if testCase.err != "" {
+			if err == nil {
+				t.Errorf("%v: Analyze() unexpectedly succeeded", testCase.name)
+			} else if !strings.Contains(err.Error(), testCase.err) {
+				t.Errorf("%v: Analyze() failed with %v; expected error "+
+					"containing %q", testCase.name, err, testCase.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Analyze() failed: %v", testCase.name, err)
+			continue
+		}
+
+		actions := []string{}
+		for _, action := range build.Actions.ActionList {
+			actions = append(actions, describeAction(action))
+		}
+		if !reflect.DeepEqual(actions, testCase.actions) {
+			t.Errorf("%v: actions = %q; expected %q", testCase.name, actions,
+				testCase.actions)
+		}
+
+		result := build.AnalysisResults[core.Label{Package: "pkg", Target: "x"}]
+		if result == nil {
+			t.Errorf("%v: no analysis result for //pkg:x", testCase.name)
+			continue
+		}
+		resultFiles := []string{}
+		for _, file := range result.Files() {
+			resultFiles = append(resultFiles, file.ShortPath())
+		}
+		sort.Strings(resultFiles)
+		if !reflect.DeepEqual(resultFiles, testCase.files) {
+			t.Errorf("%v: files = %q; expected %q", testCase.name, resultFiles,
+				testCase.files)
+		}
+		executable := ""
+		if file := result.Executable(); file != nil {
+			executable = file.ShortPath()
+		}
+		if executable != testCase.executable {
+			t.Errorf("%v: executable = %q; expected %q", testCase.name, executable,
+				testCase.executable)
+		}
+		providers := []string{}
+		for _, instance := range result.Providers {
+			providers = append(providers, instance.Provider().Name())
+		}
+		if !reflect.DeepEqual(providers, testCase.providers) {
+			t.Errorf("%v: providers = %q; expected %q", testCase.name, providers,
+				testCase.providers)
+		}
+	}
+}
+
+// analysisDepsTestRules is the .bzl file for the dependency check tests, which defines rules a,
+// b, and n (whose targets provide AInfo, BInfo, and nothing, respectively) and the rule r, whose
+// label attributes restrict the targets that they may refer to.
+const analysisDepsTestRules = `
+AInfo = provider()
+BInfo = provider()
+
+a = rule(implementation = lambda ctx: [AInfo()])
+b = rule(implementation = lambda ctx: [BInfo()])
+n = rule(implementation = lambda ctx: [])
+
+r = rule(implementation = lambda ctx: [], attrs = {
+    "srcs": attr.label_list(allow_files = [".cc"]),
+    "hdrs": attr.label_list(allow_files = (".h", ".hpp")),
+    "dep": attr.label(),
+    "a_deps": attr.label_list(providers = [AInfo]),
+    "ab_deps": attr.label_list(providers = ([AInfo], (BInfo,))),
+    "rule_deps": attr.label_list(allow_rules = ["a"]),
+    "rule_or_b_deps": attr.label_list(allow_rules = ("a",), providers = [BInfo]),
+})
+`
+
+func TestBuild_AnalyzeDependencyChecks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		build string
+
+		// err is a substring of the expected error (empty if valid).
+		err string
+	}{
+		{
+			name: "valid",
+			build: `r(name = "x", srcs = ["a.cc", ":g"], hdrs = ["a.h", "b.hpp"],
+    dep = ":ya", a_deps = [":ya", ":lib"], ab_deps = [":ya", ":yb"],
+    rule_deps = [":ya", ":alias_a"], rule_or_b_deps = [":ya", ":yb"])`,
+		},
+		{
+			name:  "wrong file type",
+			build: `r(name = "x", srcs = ["a.cc", "a.txt"])`,
+			err: "attribute srcs invalid: source file '//pkg:a.txt' is misplaced " +
+				"here (expected .cc)",
+		},
+		{
+			name:  "wrong file type from tuple",
+			build: `r(name = "x", hdrs = ["a.cc"])`,
+			err: "attribute hdrs invalid: source file '//pkg:a.cc' is misplaced " +
+				"here (expected .h, .hpp)",
+		},
+		{
+			name:  "wrong generated file type",
+			build: `r(name = "x", srcs = [":g.txt"])`,
+			err: "attribute srcs invalid: generated file '//pkg:g.txt' is " +
+				"misplaced here (expected .cc)",
+		},
+		{
+			name:  "file without allow_files",
+			build: `r(name = "x", dep = "a.txt")`,
+			err: "attribute dep invalid: source file '//pkg:a.txt' is misplaced " +
+				"here (expected no files)",
+		},
+		{
+			name:  "missing provider",
+			build: `r(name = "x", a_deps = [":yb"])`,
+			err: "attribute a_deps invalid: '//pkg:yb' does not have mandatory " +
+				"providers: 'AInfo'",
+		},
+		{
+			name:  "missing provider of filegroup",
+			build: `r(name = "x", a_deps = [":fg"])`,
+			err: "attribute a_deps invalid: '//pkg:fg' does not have mandatory " +
+				"providers: 'AInfo'",
+		},
+		{
+			name:  "missing provider alternatives",
+			build: `r(name = "x", ab_deps = [":yn"])`,
+			err: "attribute ab_deps invalid: '//pkg:yn' does not have mandatory " +
+				"providers: 'AInfo' or 'BInfo'",
+		},
+		{
+			name:  "rule not allowed",
+			build: `r(name = "x", rule_deps = [":yb"])`,
+			err: "attribute rule_deps invalid: rule '//pkg:yb' is misplaced here " +
+				"(expected a)",
+		},
+		{
+			name:  "rule not allowed without providers",
+			build: `r(name = "x", rule_or_b_deps = [":yn"])`,
+			err: "attribute rule_or_b_deps invalid: rule '//pkg:yn' is misplaced " +
+				"here (expected a)",
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE":   "",
+			"//pkg:defs.bzl": analysisDepsTestRules,
+			"//pkg:BUILD": `load(":defs.bzl", "a", "b", "n", "r")
+a(name = "ya")
+b(name = "yb")
+n(name = "yn")
+alias(name = "alias_a", actual = ":ya")
+genrule(name = "g", outs = ["g.cc", "g.txt"], cmd = "touch $(OUTS)")
+filegroup(name = "fg", srcs = ["a.txt"])
+cc_library(name = "lib")
+` + testCase.build + "\n",
+			"//pkg:a.cc":  "",
+			"//pkg:a.h":   "",
+			"//pkg:a.txt": "",
+			"//pkg:b.hpp": "",
+		}
+		build, err := execFakeBuild(files, "//pkg:BUILD")
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.name, err)
+			continue
+		}
+		build.Configuration = &Configuration{}
+		err = build.Analyze()
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%v: Analyze() failed: %v", testCase.name, err)
+			}
+		} else if err == nil {
+			t.Errorf("%v: Analyze() unexpectedly succeeded", testCase.name)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: Analyze() failed with %v; expected error containing %q",
+				testCase.name, err, testCase.err)
+		}
+	}
+}
+
+func TestBuild_AnalyzeGenrule(t *testing.T) {
+	testCases := []struct {
+		name  string
+		build string
+
+		// err is a substring of the expected error (empty if valid). Otherwise, actions are
+		// the descriptions of all the actions (in order).
+		err     string
+		actions []string
+	}{
+		{
+			name: "srcs and tools",
+			build: `genrule(name = "g", srcs = ["a.txt", ":y"], tools = ["tool.sh"],
+    outs = ["g.out"], cmd = "$(location tool.sh) $(SRCS) > $@")`,
+			actions: []string{
+				`//pkg:y: FileWrite outputs=[//pkg:y.out]`,
+				`//pkg:g: Genrule inputs=[//pkg:a.txt //pkg:y.out] ` +
+					`outputs=[//pkg:g.out] tools=[//pkg:tool.sh] ` +
+					`argv=["/bin/bash" "-c" "pkg/tool.sh pkg/a.txt ` +
+					`bazel-out/bin/pkg/y.out > bazel-out/bin/pkg/g.out"]`,
+			},
+		},
+		{
+			name: "generated inputs",
+			build: `
+genrule(name = "g2", srcs = [":g.out"], outs = ["g2.out"], cmd = "cp $< $@")
+genrule(name = "g", outs = ["g.out"], exec_tools = [":y"],
+    cmd = "$(location :y) $@")`,
+			actions: []string{
+				`//pkg:y: FileWrite outputs=[//pkg:y.out]`,
+				`//pkg:g: Genrule outputs=[//pkg:g.out] tools=[//pkg:y.out] ` +
+					`argv=["/bin/bash" "-c" ` +
+					`"bazel-out/bin/pkg/y.out bazel-out/bin/pkg/g.out"]`,
+				`//pkg:g2: Genrule inputs=[//pkg:g.out] outputs=[//pkg:g2.out] ` +
+					`argv=["/bin/bash" "-c" ` +
+					`"cp bazel-out/bin/pkg/g.out bazel-out/bin/pkg/g2.out"]`,
+			},
+		},
+		{
+			name: "cmd_bash",
+			build: `genrule(name = "g", outs = ["g.out"], cmd = "false",
+    cmd_bash = "touch $@")`,
+			actions: []string{
+				`//pkg:y: FileWrite outputs=[//pkg:y.out]`,
+				`//pkg:g: Genrule outputs=[//pkg:g.out] ` +
+					`argv=["/bin/bash" "-c" "touch bazel-out/bin/pkg/g.out"]`,
+			},
+		},
+		{
+			name:    "cmd_ps only",
+			build:   `genrule(name = "g", outs = ["g.out"], cmd_ps = "echo > $@")`,
+			actions: []string{`//pkg:y: FileWrite outputs=[//pkg:y.out]`},
+		},
+		{
+			name:  "undefined variable",
+			build: `genrule(name = "g", outs = ["g.out"], cmd = "$(FOO) > $@")`,
+			err:   "//pkg:g: attribute cmd invalid: $(FOO) not defined",
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//pkg:defs.bzl": `
+def _impl(ctx):
+    out = ctx.actions.declare_file(ctx.attr.name + ".out")
+    ctx.actions.write(out, "")
+    return [DefaultInfo(files = depset([out]))]
+
+r = rule(implementation = _impl)
+`,
+			"//pkg:BUILD": "load(\":defs.bzl\", \"r\")\nr(name = \"y\")\n" +
+				testCase.build + "\n",
+			"//pkg:a.txt":   "",
+			"//pkg:tool.sh": "",
+		}
+		build, err := execFakeBuild(files, "//pkg:BUILD")
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.name, err)
+			continue
+		}
+		build.Configuration = &Configuration{}
+		err = build.Analyze()
+		if testCase.err != "" {
+			if err == nil {
+				t.Errorf("%v: Analyze() unexpectedly succeeded", testCase.name)
+			} else if !strings.Contains(err.Error(), testCase.err) {
+				t.Errorf("%v: Analyze() failed with %v; expected error "+
+					"containing %q", testCase.name, err, testCase.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Analyze() failed: %v", testCase.name, err)
+			continue
+		}
+
+		actions := []string{}
+		for _, action := range build.Actions.ActionList {
+			actions = append(actions, describeAction(action))
+			for _, output := range action.Outputs {
+				if build.Actions.ForOutput(output) != action {
+					t.Errorf("%v: ForOutput(%v) isn't the action that "+
+						"generates it", testCase.name, output)
+				}
+			}
+		}
+		if !reflect.DeepEqual(actions, testCase.actions) {
+			t.Errorf("%v: actions = %q; expected %q", testCase.name, actions,
+				testCase.actions)
+		}
+	}
+}
@@ -5,11 +5,18 @@ package bazel // import "src.tricot.io/public/bazel2x/bazel"
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 
 	"go.starlark.net/starlark"
 
 	"src.tricot.io/public/bazel2x/bazel/builtins"
+	"src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
@@ -20,15 +27,50 @@ type loadCacheEntry struct {
 
 type Build struct {
 	sourceFileReader SourceFileReader
+	sourceDirReader  SourceDirReader
+
+	// sourceDirEntries caches the entries of source directories (keyed by workspace and
+	// slash-separated path, as "<workspace>//<path>"), mapping names to whether they're files.
+	sourceDirEntries map[string]map[string]bool
+
+	// pendingSourceFileRefs contains the references to labels in packages that haven't been
+	// loaded (yet), which may be source files (see addSourceFileTargets). It is keyed by
+	// package (as "<workspace>//<package>").
+	pendingSourceFileRefs map[string][]sourceFileRef
 
 	// loadCache caches the result of load statements. Its keys are labels (as strings).
 	loadCache map[string]*loadCacheEntry
 
+	// Registry contains the builtins from which the initial globals for executing files are
+	// made. It is the default registry (see builtins.NewDefaultRegistry) unless replaced or
+	// modified (e.g., to add rules) before any files are executed.
+	Registry *builtins.Registry
+
 	// WorkspaceName contains the name of the workspace (if any).
 	WorkspaceName core.WorkspaceName
 
 	// BuildTargets contains the output build targets.
 	BuildTargets core.BuildTargets
+
+	// Configuration is the configuration under which the build is analyzed (if any). If nil,
+	// select()s are left unresolved.
+	Configuration *Configuration
+
+	// Actions contains the actions registered by the targets analyzed (see AnalysisResults).
+	// Generated files can be looked up to find the actions that produce them.
+	Actions core.BuildActions
+
+	// Strict indicates that calls to builtins that we haven't implemented (yet) should fail,
+	// instead of doing nothing (or returning a placeholder value).
+	Strict bool
+
+	// NotImplementedCalls contains the calls to builtins that we haven't implemented (yet),
+	// in the order made (including the failed call, in strict mode).
+	NotImplementedCalls []core.NotImplementedCall
+
+	// AnalysisResults contains the results of analyzing the targets of user-defined rules
+	// (whose attributes are all resolved), keyed by label. It is populated by Analyze.
+	AnalysisResults map[core.Label]*analysis.Result
 }
 
 // ExecWorkspaceFile executes the WORKSPACE file (which always has label //:WORKSPACE). It should be
@@ -46,7 +88,204 @@ func (self *Build) ExecWorkspaceFile() error {
 // most once for each BUILD[.bazel] file.
 func (self *Build) ExecBuildFile(buildFileLabel core.Label) error {
 	self.BuildTargets.AddPackage(buildFileLabel.Workspace, buildFileLabel.Package)
-	return self.exec(buildFileLabel, core.FileTypeBuild)
+	if err := self.exec(buildFileLabel, core.FileTypeBuild); err != nil {
+		return err
+	}
+	if err := self.addOutputFileTargets(buildFileLabel.Workspace,
+		buildFileLabel.Package); err != nil {
+		return err
+	}
+	return self.addSourceFileTargets(buildFileLabel.Workspace, buildFileLabel.Package)
+}
+
+// addOutputFileTargets adds output file targets for the predeclared outputs of the targets in the
+// given package, which must be in the package.
+func (self *Build) addOutputFileTargets(workspace core.WorkspaceName,
+	packageName core.PackageName) error {
+
+	packageTargets := self.BuildTargets[workspace][packageName]
+	// Iterate over a copy, since targets are added to TargetList.
+	targets := append([]core.Target{}, packageTargets.TargetList...)
+	for _, target := range targets {
+		generatingTarget, ok := target.(core.GeneratingTarget)
+		if !ok {
+			continue
+		}
+		for _, label := range generatingTarget.OutputLabels() {
+			if label.Workspace != workspace || label.Package != packageName {
+				return fmt.Errorf("%v: output %v is not in package %v",
+					target.Label(), label, packageName)
+			}
+			err := packageTargets.Add(core.NewOutputFileTarget(label, target))
+			if err != nil {
+				return fmt.Errorf("%v: %v", target.Label(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// sourceFileRef is a reference (by a target's attribute) to a label that may be a source file.
+type sourceFileRef struct {
+	label    core.Label
+	referrer core.Label
+}
+
+// sourceFileExists returns whether the given label refers to an existing source file (rather than
+// a directory). The entries of source directories are cached.
+func (self *Build) sourceFileExists(label core.Label) (bool, error) {
+	filePath := path.Join(string(label.Package), string(label.Target))
+	dirPath := path.Dir(filePath)
+	if dirPath == "." {
+		dirPath = ""
+	}
+	key := string(label.Workspace) + "//" + dirPath
+	entries, ok := self.sourceDirEntries[key]
+	if !ok {
+		infos, err := self.sourceDirReader(label.Workspace, dirPath)
+		if err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to read directory %v: %v", dirPath, err)
+		}
+		entries = map[string]bool{}
+		for _, info := range infos {
+			entries[info.Name()] = !info.IsDir()
+		}
+		self.sourceDirEntries[key] = entries
+	}
+	return entries[path.Base(filePath)], nil
+}
+
+// addSourceFileTarget adds an (implicit) source file target for the given reference, if its label
+// isn't already a target, to its package (which must have been loaded). It fails if the label
+// refers to neither a target nor an existing file.
+func (self *Build) addSourceFileTarget(ref sourceFileRef) error {
+	packageTargets := self.BuildTargets[ref.label.Workspace][ref.label.Package]
+	if _, exists := packageTargets.TargetsByName[ref.label.Target]; exists {
+		return nil
+	}
+	exists, err := self.sourceFileExists(ref.label)
+	if err != nil {
+		return fmt.Errorf("%v: %v", ref.referrer, err)
+	}
+	if !exists {
+		return fmt.Errorf("%v: no such target '%v': target '%v' not declared in package "+
+			"'%v'", ref.referrer, ref.label, string(ref.label.Target),
+			string(ref.label.Package))
+	}
+	return packageTargets.Add(core.NewSourceFileTarget(ref.label))
+}
+
+// addSourceFileTargets adds (implicit) source file targets for the files referred to by the rules
+// in the given (just loaded) package, and for the files in the package referred to by the rules
+// in previously loaded packages. References to packages that haven't been loaded (yet) are only
+// checked once (and if) they're loaded.
+func (self *Build) addSourceFileTargets(workspace core.WorkspaceName,
+	packageName core.PackageName) error {
+
+	packageKey := string(workspace) + "//" + string(packageName)
+	refs := self.pendingSourceFileRefs[packageKey]
+	delete(self.pendingSourceFileRefs, packageKey)
+	for _, target := range self.BuildTargets[workspace][packageName].TargetList {
+		for _, label := range rules.TargetLabels(target) {
+			refs = append(refs, sourceFileRef{label: label, referrer: target.Label()})
+		}
+	}
+
+	for _, ref := range refs {
+		if self.BuildTargets[ref.label.Workspace][ref.label.Package] == nil {
+			key := string(ref.label.Workspace) + "//" + string(ref.label.Package)
+			pending := self.pendingSourceFileRefs
+			pending[key] = append(pending[key], ref)
+			continue
+		}
+		if err := self.addSourceFileTarget(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachTarget calls fn for each target in BuildTargets, in a deterministic order (stopping if fn
+// returns an error).
+func (self *Build) forEachTarget(fn func(target core.Target) error) error {
+	workspaceNames := make([]string, 0, len(self.BuildTargets))
+	for workspaceName := range self.BuildTargets {
+		workspaceNames = append(workspaceNames, string(workspaceName))
+	}
+	sort.Strings(workspaceNames)
+
+	for _, workspaceName := range workspaceNames {
+		workspaceTargets := self.BuildTargets[core.WorkspaceName(workspaceName)]
+		packageNames := make([]string, 0, len(workspaceTargets))
+		for packageName := range workspaceTargets {
+			packageNames = append(packageNames, string(packageName))
+		}
+		sort.Strings(packageNames)
+
+		for _, packageName := range packageNames {
+			packageTargets := workspaceTargets[core.PackageName(packageName)]
+			for _, target := range packageTargets.TargetList {
+				if err := fn(target); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// recordNotImplementedCall records a use of something that we haven't implemented (yet) in
+// NotImplementedCalls. It returns an error in strict mode.
+func (self *Build) recordNotImplementedCall(call core.NotImplementedCall) error {
+	self.NotImplementedCalls = append(self.NotImplementedCalls, call)
+	if self.Strict {
+		return fmt.Errorf("%v is not implemented (strict mode)", call.Builtin)
+	}
+	return nil
+}
+
+// Analyze analyzes the build. It should be called exactly once, after all the BUILD[.bazel] files
+// have been executed. This resolves the values of attributes given using select() for
+// Configuration (if set), and then runs the implementation functions of the user-defined rules (see
+// AnalysisResults) and records the actions of genrules (see Actions).
+func (self *Build) Analyze() error {
+	if self.Configuration != nil {
+		if err := self.resolveConfigurables(); err != nil {
+			return err
+		}
+	}
+	return self.analyzeTargets()
+}
+
+// resolveConfigurables resolves the values of attributes given using select() for Configuration.
+func (self *Build) resolveConfigurables() error {
+	matcher, err := self.newConfigurationMatcher(self.Configuration)
+	if err != nil {
+		return err
+	}
+	return self.forEachTarget(func(target core.Target) error {
+		configurableTarget, ok := target.(builtins_args.ResolvedArgsTarget)
+		if !ok || len(configurableTarget.Configurables()) == 0 {
+			return nil
+		}
+		// The resolved values are checked in the context of the target's package (as if
+		// in its BUILD file).
+		label := target.Label()
+		ctx := &ContextImpl{
+			build: self,
+			label: core.Label{
+				Workspace: label.Workspace,
+				Package:   label.Package,
+				Target:    "BUILD",
+			},
+			fileType: core.FileTypeBuild,
+		}
+		if err := builtins_args.ResolveConfigurables(configurableTarget, matcher,
+			ctx); err != nil {
+			return fmt.Errorf("%v: %v", label, err)
+		}
+		return nil
+	})
 }
 
 // exec executes the file specified by moduleLabel, of the given file type (which should be
@@ -60,7 +299,16 @@ func (self *Build) exec(moduleLabel core.Label, fileType core.FileType) error {
 	}
 
 	_, err = starlark.ExecFile(thread, moduleLabel.String(), sourceData,
-		builtins.InitialGlobals(fileType))
+		self.Registry.InitialGlobals(fileType))
+	return withBacktrace(err)
+}
+
+// withBacktrace replaces a *starlark.EvalError with an error whose message includes the backtrace
+// (i.e., the locations of the calls that led to the error). Other errors are returned as-is.
+func withBacktrace(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%v", evalErr.Backtrace())
+	}
 	return err
 }
 
@@ -95,16 +343,36 @@ func (self *Build) load(ctx core.Context, moduleLabel core.Label) (starlark.Stri
 
 	thread := createThread(self, moduleLabel, core.FileTypeBzl)
 	globals, err := starlark.ExecFile(thread, moduleLabelString, sourceData,
-		builtins.InitialGlobals(core.FileTypeBzl))
+		self.Registry.InitialGlobals(core.FileTypeBzl))
+	err = withBacktrace(err)
+	if err == nil {
+		exportGlobals(globals)
+	}
 	self.loadCache[moduleLabelString] = &loadCacheEntry{globals, err}
 	return globals, err
 }
 
-func NewBuild(sourceFileReader SourceFileReader) *Build {
+// exportGlobals exports the (as yet unexported) exportable values (e.g., providers) among the
+// globals of a .bzl file, giving them the names of the globals.
+func exportGlobals(globals starlark.StringDict) {
+	for _, name := range globals.Keys() {
+		if exportable, ok := globals[name].(values.Exportable); ok &&
+			!exportable.IsExported() {
+			exportable.Export(name)
+		}
+	}
+}
+
+func NewBuild(sourceFileReader SourceFileReader, sourceDirReader SourceDirReader) *Build {
 	return &Build{
-		sourceFileReader: sourceFileReader,
-		loadCache:        make(map[string]*loadCacheEntry),
-		BuildTargets:     make(core.BuildTargets),
+		sourceFileReader:      sourceFileReader,
+		sourceDirReader:       sourceDirReader,
+		sourceDirEntries:      make(map[string]map[string]bool),
+		pendingSourceFileRefs: make(map[string][]sourceFileRef),
+		loadCache:             make(map[string]*loadCacheEntry),
+		Registry:              builtins.NewDefaultRegistry(),
+		BuildTargets:          make(core.BuildTargets),
+		AnalysisResults:       make(map[core.Label]*analysis.Result),
 	}
 }
 
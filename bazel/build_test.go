@@ -0,0 +1,1424 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.starlark.net/starlark"
+
+	. "src.tricot.io/public/bazel2x/bazel"
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+type fakeFileInfo struct {
+	name  string
+	isDir bool
+}
+
+func (self fakeFileInfo) Name() string       { return self.name }
+func (self fakeFileInfo) Size() int64        { return 0 }
+func (self fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (self fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (self fakeFileInfo) IsDir() bool        { return self.isDir }
+func (self fakeFileInfo) Sys() interface{}   { return nil }
+
+// newFakeBuild creates a Build whose source files are given by files (keyed by label, as strings),
+// and whose source directories contain just those files (and their parent directories).
+func newFakeBuild(files map[string]string) *Build {
+	// trees contains the entries of the directories (keyed by path) of each workspace, mapping
+	// names to whether they're directories.
+	trees := map[core.WorkspaceName]map[string]map[string]bool{}
+	for file := range files {
+		label, err := core.ParseLabel("", "", file)
+		if err != nil {
+			panic(err)
+		}
+		if trees[label.Workspace] == nil {
+			trees[label.Workspace] = map[string]map[string]bool{}
+		}
+		tree := trees[label.Workspace]
+		isDir := false
+		for p := path.Join(string(label.Package), string(label.Target)); p != "."; p =
+			path.Dir(p) {
+
+			dir := path.Dir(p)
+			if dir == "." {
+				dir = ""
+			}
+			if tree[dir] == nil {
+				tree[dir] = map[string]bool{}
+			}
+			tree[dir][path.Base(p)] = isDir
+			isDir = true
+		}
+	}
+
+	return NewBuild(
+		func(sourceFileLabel core.Label) ([]byte, error) {
+			data, ok := files[sourceFileLabel.String()]
+			if !ok {
+				return nil, fmt.Errorf("%v not found", sourceFileLabel)
+			}
+			return []byte(data), nil
+		},
+		func(workspace core.WorkspaceName, dirPath string) ([]os.FileInfo, error) {
+			entries, ok := trees[workspace][dirPath]
+			if !ok {
+				return nil, &os.PathError{Op: "open", Path: dirPath,
+					Err: os.ErrNotExist}
+			}
+			rv := []os.FileInfo{}
+			for name, isDir := range entries {
+				rv = append(rv, fakeFileInfo{name, isDir})
+			}
+			sort.Slice(rv, func(i, j int) bool { return rv[i].Name() < rv[j].Name() })
+			return rv, nil
+		})
+}
+
+// execFakeBuild creates a fake build (see newFakeBuild) and executes its WORKSPACE file (which
+// must be given) and then the given BUILD files (given as labels).
+func execFakeBuild(files map[string]string, buildFiles ...string) (*Build, error) {
+	build := newFakeBuild(files)
+	if err := build.ExecWorkspaceFile(); err != nil {
+		return nil, err
+	}
+	for _, buildFile := range buildFiles {
+		buildFileLabel, err := core.ParseLabel("", "", buildFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := build.ExecBuildFile(buildFileLabel); err != nil {
+			return nil, err
+		}
+	}
+	return build, nil
+}
+
+func TestBuild_NotImplementedCalls(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "workspace(name = \"ws\")\nbind(name = \"x\", actual = \"//:y\")\n",
+		"//foo:BUILD": `load(":defs.bzl", "m")
+package_group(name = "pg")
+sh_test(name = "t", srcs = ["t.sh"])
+m(name = "p")
+`,
+		"//foo:defs.bzl": "def m(name):\n    native.py_library(name = name)\n",
+		"//foo:t.sh":     "",
+	}
+	buildFileLabel := core.Label{Package: "foo", Target: "BUILD"}
+
+	build := newFakeBuild(files)
+	if err := build.ExecWorkspaceFile(); err != nil {
+		t.Fatalf("ExecWorkspaceFile() failed: %v", err)
+	}
+	if err := build.ExecBuildFile(buildFileLabel); err != nil {
+		t.Fatalf("ExecBuildFile() failed: %v", err)
+	}
+	expected := []core.NotImplementedCall{
+		{Builtin: "bind", Location: "//:WORKSPACE:2:5"},
+		{Builtin: "package_group", Location: "//foo:BUILD:2:14"},
+		// Generic rules (for native rules that we don't model) are also recorded.
+		{Builtin: "sh_test", Location: "//foo:BUILD:3:8"},
+		{Builtin: "py_library", Location: "//foo:defs.bzl:2:22"},
+	}
+	if !reflect.DeepEqual(build.NotImplementedCalls, expected) {
+		t.Errorf("NotImplementedCalls = %v; expected %v", build.NotImplementedCalls,
+			expected)
+	}
+	target := build.BuildTargets[""]["foo"].TargetsByName["t"]
+	if _, ok := target.(*rules.GenericRuleTarget); !ok {
+		t.Errorf("sh_test target not instantiated")
+	}
+
+	build = newFakeBuild(files)
+	build.Strict = true
+	if err := build.ExecWorkspaceFile(); err == nil {
+		t.Errorf("ExecWorkspaceFile() unexpectedly succeeded in strict mode")
+	}
+
+	files["//:WORKSPACE"] = ""
+	files["//foo:BUILD"] = "sh_test(name = \"t\", srcs = [\"t.sh\"])\n"
+	build = newFakeBuild(files)
+	build.Strict = true
+	if err := build.ExecWorkspaceFile(); err != nil {
+		t.Fatalf("ExecWorkspaceFile() failed: %v", err)
+	}
+	err := build.ExecBuildFile(buildFileLabel)
+	if err == nil || !strings.Contains(err.Error(), "sh_test is not implemented") {
+		t.Errorf("ExecBuildFile() in strict mode gave %v", err)
+	}
+}
+
+func TestBuild_ResolveConfigurablesChecksWholeValue(t *testing.T) {
+	testCases := []struct {
+		srcs  string
+		valid bool
+	}{
+		{`[] + select({"//conditions:default": ["a.txt"]})`, true},
+		{`select({"//conditions:default": []}) + ["a.txt"]`, true},
+		{`[] + select({"//conditions:default": []})`, false},
+		{`select({"//conditions:default": []})`, false},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:defs.bzl": `
+r = rule(implementation = lambda ctx: [], attrs = {
+    "srcs": attr.label_list(allow_files = True, allow_empty = False),
+})
+`,
+			"//foo:BUILD": `load(":defs.bzl", "r")
+r(name = "x", srcs = ` + testCase.srcs + `)
+`,
+			"//foo:a.txt": "",
+		}
+		build, err := execFakeBuild(files, "//foo:BUILD")
+		if err != nil {
+			t.Errorf("srcs = %v: executing failed: %v", testCase.srcs, err)
+			continue
+		}
+		build.Configuration = &Configuration{}
+		err = build.Analyze()
+		if testCase.valid && err != nil {
+			t.Errorf("srcs = %v: Analyze() failed: %v", testCase.srcs, err)
+		} else if !testCase.valid && err == nil {
+			t.Errorf("srcs = %v: Analyze() unexpectedly succeeded", testCase.srcs)
+		}
+	}
+}
+
+func TestBuild_AnalyzeOwnOutputAsDependency(t *testing.T) {
+	testCases := []struct {
+		build string
+		valid bool
+	}{
+		{`r(name = "x", out = "o.txt", srcs = ["a.txt"])`, true},
+		{`r(name = "x", out = "o.txt", srcs = ["o.txt"])`, false},
+		{`r(name = "x", out = "o.txt", srcs = [":o.txt", "a.txt"])`, false},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:defs.bzl": `
+def _impl(ctx):
+    ctx.actions.write(ctx.outputs.out, str(len(ctx.files.srcs)))
+
+r = rule(implementation = _impl, attrs = {
+    "out": attr.output(),
+    "srcs": attr.label_list(allow_files = True),
+})
+`,
+			"//foo:BUILD": "load(\":defs.bzl\", \"r\")\n" + testCase.build + "\n",
+			"//foo:a.txt": "",
+		}
+		build, err := execFakeBuild(files, "//foo:BUILD")
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.build, err)
+			continue
+		}
+		build.Configuration = &Configuration{}
+		err = build.Analyze()
+		if testCase.valid && err != nil {
+			t.Errorf("%v: Analyze() failed: %v", testCase.build, err)
+		} else if !testCase.valid {
+			if err == nil {
+				t.Errorf("%v: Analyze() unexpectedly succeeded", testCase.build)
+			} else if !strings.Contains(err.Error(), "cycle") {
+				t.Errorf("%v: Analyze() failed with unexpected error: %v",
+					testCase.build, err)
+			}
+		}
+	}
+}
+
+func TestBuild_AnalyzeGenericRuleDependencies(t *testing.T) {
+	testCases := []struct {
+		srcs   string
+		files  string
+		strict bool
+		valid  bool
+		// notImplemented is the builtin recorded as not implemented (if any).
+		notImplemented string
+	}{
+		{`[":fg"]`, `["foo/a.txt", "foo/b.txt"]`, false, true, ""},
+		{`[":al", "c.txt"]`, `["foo/a.txt", "foo/b.txt", "foo/c.txt"]`, false, true, ""},
+		{`[":al2"]`, `["foo/g.txt"]`, false, true, ""},
+		{`[":fg2"]`, `["foo/a.txt", "foo/b.txt", "foo/g.txt"]`, false, true, ""},
+		// Targets of kinds that we don't model get opaque results (with a warning).
+		{`[":lib"]`, `[]`, false, true, "depending on java_library targets"},
+		{`[":lib"]`, `[]`, true, false, "depending on java_library targets"},
+		{`[":fg", ":lib"]`, `["foo/a.txt", "foo/b.txt"]`, false, true,
+			"depending on java_library targets"},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:defs.bzl": `
+def _impl(ctx):
+    files = [f.short_path for f in ctx.files.srcs]
+    if files != ` + testCase.files + `:
+        fail("unexpected files %s" % files)
+
+r = rule(implementation = _impl, attrs = {
+    "srcs": attr.label_list(allow_files = True),
+})
+`,
+			"//foo:BUILD": `load(":defs.bzl", "r")
+filegroup(name = "fg", srcs = ["a.txt", "b.txt"])
+alias(name = "al", actual = ":fg")
+genrule(name = "gen", outs = ["g.txt"], cmd = "touch $@")
+alias(name = "al2", actual = ":gen")
+filegroup(name = "fg2", srcs = [":fg", "a.txt", ":g.txt"])
+java_library(name = "lib", srcs = ["A.java"])
+r(name = "x", srcs = ` + testCase.srcs + `)
+`,
+			"//foo:A.java": "",
+			"//foo:a.txt":  "",
+			"//foo:b.txt":  "",
+			"//foo:c.txt":  "",
+		}
+		build, err := execFakeBuild(files, "//foo:BUILD")
+		if err != nil {
+			t.Errorf("srcs = %v: executing failed: %v", testCase.srcs, err)
+			continue
+		}
+		// The instantiations of the generic rules were recorded; only consider the calls
+		// recorded by analysis.
+		numExecCalls := len(build.NotImplementedCalls)
+		build.Configuration = &Configuration{}
+		build.Strict = testCase.strict
+		err = build.Analyze()
+		if testCase.valid && err != nil {
+			t.Errorf("srcs = %v: Analyze() failed: %v", testCase.srcs, err)
+		} else if !testCase.valid && err == nil {
+			t.Errorf("srcs = %v: Analyze() unexpectedly succeeded", testCase.srcs)
+		}
+		notImplemented := ""
+		for _, call := range build.NotImplementedCalls[numExecCalls:] {
+			notImplemented = call.Builtin
+			if call.Location != "//foo:BUILD:7:13" {
+				t.Errorf("srcs = %v: not implemented call location %v",
+					testCase.srcs, call.Location)
+			}
+		}
+		if notImplemented != testCase.notImplemented {
+			t.Errorf("srcs = %v: not implemented builtin %q, expected %q",
+				testCase.srcs, notImplemented, testCase.notImplemented)
+		}
+	}
+}
+
+func TestBuild_CcTestEnvAndExecProperties(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:BUILD": `
+cc_test(
+    name = "t",
+    srcs = ["t.cc"],
+    env = {"A": "1"},
+    env_inherit = ["HOME"],
+    exec_properties = {"OSFamily": "Linux"},
+)
+platform(name = "p", exec_properties = {"container-image": "x"})
+`,
+		"//foo:t.cc": "",
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	targets := build.BuildTargets[""]["foo"].TargetsByName
+
+	ccTest := targets["t"].(*rules.CcTestTarget)
+	if env := ccTest.GetEnv(); !reflect.DeepEqual(env, map[string]string{"A": "1"}) {
+		t.Errorf("env = %v", env)
+	}
+	if envInherit := ccTest.GetEnvInherit(); !reflect.DeepEqual(envInherit,
+		[]string{"HOME"}) {
+		t.Errorf("env_inherit = %v", envInherit)
+	}
+	if execProperties := ccTest.GetExecProperties(); !reflect.DeepEqual(execProperties,
+		map[string]string{"OSFamily": "Linux"}) {
+		t.Errorf("cc_test exec_properties = %v", execProperties)
+	}
+
+	platform := targets["p"].(*rules.PlatformTarget)
+	if execProperties := platform.GetExecProperties(); !reflect.DeepEqual(execProperties,
+		map[string]string{"container-image": "x"}) {
+		t.Errorf("platform exec_properties = %v", execProperties)
+	}
+}
+
+func TestBuild_Genrule(t *testing.T) {
+	testCases := []struct {
+		build string
+		// err is a substring of the expected error (empty if valid).
+		err string
+	}{
+		{`genrule(name = "g", outs = ["g.h"], cmd = "touch $@")`, ""},
+		{`genrule(name = "g", outs = ["g.h"], cmd_bash = "touch $@")`, ""},
+		{`genrule(name = "g", outs = ["g.h"], cmd_ps = "echo > $@")`, ""},
+		{`genrule(name = "g", outs = ["g.h"],
+    cmd = select({"//conditions:default": "touch $@"}))`, ""},
+		{`genrule(name = "g", outs = ["g.sh"], executable = True, cmd = "touch $@")`, ""},
+		{`genrule(name = "g", outs = [], cmd = "true")`, "outs invalid: must be non-empty"},
+		{`genrule(name = "g", cmd = "true")`, "outs required"},
+		{`genrule(name = "g", outs = ["a", "b"], executable = True, cmd = "touch $(OUTS)")`,
+			"must contain exactly one output if executable is set"},
+		{`genrule(name = "g", outs = ["g.h"])`, "one of cmd, cmd_bash, cmd_bat, or cmd_ps"},
+		{`genrule(name = "g", outs = ["//bar:g.h"], cmd = "touch $@")`,
+			"output //bar:g.h is not in package //foo"},
+		{`genrule(name = "g", outs = ["../g.h"], cmd = "touch $@")`, "invalid label"},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:BUILD":  testCase.build + "\n",
+		}
+		_, err := execFakeBuild(files, "//foo:BUILD")
+		if testCase.err == "" && err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.build, err)
+		} else if testCase.err != "" && err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", testCase.build)
+		} else if err != nil && !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q",
+				testCase.build, err, testCase.err)
+		}
+	}
+}
+
+func TestBuild_GenruleOutputFileTargets(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:BUILD": `
+genrule(name = "g", outs = ["g.h", "sub/h.h"], cmd = "touch $(OUTS)")
+cc_library(name = "lib", hdrs = [":g.h", "sub/h.h"])
+`,
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	targets := build.BuildTargets[""]["foo"].TargetsByName
+
+	genrule := targets["g"]
+	for _, name := range []core.TargetName{"g.h", "sub/h.h"} {
+		output, ok := targets[name].(*core.OutputFileTarget)
+		if !ok {
+			t.Errorf("%v is not an output file target: %v", name, targets[name])
+		} else if output.GeneratingRule != genrule {
+			t.Errorf("%v is generated by %v; expected %v", name, output.GeneratingRule,
+				genrule)
+		}
+	}
+
+	expected := []core.Label{
+		{Package: "foo", Target: "g.h"},
+		{Package: "foo", Target: "sub/h.h"},
+	}
+	hdrs := targets["lib"].(*rules.CcLibraryTarget).GetHdrs()
+	if !reflect.DeepEqual(hdrs, expected) {
+		t.Errorf("hdrs = %v; expected %v", hdrs, expected)
+	}
+}
+
+func TestBuild_RuleArgValidation(t *testing.T) {
+	testCases := []struct {
+		rule string
+		args string
+		// err is the expected error (after the location and target prefix), or empty if
+		// valid.
+		err string
+	}{
+		{"cc_library", `alwayslink = True`, ""},
+		{"cc_library", `alwayslink = 1`, ""},
+		{"cc_library", `alwayslink = 0`, ""},
+		{"cc_library", `alwayslink = "yes"`,
+			"argument alwayslink invalid: expected value of type 'bool' instead of " +
+				"'string'"},
+		{"cc_library", `alwayslink = 2`, "argument alwayslink invalid: expected value of " +
+			"type 'bool' (True, False, 1, or 0) instead of 2"},
+		{"cc_test", `size = "small", timeout = "eternal"`, ""},
+		{"cc_test", `size = "huge"`, "argument size invalid: has to be one of \"small\", " +
+			"\"medium\", \"large\", \"enormous\" instead of \"huge\""},
+		{"cc_test", `timeout = "forever"`, "argument timeout invalid: has to be one of " +
+			"\"short\", \"moderate\", \"long\", \"eternal\" instead of \"forever\""},
+		{"cc_test", `shard_count = -1`,
+			"argument shard_count invalid: must not be negative"},
+		{"cc_binary", `stamp = -1`, ""},
+		{"cc_binary", `stamp = 1`, ""},
+		{"cc_binary", `stamp = 2`,
+			"argument stamp invalid: has to be -1, 0, or 1 instead of 2"},
+		{"cc_test", `stamp = -2`,
+			"argument stamp invalid: has to be -1, 0, or 1 instead of -2"},
+		{"cc_library", `srcs = ["a.cc", "b.cc"]`, ""},
+		{"cc_library", `srcs = ["a.cc", "b.cc", "a.cc"]`,
+			"argument srcs invalid: label //pkg:a.cc is duplicated"},
+		{"cc_library", `srcs = ["a.cc", ":a.cc"]`,
+			"argument srcs invalid: label //pkg:a.cc is duplicated"},
+		{"cc_library", `zz = 1`, "unknown keyword argument zz"},
+		{"cc_library", `zz = 1, aa = 2, mm = 3`, "unknown keyword arguments aa, mm, zz"},
+		{"cc_library", `includes = [".", "inc", ".."]`, ""},
+		{"cc_library", `includes = ["../.."]`, "argument includes invalid: invalid " +
+			"element: path \"../..\" points outside the workspace"},
+		{"cc_library", `includes = ["/usr/include"]`, "argument includes invalid: " +
+			"invalid element: path \"/usr/include\" points outside the workspace"},
+		{"cc_binary", `includes = ["inc/../../../x"]`, "argument includes invalid: " +
+			"invalid element: path \"inc/../../../x\" points outside the workspace"},
+		{"cc_library", `include_prefix = "foo/bar"`, ""},
+		{"cc_library", `include_prefix = "../foo"`,
+			"argument include_prefix invalid: should not contain uplevel references"},
+		{"cc_library", `include_prefix = "/foo"`,
+			"argument include_prefix invalid: should be a relative path"},
+	}
+	for _, testCase := range testCases {
+		call := testCase.rule + `(name = "x", ` + testCase.args + ")"
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//pkg:BUILD":  call + "\n",
+			"//pkg:a.cc":   "",
+			"//pkg:b.cc":   "",
+		}
+		_, err := execFakeBuild(files, "//pkg:BUILD")
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%v: executing failed: %v", call, err)
+			}
+			continue
+		}
+		// The location is only given by the backtrace.
+		expected := fmt.Sprintf("//pkg:BUILD:1:%v: in <toplevel>\nError in %v: in %v "+
+			"rule //pkg:x: %v", len(testCase.rule)+1, testCase.rule, testCase.rule,
+			testCase.err)
+		if err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", call)
+		} else if !strings.Contains(err.Error(), expected) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q", call,
+				err, expected)
+		}
+	}
+}
+
+func TestBuild_RuleArgValidationInMacro(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//pkg:defs.bzl": `
+def m(name):
+    native.cc_test(name = name, size = "huge")
+`,
+		"//pkg:BUILD": `load(":defs.bzl", "m")
+m(name = "x")
+`,
+	}
+	_, err := execFakeBuild(files, "//pkg:BUILD")
+	// The location of the call in the BUILD file is given, since the innermost frame of the
+	// backtrace is in the macro.
+	expected := "//pkg:defs.bzl:3:19: in m\nError in cc_test: //pkg:BUILD:2:2: in cc_test " +
+		"rule //pkg:x: argument size invalid"
+	if err == nil {
+		t.Errorf("executing unexpectedly succeeded")
+	} else if !strings.Contains(err.Error(), expected) {
+		t.Errorf("executing failed with %v; expected error containing %q", err, expected)
+	}
+}
+
+func TestBuild_ResolvedRuleArgValidation(t *testing.T) {
+	testCases := []struct {
+		call string
+		// err is the expected error (after the target's label), or empty if valid.
+		err string
+	}{
+		{`cc_test(name = "x", size = select({"//conditions:default": "small"}))`, ""},
+		{`cc_test(name = "x", size = select({"//conditions:default": "huge"}))`,
+			"argument size invalid: has to be one of"},
+		{`cc_binary(name = "x", stamp = select({"//conditions:default": 7}))`,
+			"argument stamp invalid: has to be -1, 0, or 1 instead of 7"},
+		{`cc_library(name = "x", ` +
+			`includes = select({"//conditions:default": ["../../.."]}))`,
+			"argument includes invalid"},
+		{`cc_library(name = "x", deps = [":a"] + select({"//conditions:default": [":b"]}))`,
+			""},
+		{`cc_library(name = "x", deps = [":a"] + select({"//conditions:default": [":a"]}))`,
+			"argument deps invalid: label //pkg:a is duplicated"},
+		{`genrule(name = "x", outs = ["x.out"], ` +
+			`cmd = select({"//conditions:default": None}))`,
+			"one of cmd, cmd_bash, cmd_bat, or cmd_ps must be given"},
+		{`r(name = "x", srcs = ["a.txt"] + select({"//conditions:default": ["a.txt"]}))`,
+			"argument srcs invalid: label //pkg:a.txt is duplicated"},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//pkg:defs.bzl": `
+r = rule(implementation = lambda ctx: [], attrs = {
+    "srcs": attr.label_list(allow_files = True),
+})
+`,
+			"//pkg:BUILD": "load(\":defs.bzl\", \"r\")\n" + testCase.call + "\n" +
+				"cc_library(name = \"a\")\ncc_library(name = \"b\")\n",
+			"//pkg:a.txt": "",
+		}
+		build, err := execFakeBuild(files, "//pkg:BUILD")
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.call, err)
+			continue
+		}
+		build.Configuration = &Configuration{}
+		err = build.Analyze()
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%v: Analyze() failed: %v", testCase.call, err)
+			}
+			continue
+		}
+		expected := "//pkg:x: " + testCase.err
+		if err == nil {
+			t.Errorf("%v: Analyze() unexpectedly succeeded", testCase.call)
+		} else if !strings.Contains(err.Error(), expected) {
+			t.Errorf("%v: Analyze() failed with %v; expected error containing %q",
+				testCase.call, err, expected)
+		}
+	}
+}
+
+func TestBuild_StarlarkRuleCommonAttrs(t *testing.T) {
+	testCases := []struct {
+		call string
+		// err is a substring of the expected error, or empty if valid.
+		err string
+	}{
+		{`r(name = "x", tags = ["t"], testonly = True, ` +
+			`visibility = ["//visibility:public"], toolchains = [":t"], ` +
+			`exec_properties = {"k": "v"})`, ""},
+		{`r(name = "x", deps = [":a"])`, "unknown keyword argument deps"},
+		{`r(name = "x", data = [":a"])`, "unknown keyword argument data"},
+		{`r_with_deps(name = "x", deps = [":a"], data = [":b"])`, ""},
+		{`r(name = "x", size = "small")`, "unknown keyword argument size"},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:defs.bzl": `
+r = rule(implementation = lambda ctx: [])
+r_with_deps = rule(implementation = lambda ctx: [], attrs = {
+    "deps": attr.label_list(),
+    "data": attr.label_list(allow_files = True),
+})
+`,
+			"//foo:BUILD": "load(\":defs.bzl\", \"r\", \"r_with_deps\")\n" +
+				testCase.call + "\nfilegroup(name = \"a\")\n" +
+				"filegroup(name = \"b\")\nfilegroup(name = \"t\")\n",
+		}
+		_, err := execFakeBuild(files, "//foo:BUILD")
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%v: executing failed: %v", testCase.call, err)
+			}
+		} else if err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", testCase.call)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q",
+				testCase.call, err, testCase.err)
+		}
+	}
+}
+
+func TestBuild_RuleNotImplementedArgs(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:defs.bzl": `
+r = rule(
+    implementation = lambda ctx: [],
+    fragments = ["cpp"],
+    analysis_test = False,
+    cfg = None,
+    provides = [],
+    toolchains = ["//foo:t"],
+)
+`,
+		"//foo:BUILD": "load(\":defs.bzl\", \"r\")\n",
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	expected := []core.NotImplementedCall{
+		{Builtin: "rule(toolchains)", Location: "//foo:defs.bzl:2:9"},
+		{Builtin: "rule(provides)", Location: "//foo:defs.bzl:2:9"},
+	}
+	if !reflect.DeepEqual(build.NotImplementedCalls, expected) {
+		t.Errorf("NotImplementedCalls = %v; expected %v", build.NotImplementedCalls,
+			expected)
+	}
+
+	build = newFakeBuild(files)
+	build.Strict = true
+	if err := build.ExecWorkspaceFile(); err != nil {
+		t.Fatalf("ExecWorkspaceFile() failed: %v", err)
+	}
+	err = build.ExecBuildFile(core.Label{Package: "foo", Target: "BUILD"})
+	if err == nil || !strings.Contains(err.Error(), "rule(toolchains) is not implemented") {
+		t.Errorf("ExecBuildFile() in strict mode gave %v", err)
+	}
+}
+
+// describeAttrProviders describes the required providers of an attribute schema (as lists of
+// provider names), for comparison in tests.
+func describeAttrProviders(providers [][]starlark.Value) [][]string {
+	rv := [][]string{}
+	for _, alternative := range providers {
+		names := []string{}
+		for _, provider := range alternative {
+			if p, ok := provider.(*values.Provider); ok {
+				names = append(names, p.Name())
+			} else {
+				names = append(names, provider.String())
+			}
+		}
+		rv = append(rv, names)
+	}
+	return rv
+}
+
+func TestBuild_AttrSchemas(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:defs.bzl": `
+AInfo = provider()
+BInfo = provider()
+
+r = rule(implementation = lambda ctx: [], attrs = {
+    "s": attr.string(values = ("a", "b")),
+    "i": attr.int(values = [1, 2]),
+    "files": attr.label_list(allow_files = (".cc", ".h")),
+    "single": attr.label(allow_single_file = [".txt"]),
+    "any_files": attr.label_list(allow_files = True),
+    "providers": attr.label_list(providers = (AInfo, BInfo)),
+    "alternatives": attr.label_list(providers = [(AInfo,), ["legacy"]]),
+    "rules": attr.label_list(allow_rules = ("cc_library", "r")),
+})
+`,
+		"//foo:BUILD": "load(\":defs.bzl\", \"r\")\nr(name = \"x\")\n",
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	rule := build.BuildTargets[""]["foo"].TargetsByName["x"].(*rules.StarlarkRuleTarget).Rule()
+
+	testCases := []struct {
+		attrName string
+
+		values           string
+		allowFiles       bool
+		allowedFileTypes []string
+		singleFile       bool
+		providers        [][]string
+		allowRules       []string
+	}{
+		{attrName: "s", values: `["a", "b"]`, providers: [][]string{}},
+		{attrName: "i", values: `[1, 2]`, providers: [][]string{}},
+		{
+			attrName:         "files",
+			allowFiles:       true,
+			allowedFileTypes: []string{".cc", ".h"},
+			providers:        [][]string{},
+		},
+		{
+			attrName:         "single",
+			allowFiles:       true,
+			allowedFileTypes: []string{".txt"},
+			singleFile:       true,
+			providers:        [][]string{},
+		},
+		{attrName: "any_files", allowFiles: true, providers: [][]string{}},
+		{attrName: "providers", providers: [][]string{{"AInfo", "BInfo"}}},
+		{attrName: "alternatives", providers: [][]string{{"AInfo"}, {`"legacy"`}}},
+		{
+			attrName:   "rules",
+			providers:  [][]string{},
+			allowRules: []string{"cc_library", "r"},
+		},
+	}
+	for _, testCase := range testCases {
+		attr := rule.Attr(testCase.attrName)
+		if attr == nil {
+			t.Errorf("%v: no such attribute", testCase.attrName)
+			continue
+		}
+		attrValues := ""
+		if attr.Values != nil {
+			attrValues = starlark.NewList(attr.Values).String()
+		}
+		if attrValues != testCase.values {
+			t.Errorf("%v: Values = %v; expected %v", testCase.attrName, attrValues,
+				testCase.values)
+		}
+		if attr.AllowFiles != testCase.allowFiles ||
+			!reflect.DeepEqual(attr.AllowedFileTypes, testCase.allowedFileTypes) ||
+			attr.SingleFile != testCase.singleFile {
+
+			t.Errorf("%v: AllowFiles, AllowedFileTypes, SingleFile = %v, %q, %v; "+
+				"expected %v, %q, %v", testCase.attrName, attr.AllowFiles,
+				attr.AllowedFileTypes, attr.SingleFile, testCase.allowFiles,
+				testCase.allowedFileTypes, testCase.singleFile)
+		}
+		if providers := describeAttrProviders(attr.Providers); !reflect.DeepEqual(providers,
+			testCase.providers) {
+
+			t.Errorf("%v: Providers = %q; expected %q", testCase.attrName, providers,
+				testCase.providers)
+		}
+		if !reflect.DeepEqual(attr.AllowRules, testCase.allowRules) {
+			t.Errorf("%v: AllowRules = %q; expected %q", testCase.attrName,
+				attr.AllowRules, testCase.allowRules)
+		}
+	}
+}
+
+func TestBuild_AttrSchemaErrors(t *testing.T) {
+	testCases := []struct {
+		attr string
+		err  string
+	}{
+		{`attr.string(values = "a")`, "argument values invalid: value is not a list"},
+		{
+			`attr.label_list(allow_files = ".cc")`,
+			"argument allow_files invalid: value is not a bool or list",
+		},
+		{
+			`attr.label_list(allow_files = (".cc", 1))`,
+			"argument allow_files invalid: invalid element: value is not a string",
+		},
+		{
+			`attr.label_list(providers = (1,))`,
+			"argument providers invalid: invalid element: value is not a provider",
+		},
+		{
+			`attr.label_list(providers = [[], 1])`,
+			"argument providers invalid: invalid element: value is not a list",
+		},
+		{
+			`attr.label_list(allow_rules = "cc_library")`,
+			"argument allow_rules invalid: value is not a list",
+		},
+		{
+			`attr.label_list(aspects = (1,))`,
+			"argument aspects invalid: invalid element: value is not an aspect",
+		},
+		{`attr.string(allow_files = True)`, "unexpected keyword argument allow_files"},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:defs.bzl": "r = rule(implementation = lambda ctx: [], " +
+				"attrs = {\"a\": " + testCase.attr + "})\n",
+			"//foo:BUILD": "load(\":defs.bzl\", \"r\")\n",
+		}
+		_, err := execFakeBuild(files, "//foo:BUILD")
+		if err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", testCase.attr)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q",
+				testCase.attr, err, testCase.err)
+		}
+	}
+}
+
+func TestBuild_Aspect(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:defs.bzl": `
+AInfo = provider()
+
+a = aspect(
+    implementation = lambda target, ctx: [],
+    attr_aspects = ("deps",),
+    attrs = {
+        "mode": attr.string(values = ("x", "y")),
+        "_tool": attr.label(allow_single_file = (".sh",)),
+    },
+    doc = "An aspect.",
+)
+
+r = rule(implementation = lambda ctx: [], attrs = {
+    "deps": attr.label_list(aspects = (a,), providers = ((AInfo,),)),
+})
+`,
+		"//foo:BUILD": "load(\":defs.bzl\", \"r\")\nr(name = \"x\")\n",
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	rule := build.BuildTargets[""]["foo"].TargetsByName["x"].(*rules.StarlarkRuleTarget).Rule()
+	deps := rule.Attr("deps")
+	if providers := describeAttrProviders(deps.Providers); !reflect.DeepEqual(providers,
+		[][]string{{"AInfo"}}) {
+
+		t.Errorf("deps Providers = %q", providers)
+	}
+	if len(deps.Aspects) != 1 {
+		t.Fatalf("deps has %v aspects; expected 1", len(deps.Aspects))
+	}
+	aspect := deps.Aspects[0]
+	if aspect.Name() != "a" || aspect.Doc() != "An aspect." {
+		t.Errorf("aspect Name(), Doc() = %q, %q", aspect.Name(), aspect.Doc())
+	}
+	if !reflect.DeepEqual(aspect.AttrAspects(), []string{"deps"}) {
+		t.Errorf("aspect AttrAspects() = %q", aspect.AttrAspects())
+	}
+	if !reflect.DeepEqual(aspect.AttrNames(), []string{"mode", "_tool"}) {
+		t.Errorf("aspect AttrNames() = %q", aspect.AttrNames())
+	}
+	if mode := aspect.Attr("mode"); starlark.NewList(mode.Values).String() != `["x", "y"]` {
+		t.Errorf("aspect mode Values = %v", mode.Values)
+	}
+	if tool := aspect.Attr("_tool"); !tool.SingleFile ||
+		!reflect.DeepEqual(tool.AllowedFileTypes, []string{".sh"}) {
+
+		t.Errorf("aspect _tool SingleFile, AllowedFileTypes = %v, %q", tool.SingleFile,
+			tool.AllowedFileTypes)
+	}
+
+	errorTestCases := []struct {
+		aspect string
+		err    string
+	}{
+		{
+			`aspect(implementation = lambda target, ctx: [], ` +
+				`attrs = {"srcs": attr.label_list()})`,
+			"attribute srcs invalid: aspect parameters must be of type bool, int or " +
+				"string",
+		},
+		{
+			`aspect(implementation = lambda target, ctx: [], attr_aspects = "deps")`,
+			"argument attr_aspects invalid: value is not a list",
+		},
+		{
+			`aspect(implementation = lambda target, ctx: [], attrs = [])`,
+			"argument attrs invalid: value is not a dict",
+		},
+	}
+	for _, testCase := range errorTestCases {
+		files := map[string]string{
+			"//:WORKSPACE":   "",
+			"//foo:defs.bzl": "a = " + testCase.aspect + "\n",
+			"//foo:BUILD":    "load(\":defs.bzl\", \"a\")\n",
+		}
+		_, err := execFakeBuild(files, "//foo:BUILD")
+		if err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", testCase.aspect)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q",
+				testCase.aspect, err, testCase.err)
+		}
+	}
+}
+
+func TestBuild_RepositoryRule(t *testing.T) {
+	const repoBzl = `
+my_repo = repository_rule(
+    implementation = lambda ctx: None,
+    attrs = {
+        "mode": attr.string(values = ("a", "b"), mandatory = True),
+        "files": attr.label_list(allow_files = (".bzl",), allow_empty = False),
+    },
+    environ = ("PATH",),
+)
+`
+	testCases := []struct {
+		workspace string
+
+		// err is a substring of the expected error (empty if valid).
+		err string
+	}{
+		{workspace: `my_repo(name = "r", mode = "a")`},
+		{workspace: `my_repo(name = "r", mode = "b", files = ["//:x.bzl"])`},
+		{
+			workspace: `my_repo(name = "r", mode = "c")`,
+			err: `argument mode invalid: has to be one of ["a", "b"] ` +
+				`instead of "c"`,
+		},
+		{
+			workspace: `my_repo(name = "r", mode = "a", files = [])`,
+			err:       "argument files invalid: attribute must be non empty",
+		},
+		{
+			workspace: `my_repo(name = "r")`,
+			err:       "target argument mode required",
+		},
+		{
+			workspace: `my_repo(name = "r", mode = "a", other = 1)`,
+			err:       "unknown keyword argument other",
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "load(\"//:repo.bzl\", \"my_repo\")\n" +
+				testCase.workspace + "\n",
+			"//:repo.bzl": repoBzl,
+		}
+		_, err := execFakeBuild(files)
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%v: executing failed: %v", testCase.workspace, err)
+			}
+		} else if err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", testCase.workspace)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q",
+				testCase.workspace, err, testCase.err)
+		}
+	}
+
+	files := map[string]string{
+		"//:WORKSPACE": "load(\"//:repo.bzl\", \"my_repo\")\n",
+		"//:repo.bzl": "my_repo = repository_rule(implementation = lambda ctx: None, " +
+			"environ = \"PATH\")\n",
+	}
+	if _, err := execFakeBuild(files); err == nil ||
+		!strings.Contains(err.Error(), "argument environ invalid: value is not a list") {
+
+		t.Errorf("repository_rule with invalid environ gave %v", err)
+	}
+}
+
+func TestBuild_SourceFileTargets(t *testing.T) {
+	testCases := []struct {
+		name string
+		// buildFiles are the BUILD files to execute, in order.
+		buildFiles []string
+
+		// sourceFiles are the labels of the expected source file targets, if err is
+		// empty, and otherwise err is a substring of the expected error.
+		sourceFiles []string
+		err         string
+	}{
+		{
+			// Neither //b nor //c is loaded.
+			name:        "same package",
+			buildFiles:  []string{"//a:BUILD"},
+			sourceFiles: []string{"//a:a.txt", "//a:sub/s.txt"},
+		},
+		{
+			name:        "referenced package loaded first",
+			buildFiles:  []string{"//b:BUILD", "//a:BUILD"},
+			sourceFiles: []string{"//a:a.txt", "//a:sub/s.txt", "//b:b.txt"},
+		},
+		{
+			name:        "referenced package loaded later",
+			buildFiles:  []string{"//a:BUILD", "//b:BUILD"},
+			sourceFiles: []string{"//a:a.txt", "//a:sub/s.txt", "//b:b.txt"},
+		},
+		{
+			name:       "referenced packages loaded",
+			buildFiles: []string{"//a:BUILD", "//b:BUILD", "//c:BUILD"},
+			sourceFiles: []string{"//a:a.txt", "//a:sub/s.txt", "//b:b.txt",
+				"//c:c.txt"},
+		},
+		{
+			name:       "missing file",
+			buildFiles: []string{"//d:BUILD"},
+			err: "//d:x: no such target '//d:missing.txt': target 'missing.txt' " +
+				"not declared in package 'd'",
+		},
+		{
+			name:       "missing file in package loaded first",
+			buildFiles: []string{"//b:BUILD", "//e:BUILD"},
+			err: "//e:x: no such target '//b:missing.txt': target 'missing.txt' " +
+				"not declared in package 'b'",
+		},
+		{
+			name:       "missing file in package loaded later",
+			buildFiles: []string{"//e:BUILD", "//b:BUILD"},
+			err:        "//e:x: no such target '//b:missing.txt'",
+		},
+		{
+			name:       "directory",
+			buildFiles: []string{"//f:BUILD"},
+			err:        "//f:x: no such target '//f:sub'",
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//a:BUILD": `filegroup(name = "x", visibility = [":__pkg__"],
+    srcs = ["a.txt", "sub/s.txt", "//b:b.txt", "//b:y", "//c:c.txt"])`,
+			"//a:a.txt":     "",
+			"//a:sub/s.txt": "",
+			"//b:BUILD":     `filegroup(name = "y")`,
+			"//b:b.txt":     "",
+			"//c:BUILD":     "",
+			"//c:c.txt":     "",
+			"//d:BUILD":     `filegroup(name = "x", srcs = ["missing.txt"])`,
+			"//e:BUILD":     `filegroup(name = "x", srcs = ["//b:missing.txt"])`,
+			"//f:BUILD":     `filegroup(name = "x", srcs = ["sub"])`,
+			"//f:sub/s.txt": "",
+		}
+		build, err := execFakeBuild(files, testCase.buildFiles...)
+		if testCase.err != "" {
+			if err == nil {
+				t.Errorf("%v: executing unexpectedly succeeded", testCase.name)
+			} else if !strings.Contains(err.Error(), testCase.err) {
+				t.Errorf("%v: executing failed with %v; expected error "+
+					"containing %q", testCase.name, err, testCase.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.name, err)
+			continue
+		}
+		sourceFiles := []string{}
+		for _, packageTargets := range build.BuildTargets[""] {
+			for _, target := range packageTargets.TargetList {
+				if _, ok := target.(*core.SourceFileTarget); ok {
+					sourceFiles = append(sourceFiles, target.Label().String())
+				}
+			}
+		}
+		sort.Strings(sourceFiles)
+		if !reflect.DeepEqual(sourceFiles, testCase.sourceFiles) {
+			t.Errorf("%v: source file targets %q; expected %q", testCase.name,
+				sourceFiles, testCase.sourceFiles)
+		}
+	}
+}
+
+func TestBuild_SourceFileTargetsDirReaderError(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//a:BUILD":    `filegroup(name = "x", srcs = ["a.txt"])`,
+	}
+	build := NewBuild(
+		func(sourceFileLabel core.Label) ([]byte, error) {
+			return []byte(files[sourceFileLabel.String()]), nil
+		},
+		func(workspace core.WorkspaceName, dirPath string) ([]os.FileInfo, error) {
+			return nil, fmt.Errorf("permission denied")
+		})
+	if err := build.ExecWorkspaceFile(); err != nil {
+		t.Fatalf("ExecWorkspaceFile() failed: %v", err)
+	}
+	err := build.ExecBuildFile(core.Label{Package: "a", Target: "BUILD"})
+	expected := "//a:x: failed to read directory a: permission denied"
+	if err == nil || err.Error() != expected {
+		t.Errorf("ExecBuildFile() gave %v; expected %v", err, expected)
+	}
+}
+
+func TestBuild_PackageCallErrors(t *testing.T) {
+	testCases := []struct {
+		build string
+		// err is a substring of the expected error, or empty if valid.
+		err string
+	}{
+		{"package(default_testonly = True)\ncc_library(name = \"a\")\n", ""},
+		{"package()\npackage()\n", "can only be called once per package"},
+		{"cc_library(name = \"a\")\npackage()\n", "must be called before any rules"},
+		{"package(default_testonly = select({\"//conditions:default\": True}))\n",
+			"not configurable"},
+		{"package(default_hdrs_check = \"strict\")\n",
+			"unknown keyword argument default_hdrs_check"},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:BUILD":  testCase.build,
+		}
+		_, err := execFakeBuild(files, "//foo:BUILD")
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%q: executing failed: %v", testCase.build, err)
+			}
+		} else if err == nil {
+			t.Errorf("%q: executing unexpectedly succeeded", testCase.build)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%q: executing failed with %v; expected error containing %q",
+				testCase.build, err, testCase.err)
+		}
+	}
+}
+
+func TestBuild_PackageDefaults(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:BUILD": `
+package(
+    default_visibility = ["//visibility:public"],
+    default_testonly = True,
+    default_deprecation = "old",
+    features = ["f"],
+)
+cc_library(name = "inherits")
+cc_library(
+    name = "overrides",
+    visibility = ["//visibility:private"],
+    testonly = False,
+    deprecation = "older",
+    features = ["g"],
+)
+cc_library(name = "inherits_too")
+`,
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	targets := build.BuildTargets[""]["foo"].TargetsByName
+	public := []core.Label{{Workspace: "", Package: "visibility", Target: "public"}}
+	private := []core.Label{{Workspace: "", Package: "visibility", Target: "private"}}
+
+	testCases := []struct {
+		name        string
+		visibility  []core.Label
+		testonly    bool
+		deprecation string
+		features    []string
+	}{
+		{"inherits", public, true, "old", []string{"f"}},
+		{"overrides", private, false, "older", []string{"f", "g"}},
+		{"inherits_too", public, true, "old", []string{"f"}},
+	}
+	for _, testCase := range testCases {
+		target := targets[core.TargetName(testCase.name)].(*rules.CcLibraryTarget)
+		if visibility := target.GetVisibility(); !reflect.DeepEqual(visibility,
+			testCase.visibility) {
+			t.Errorf("%v: visibility = %v; expected %v", testCase.name, visibility,
+				testCase.visibility)
+		}
+		if testonly := target.GetTestonly(); testonly != testCase.testonly {
+			t.Errorf("%v: testonly = %v; expected %v", testCase.name, testonly,
+				testCase.testonly)
+		}
+		if deprecation := target.GetDeprecation(); deprecation != testCase.deprecation {
+			t.Errorf("%v: deprecation = %q; expected %q", testCase.name, deprecation,
+				testCase.deprecation)
+		}
+		if features := target.GetFeatures(); !reflect.DeepEqual(features,
+			testCase.features) {
+			t.Errorf("%v: features = %q; expected %q", testCase.name, features,
+				testCase.features)
+		}
+	}
+
+	// The defaults are copied, so modifying one target's values doesn't affect the others'.
+	inherits := targets["inherits"].(*rules.CcLibraryTarget)
+	(*inherits.Visibility)[0] = private[0]
+	*inherits.Testonly = false
+	*inherits.Deprecation = "new"
+	(*inherits.Features)[0] = "h"
+	inheritsToo := targets["inherits_too"].(*rules.CcLibraryTarget)
+	if !reflect.DeepEqual(inheritsToo.GetVisibility(), public) ||
+		!inheritsToo.GetTestonly() || inheritsToo.GetDeprecation() != "old" ||
+		!reflect.DeepEqual(inheritsToo.GetFeatures(), []string{"f"}) {
+		t.Errorf("modifying a target's defaults modified another's: visibility = %v, "+
+			"testonly = %v, deprecation = %q, features = %q",
+			inheritsToo.GetVisibility(), inheritsToo.GetTestonly(),
+			inheritsToo.GetDeprecation(), inheritsToo.GetFeatures())
+	}
+}
+
+func TestBuild_Fail(t *testing.T) {
+	testCases := []struct {
+		name  string
+		build string
+		// analyze indicates that the error is expected from Analyze (instead of from
+		// executing the BUILD file).
+		analyze bool
+
+		// err is the expected error message (including the backtrace).
+		err string
+	}{
+		{
+			name:  "msg",
+			build: `fail("oops")`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: oops",
+		},
+		{
+			name:  "msg keyword",
+			build: `fail(msg = "oops")`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: oops",
+		},
+		{
+			name:  "multiple args",
+			build: `fail("a", 1, [2], None, True)`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: a 1 [2] None True",
+		},
+		{
+			name:  "msg keyword and args",
+			build: `fail("b", msg = "a")`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: a b",
+		},
+		{
+			name:  "sep",
+			build: `fail("a", "b", sep = ", ")`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: a, b",
+		},
+		{
+			name:  "attr",
+			build: `fail("oops", attr = "srcs")`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: attribute srcs: oops",
+		},
+		{
+			name:  "in macro",
+			build: `m(name = "x")`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:2: in <toplevel>\n" +
+				"  //foo:defs.bzl:2:9: in m\n" +
+				"Error in fail: attribute x: bad",
+		},
+		{
+			name:    "in rule implementation",
+			build:   `r(name = "x")`,
+			analyze: true,
+			err: "//foo:x: Traceback (most recent call last):\n" +
+				"  //foo:defs.bzl:5:9: in _impl\n" +
+				"Error in fail: impl of x failed",
+		},
+		{
+			name:  "invalid sep",
+			build: `fail("a", sep = 1)`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: argument sep invalid: value is not a string",
+		},
+		{
+			name:  "unexpected keyword",
+			build: `fail("a", foo = 1)`,
+			err: "Traceback (most recent call last):\n" +
+				"  //foo:BUILD:2:5: in <toplevel>\n" +
+				"Error in fail: unexpected keyword argument foo",
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//foo:defs.bzl": `def m(name):
+    fail("bad", attr = name)
+
+def _impl(ctx):
+    fail("impl of %s failed" % ctx.label.name)
+
+r = rule(implementation = _impl)
+`,
+			"//foo:BUILD": "load(\":defs.bzl\", \"m\", \"r\")\n" + testCase.build +
+				"\n",
+		}
+		build, err := execFakeBuild(files, "//foo:BUILD")
+		if testCase.analyze {
+			if err != nil {
+				t.Errorf("%v: executing failed: %v", testCase.name, err)
+				continue
+			}
+			build.Configuration = &Configuration{}
+			err = build.Analyze()
+		}
+		if err == nil {
+			t.Errorf("%v: unexpectedly succeeded", testCase.name)
+		} else if err.Error() != testCase.err {
+			t.Errorf("%v: failed with %q; expected %q", testCase.name, err.Error(),
+				testCase.err)
+		}
+	}
+}
+
+func TestBuild_Provenance(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//foo:defs.bzl": `def inner(name, suffix):
+    native.cc_library(name = name + suffix)
+
+def outer(name):
+    inner(name = name + "_inner", suffix = "_lib")
+    native.cc_library(name = name + "_lib")
+
+def nameless(prefix):
+    native.cc_library(name = prefix + "_lib")
+`,
+		"//foo:BUILD": `load(":defs.bzl", "nameless", "outer")
+cc_library(name = "direct")
+outer(name = "o")
+nameless(prefix = "n")
+`,
+	}
+	build, err := execFakeBuild(files, "//foo:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+
+	testCases := []struct {
+		name              string
+		generatorName     string
+		generatorFunction string
+		generatorLocation string
+		location          string
+		// callStack are the names of the functions in the call stack (outermost first).
+		callStack []string
+	}{
+		{"direct", "", "", "", "//foo:BUILD:2:11", []string{"<toplevel>"}},
+		{"o_lib", "o", "outer", "//foo:BUILD:3:6", "//foo:BUILD:3:6",
+			[]string{"<toplevel>", "outer"}},
+		// The generator name is that of the macro called from the BUILD file (not that of
+		// the nested macro).
+		{"o_inner_lib", "o", "outer", "//foo:BUILD:3:6", "//foo:BUILD:3:6",
+			[]string{"<toplevel>", "outer", "inner"}},
+		// If the macro has no name argument, the generator name is the target's.
+		{"n_lib", "n_lib", "nameless", "//foo:BUILD:4:9", "//foo:BUILD:4:9",
+			[]string{"<toplevel>", "nameless"}},
+	}
+	targets := build.BuildTargets[""]["foo"].TargetsByName
+	for _, testCase := range testCases {
+		target, ok := targets[core.TargetName(testCase.name)]
+		if !ok {
+			t.Errorf("%v: no such target", testCase.name)
+			continue
+		}
+		provenance := target.Provenance()
+		if provenance.IsGenerated() != (testCase.generatorFunction != "") ||
+			provenance.GeneratorName != testCase.generatorName ||
+			provenance.GeneratorFunction != testCase.generatorFunction ||
+			provenance.GeneratorLocation != testCase.generatorLocation ||
+			provenance.Location() != testCase.location {
+			t.Errorf("%v: provenance has generator name %q, function %q, location %q "+
+				"and location %q; expected %q, %q, %q and %q", testCase.name,
+				provenance.GeneratorName, provenance.GeneratorFunction,
+				provenance.GeneratorLocation, provenance.Location(),
+				testCase.generatorName, testCase.generatorFunction,
+				testCase.generatorLocation, testCase.location)
+		}
+		callStack := []string{}
+		for _, frame := range provenance.CallStack {
+			callStack = append(callStack, frame.Name)
+		}
+		if !reflect.DeepEqual(callStack, testCase.callStack) {
+			t.Errorf("%v: call stack is %q; expected %q", testCase.name, callStack,
+				testCase.callStack)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package analysis // import "src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+
+import (
+	"fmt"
+	"path"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Actions is the ctx.actions value, which is used to declare files and register actions.
+type Actions struct {
+	ctx *RuleContext
+}
+
+var _ starlark.HasAttrs = (*Actions)(nil)
+
+func (self *Actions) String() string {
+	return fmt.Sprintf("<actions for %v>", self.ctx.label)
+}
+
+func (self *Actions) Type() string {
+	return "actions"
+}
+
+// Freeze does nothing, since the actions value itself is immutable.
+func (self *Actions) Freeze() {}
+
+func (self *Actions) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Actions) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", self.Type())
+}
+
+// declare declares a new generated file (or directory), which may be relative to a sibling file.
+func (self *Actions) declare(fnName string, isDirectory bool, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	filename := ""
+	var sibling starlark.Value = starlark.None
+	if err := starlark.UnpackArgs(fnName, args, kwargs, "filename", &filename, "sibling?",
+		&sibling); err != nil {
+		return nil, err
+	}
+
+	relPath := filename
+	if sibling != starlark.None {
+		siblingFile, ok := sibling.(*values.File)
+		if !ok {
+			return nil, fmt.Errorf("%v: argument sibling invalid: value is not a File",
+				fnName)
+		}
+		siblingLabel := siblingFile.Label()
+		if siblingLabel.Workspace != self.ctx.label.Workspace ||
+			siblingLabel.Package != self.ctx.label.Package {
+			return nil, fmt.Errorf("%v: argument sibling invalid: %v is not in "+
+				"package %v", fnName, siblingLabel, self.ctx.label.Package)
+		}
+		relPath = path.Join(path.Dir(string(siblingLabel.Target)), filename)
+	}
+
+	label := core.Label{
+		Workspace: self.ctx.label.Workspace,
+		Package:   self.ctx.label.Package,
+		Target:    core.TargetName(relPath),
+	}
+	if !label.IsValid() {
+		return nil, fmt.Errorf("%v: invalid filename %v", fnName, filename)
+	}
+	file, err := self.ctx.declareFile(label, isDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", fnName, err)
+	}
+	return file, nil
+}
+
+// toExecutable converts the executable argument of run (a File or a string) to a path, and
+// returns the File (if any).
+func toExecutable(value starlark.Value) (string, *values.File, error) {
+	switch v := value.(type) {
+	case *values.File:
+		return v.Path(), v, nil
+	case starlark.String:
+		return string(v), nil, nil
+	default:
+		return "", nil, fmt.Errorf("value is not a File or string")
+	}
+}
+
+// toArguments converts an arguments argument (a list of strings and Args) to a []string.
+func toArguments(value starlark.Value) ([]string, error) {
+	elems, err := toValues(value)
+	if err != nil {
+		return nil, err
+	}
+	rv := []string{}
+	for _, elem := range elems {
+		switch v := elem.(type) {
+		case starlark.String:
+			rv = append(rv, string(v))
+		case *Args:
+			v.Freeze()
+			rv = append(rv, v.Args()...)
+		default:
+			return nil, fmt.Errorf("invalid element: value is not a string or Args")
+		}
+	}
+	return rv, nil
+}
+
+// commonActionArgs contains the arguments common to run and run_shell.
+type commonActionArgs struct {
+	outputs            starlark.Value
+	inputs             starlark.Value
+	tools              starlark.Value
+	arguments          starlark.Value
+	mnemonic           string
+	progressMessage    string
+	useDefaultShellEnv bool
+	env                starlark.Value
+
+	// unusedKwargs are the values of the (accepted, but) unsupported arguments.
+	unusedKwargs [6]starlark.Value
+}
+
+// unpackPairs returns the parameter pairs (for starlark.UnpackArgs) for the arguments common to
+// run and run_shell, other than outputs (which is required, and must be given first).
+func (self *commonActionArgs) unpackPairs() []interface{} {
+	return []interface{}{
+		"inputs?", &self.inputs,
+		"tools?", &self.tools,
+		"arguments?", &self.arguments,
+		"mnemonic?", &self.mnemonic,
+		"progress_message?", &self.progressMessage,
+		"use_default_shell_env?", &self.useDefaultShellEnv,
+		"env?", &self.env,
+		// TODO(vtl): Support (some of) these.
+		"execution_requirements?", &self.unusedKwargs[0],
+		"input_manifests?", &self.unusedKwargs[1],
+		"exec_group?", &self.unusedKwargs[2],
+		"shadowed_action?", &self.unusedKwargs[3],
+		"resource_set?", &self.unusedKwargs[4],
+		"toolchain?", &self.unusedKwargs[5],
+	}
+}
+
+// toAction converts the arguments common to run and run_shell to an Action (with its Argv set to
+// the converted arguments).
+func (self *commonActionArgs) toAction(defaultMnemonic string) (*core.Action, error) {
+	rv := &core.Action{Mnemonic: self.mnemonic, ProgressMessage: self.progressMessage}
+	if rv.Mnemonic == "" {
+		rv.Mnemonic = defaultMnemonic
+	}
+
+	var err error
+	if rv.Outputs, err = toFileLabels(self.outputs); err != nil {
+		return nil, fmt.Errorf("argument outputs invalid: %v", err)
+	}
+	if len(rv.Outputs) == 0 {
+		return nil, fmt.Errorf("argument outputs invalid: must not be empty")
+	}
+	if rv.Inputs, err = toFileLabels(self.inputs); err != nil {
+		return nil, fmt.Errorf("argument inputs invalid: %v", err)
+	}
+	if rv.Tools, err = toFileLabels(self.tools); err != nil {
+		return nil, fmt.Errorf("argument tools invalid: %v", err)
+	}
+	if rv.Argv, err = toArguments(self.arguments); err != nil {
+		return nil, fmt.Errorf("argument arguments invalid: %v", err)
+	}
+	if rv.Env, err = toStringMap(self.env); err != nil {
+		return nil, fmt.Errorf("argument env invalid: %v", err)
+	}
+	return rv, nil
+}
+
+func (self *Actions) run(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	common := &commonActionArgs{}
+	var executable starlark.Value
+	var unusedInputsList starlark.Value
+	pairs := append([]interface{}{"outputs", &common.outputs, "executable", &executable},
+		common.unpackPairs()...)
+	pairs = append(pairs, "unused_inputs_list?", &unusedInputsList)
+	if err := starlark.UnpackArgs("run", args, kwargs, pairs...); err != nil {
+		return nil, err
+	}
+
+	action, err := common.toAction("Action")
+	if err != nil {
+		return nil, fmt.Errorf("run: %v", err)
+	}
+	executablePath, executableFile, err := toExecutable(executable)
+	if err != nil {
+		return nil, fmt.Errorf("run: argument executable invalid: %v", err)
+	}
+	if executableFile != nil {
+		action.Tools = append(action.Tools, executableFile.Label())
+	}
+	action.Argv = append([]string{executablePath}, action.Argv...)
+	if err := self.ctx.registerAction(action); err != nil {
+		return nil, fmt.Errorf("run: %v", err)
+	}
+	return starlark.None, nil
+}
+
+func (self *Actions) runShell(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	common := &commonActionArgs{}
+	var command starlark.Value
+	pairs := append([]interface{}{"outputs", &common.outputs, "command", &command},
+		common.unpackPairs()...)
+	if err := starlark.UnpackArgs("run_shell", args, kwargs, pairs...); err != nil {
+		return nil, err
+	}
+
+	action, err := common.toAction("Action")
+	if err != nil {
+		return nil, fmt.Errorf("run_shell: %v", err)
+	}
+	switch c := command.(type) {
+	case starlark.String:
+		// As in Bazel, the arguments are available to the command as $1, $2, etc.
+		action.Command = string(c)
+		argv := []string{"/bin/bash", "-c", action.Command}
+		if len(action.Argv) > 0 {
+			argv = append(argv, "")
+		}
+		action.Argv = append(argv, action.Argv...)
+	case *starlark.List:
+		// The (deprecated) list form gives the complete command line.
+		commandArgs, err := toArguments(c)
+		if err != nil {
+			return nil, fmt.Errorf("run_shell: argument command invalid: %v", err)
+		}
+		action.Argv = append(commandArgs, action.Argv...)
+	default:
+		return nil, fmt.Errorf("run_shell: argument command invalid: value is not a string")
+	}
+	if err := self.ctx.registerAction(action); err != nil {
+		return nil, fmt.Errorf("run_shell: %v", err)
+	}
+	return starlark.None, nil
+}
+
+func (self *Actions) write(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var output *values.File
+	var content starlark.Value
+	isExecutable := false
+	if err := starlark.UnpackArgs("write", args, kwargs, "output", &output, "content",
+		&content, "is_executable?", &isExecutable); err != nil {
+		return nil, err
+	}
+
+	action := &core.Action{
+		Mnemonic:     "FileWrite",
+		Outputs:      []core.Label{output.Label()},
+		IsExecutable: isExecutable,
+	}
+	switch c := content.(type) {
+	case starlark.String:
+		action.Content = string(c)
+	case *Args:
+		c.Freeze()
+		for _, arg := range c.Args() {
+			action.Content += arg + "\n"
+		}
+	default:
+		return nil, fmt.Errorf("write: argument content invalid: value is not a string " +
+			"or Args")
+	}
+	if err := self.ctx.registerAction(action); err != nil {
+		return nil, fmt.Errorf("write: %v", err)
+	}
+	return starlark.None, nil
+}
+
+func (self *Actions) expandTemplate(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var template, output *values.File
+	var substitutions starlark.Value = starlark.None
+	isExecutable := false
+	var computedSubstitutions starlark.Value
+	if err := starlark.UnpackArgs("expand_template", args, kwargs, "template", &template,
+		"output", &output, "substitutions?", &substitutions, "is_executable?",
+		&isExecutable, "computed_substitutions?", &computedSubstitutions); err != nil {
+		return nil, err
+	}
+
+	substitutionsMap, err := toStringMap(substitutions)
+	if err != nil {
+		return nil, fmt.Errorf("expand_template: argument substitutions invalid: %v", err)
+	}
+	action := &core.Action{
+		Mnemonic:      "TemplateExpand",
+		Inputs:        []core.Label{template.Label()},
+		Outputs:       []core.Label{output.Label()},
+		Substitutions: substitutionsMap,
+		IsExecutable:  isExecutable,
+	}
+	if err := self.ctx.registerAction(action); err != nil {
+		return nil, fmt.Errorf("expand_template: %v", err)
+	}
+	return starlark.None, nil
+}
+
+func (self *Actions) symlink(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var output *values.File
+	var targetFile, targetPath starlark.Value = starlark.None, starlark.None
+	isExecutable := false
+	progressMessage := ""
+	if err := starlark.UnpackArgs("symlink", args, kwargs, "output", &output,
+		"target_file?", &targetFile, "target_path?", &targetPath, "is_executable?",
+		&isExecutable, "progress_message?", &progressMessage); err != nil {
+		return nil, err
+	}
+
+	action := &core.Action{
+		Mnemonic:        "Symlink",
+		Outputs:         []core.Label{output.Label()},
+		IsExecutable:    isExecutable,
+		ProgressMessage: progressMessage,
+	}
+	switch {
+	case targetFile != starlark.None && targetPath == starlark.None:
+		file, ok := targetFile.(*values.File)
+		if !ok {
+			return nil, fmt.Errorf("symlink: argument target_file invalid: value is " +
+				"not a File")
+		}
+		action.Inputs = []core.Label{file.Label()}
+		action.Argv = []string{"ln", "-s", file.Path(), output.Path()}
+	case targetFile == starlark.None && targetPath != starlark.None:
+		p, ok := targetPath.(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("symlink: argument target_path invalid: value is " +
+				"not a string")
+		}
+		action.Argv = []string{"ln", "-s", string(p), output.Path()}
+	default:
+		return nil, fmt.Errorf("symlink: exactly one of target_file or target_path is " +
+			"required")
+	}
+	if err := self.ctx.registerAction(action); err != nil {
+		return nil, fmt.Errorf("symlink: %v", err)
+	}
+	return starlark.None, nil
+}
+
+func (self *Actions) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "args":
+		return newMethod(name, self, func(thread *starlark.Thread, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			if err := starlark.UnpackPositionalArgs(name, args, kwargs, 0); err != nil {
+				return nil, err
+			}
+			return &Args{}, nil
+		}), nil
+	case "declare_directory":
+		return newMethod(name, self, func(thread *starlark.Thread, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			return self.declare(name, true, args, kwargs)
+		}), nil
+	case "declare_file", "declare_symlink":
+		return newMethod(name, self, func(thread *starlark.Thread, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			return self.declare(name, false, args, kwargs)
+		}), nil
+	case "do_nothing":
+		return newMethod(name, self, func(thread *starlark.Thread, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			mnemonic := ""
+			var inputs starlark.Value
+			if err := starlark.UnpackArgs(name, args, kwargs, "mnemonic", &mnemonic,
+				"inputs?", &inputs); err != nil {
+				return nil, err
+			}
+			return starlark.None, nil
+		}), nil
+	case "expand_template":
+		return newMethod(name, self, self.expandTemplate), nil
+	case "run":
+		return newMethod(name, self, self.run), nil
+	case "run_shell":
+		return newMethod(name, self, self.runShell), nil
+	case "symlink":
+		return newMethod(name, self, self.symlink), nil
+	case "write":
+		return newMethod(name, self, self.write), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *Actions) AttrNames() []string {
+	return []string{"args", "declare_directory", "declare_file", "declare_symlink",
+		"do_nothing", "expand_template", "run", "run_shell", "symlink", "write"}
+}
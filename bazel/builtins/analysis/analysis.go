@@ -0,0 +1,282 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package analysis contains the emulation of Bazel's analysis phase for user-defined rules: their
+// implementation functions are run with an emulated ctx, and the actions that they register and
+// the providers that they return are recorded.
+package analysis // import "src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Result is the result of analyzing a target.
+type Result struct {
+	Label core.Label
+
+	// Kind is the kind of the target (see core.Target.Kind), e.g., "cc_library" or, for file
+	// targets, "source file" or "generated file".
+	Kind string
+
+	// IncompleteProviders indicates that the target may have providers other than those in
+	// Providers (e.g., if it's a target of a native rule that we don't model), so the
+	// providers required by attributes can't be checked.
+	IncompleteProviders bool
+
+	// Actions are the actions registered by the target's rule's implementation function (in
+	// the order registered).
+	Actions []*core.Action
+
+	// Providers are the provider instances of the target (including DefaultInfo).
+	Providers []*values.Struct
+}
+
+// Provider returns the instance of the given provider of the target (or nil if the target
+// doesn't have it).
+func (self *Result) Provider(provider *values.Provider) *values.Struct {
+	for _, instance := range self.Providers {
+		if instance.Provider() == provider {
+			return instance
+		}
+	}
+	return nil
+}
+
+// Files returns the target's default outputs (the files of its DefaultInfo).
+func (self *Result) Files() []*values.File {
+	defaultInfo := self.Provider(values.DefaultInfo)
+	if defaultInfo == nil {
+		return nil
+	}
+	files, _ := defaultInfo.Attr("files")
+	rv, _ := toFiles(files)
+	return rv
+}
+
+// Executable returns the target's executable (from its DefaultInfo), or nil if it has none.
+func (self *Result) Executable() *values.File {
+	defaultInfo := self.Provider(values.DefaultInfo)
+	if defaultInfo == nil {
+		return nil
+	}
+	executable, _ := defaultInfo.Attr("executable")
+	file, _ := executable.(*values.File)
+	return file
+}
+
+// newFilesDepset creates a depset of the given files (and the given transitive depsets).
+func newFilesDepset(files []*values.File, transitive []*values.Depset) (*values.Depset, error) {
+	elems := make([]starlark.Value, len(files))
+	for i, file := range files {
+		elems[i] = file
+	}
+	return values.NewDepset(values.DepsetOrderDefault, elems, transitive)
+}
+
+// newDefaultInfo creates a DefaultInfo instance with the given files (and executable, if not
+// nil).
+func newDefaultInfo(files []*values.File, executable *values.File) *values.Struct {
+	depset, err := newFilesDepset(files, nil)
+	if err != nil {
+		panic(err)
+	}
+	runfiles, err := newFilesDepset(nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	kwargs := []starlark.Tuple{
+		{starlark.String("files"), depset},
+		{starlark.String("default_runfiles"), &Runfiles{runfiles}},
+		{starlark.String("data_runfiles"), &Runfiles{runfiles}},
+	}
+	if executable != nil {
+		kwargs = append(kwargs, starlark.Tuple{starlark.String("executable"), executable})
+	}
+	rv, err := values.NewStruct(values.DefaultInfo, kwargs)
+	if err != nil {
+		panic(err)
+	}
+	return rv
+}
+
+// NewSourceFileResult creates the result for a source file target (or a label that doesn't refer
+// to any target, which is assumed to be a source file).
+func NewSourceFileResult(label core.Label) *Result {
+	return &Result{
+		Label: label,
+		Kind:  "source file",
+		Providers: []*values.Struct{
+			newDefaultInfo([]*values.File{values.NewSourceFile(label)}, nil),
+		},
+	}
+}
+
+// NewOutputFileResult creates the result for an output file target (i.e., a predeclared
+// generated file).
+func NewOutputFileResult(label core.Label) *Result {
+	return &Result{
+		Label: label,
+		Kind:  "generated file",
+		Providers: []*values.Struct{
+			newDefaultInfo([]*values.File{values.NewGeneratedFile(label, false)}, nil),
+		},
+	}
+}
+
+// NewGeneratedFilesResult creates the result for a target of a native rule that generates the
+// given (predeclared) outputs (e.g., a genrule), whose DefaultInfo has those files.
+func NewGeneratedFilesResult(label core.Label, outputs []core.Label) *Result {
+	files := make([]*values.File, len(outputs))
+	for i, output := range outputs {
+		files[i] = values.NewGeneratedFile(output, false)
+	}
+	return &Result{
+		Label:     label,
+		Providers: []*values.Struct{newDefaultInfo(files, nil)},
+	}
+}
+
+// NewExecutableResult creates the result for a target of a native executable rule (e.g., a
+// cc_binary or cc_test), whose DefaultInfo has the executable (a generated file with the same
+// label as the target) as its only file. Its other providers are unknown.
+func NewExecutableResult(label core.Label) *Result {
+	executable := values.NewGeneratedFile(label, false)
+	return &Result{
+		Label:               label,
+		IncompleteProviders: true,
+		Providers: []*values.Struct{
+			newDefaultInfo([]*values.File{executable}, executable),
+		},
+	}
+}
+
+// NewFilegroupResult creates the result for a filegroup target, whose DefaultInfo has the files of
+// (the DefaultInfos of) its srcs, given by srcResults.
+func NewFilegroupResult(label core.Label, srcResults []*Result) *Result {
+	files := []*values.File{}
+	seen := map[core.Label]bool{}
+	for _, srcResult := range srcResults {
+		for _, file := range srcResult.Files() {
+			if !seen[file.Label()] {
+				seen[file.Label()] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return &Result{
+		Label:     label,
+		Providers: []*values.Struct{newDefaultInfo(files, nil)},
+	}
+}
+
+// NewAliasResult creates the result for an alias target, which has the kind and providers of its
+// actual target (given by actualResult).
+func NewAliasResult(label core.Label, actualResult *Result) *Result {
+	return &Result{
+		Label:               label,
+		Kind:                actualResult.Kind,
+		IncompleteProviders: actualResult.IncompleteProviders,
+		Providers:           actualResult.Providers,
+	}
+}
+
+// NewOpaqueResult creates the result for a target whose outputs and providers are unknown (e.g., a
+// target of a native rule): it only has an empty DefaultInfo.
+func NewOpaqueResult(label core.Label) *Result {
+	return &Result{
+		Label:               label,
+		IncompleteProviders: true,
+		Providers:           []*values.Struct{newDefaultInfo(nil, nil)},
+	}
+}
+
+// newMethod creates a built-in method (bound to receiver).
+func newMethod(name string, receiver starlark.Value, fn func(thread *starlark.Thread,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)) *starlark.Builtin {
+
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, _ *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		return fn(thread, args, kwargs)
+	}).BindReceiver(receiver)
+}
+
+// toValues converts a list, tuple, or depset (or None) to its elements.
+func toValues(value starlark.Value) ([]starlark.Value, error) {
+	switch v := value.(type) {
+	case nil, starlark.NoneType:
+		return nil, nil
+	case *values.Depset:
+		return v.ToList(), nil
+	case starlark.Indexable:
+		if _, isString := v.(starlark.String); isString {
+			break
+		}
+		rv := make([]starlark.Value, v.Len())
+		for i := range rv {
+			rv[i] = v.Index(i)
+		}
+		return rv, nil
+	}
+	return nil, fmt.Errorf("value is not a list or depset")
+}
+
+// toFiles converts a list, tuple, or depset of Files (or None) to a []*values.File.
+func toFiles(value starlark.Value) ([]*values.File, error) {
+	elems, err := toValues(value)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]*values.File, len(elems))
+	for i, elem := range elems {
+		file, ok := elem.(*values.File)
+		if !ok {
+			return nil, fmt.Errorf("invalid element: value is not a File")
+		}
+		rv[i] = file
+	}
+	return rv, nil
+}
+
+// toFileLabels converts a list, tuple, or depset of Files (or None) to the files' labels.
+func toFileLabels(value starlark.Value) ([]core.Label, error) {
+	files, err := toFiles(value)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]core.Label, len(files))
+	for i, file := range files {
+		rv[i] = file.Label()
+	}
+	return rv, nil
+}
+
+// toStringMap converts a dict of strings (or None) to a map[string]string.
+func toStringMap(value starlark.Value) (map[string]string, error) {
+	if value == nil || value == starlark.None {
+		return nil, nil
+	}
+	d, ok := value.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("value is not a dict")
+	}
+	rv := make(map[string]string, d.Len())
+	for _, item := range d.Items() {
+		k, ok := item[0].(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid key: value is not a string")
+		}
+		v, ok := item[1].(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for key %v: value is not a string",
+				k)
+		}
+		rv[string(k)] = string(v)
+	}
+	return rv, nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package analysis // import "src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+)
+
+// Args is a Bazel Args value (as created by ctx.actions.args()): a command line (or part of one)
+// being built up. Unlike in Bazel, arguments are expanded eagerly (when they are added).
+type Args struct {
+	args   []string
+	frozen bool
+}
+
+var _ starlark.HasAttrs = (*Args)(nil)
+
+// Args returns the (expanded) arguments.
+func (self *Args) Args() []string {
+	return self.args
+}
+
+func (self *Args) String() string {
+	return fmt.Sprintf("<Args %q>", self.args)
+}
+
+func (self *Args) Type() string {
+	return "Args"
+}
+
+func (self *Args) Freeze() {
+	self.frozen = true
+}
+
+func (self *Args) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Args) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", self.Type())
+}
+
+// argToString converts a value to a command-line argument (files are converted to their paths).
+func argToString(value starlark.Value) string {
+	switch v := value.(type) {
+	case starlark.String:
+		return string(v)
+	case *values.File:
+		return v.Path()
+	default:
+		return value.String()
+	}
+}
+
+// formatArg formats an argument using a format string (which should contain "%s").
+func formatArg(format string, arg string) (string, error) {
+	if strings.Count(format, "%s") != 1 {
+		return "", fmt.Errorf("invalid format string %q: should contain %%s exactly once",
+			format)
+	}
+	return strings.Replace(format, "%s", arg, 1), nil
+}
+
+// expandValues expands a list or depset of values to arguments, applying map_each (if not None)
+// and format_each (if not empty), and removing duplicates if uniquify is set.
+func expandValues(thread *starlark.Thread, valuesValue starlark.Value,
+	mapEach starlark.Value, formatEach string, uniquify bool) ([]string, error) {
+
+	elems, err := toValues(valuesValue)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := []string{}
+	for _, elem := range elems {
+		mapped := []starlark.Value{elem}
+		if mapEach != nil && mapEach != starlark.None {
+			fn, ok := mapEach.(starlark.Callable)
+			if !ok {
+				return nil, fmt.Errorf("map_each is not callable")
+			}
+			result, err := starlark.Call(thread, fn, starlark.Tuple{elem}, nil)
+			if err != nil {
+				return nil, err
+			}
+			switch r := result.(type) {
+			case starlark.NoneType:
+				mapped = nil
+			case *starlark.List:
+				mapped, _ = toValues(r)
+			default:
+				mapped = []starlark.Value{r}
+			}
+		}
+		for _, m := range mapped {
+			arg := argToString(m)
+			if formatEach != "" {
+				if arg, err = formatArg(formatEach, arg); err != nil {
+					return nil, err
+				}
+			}
+			rv = append(rv, arg)
+		}
+	}
+
+	if uniquify {
+		seen := map[string]bool{}
+		unique := []string{}
+		for _, arg := range rv {
+			if !seen[arg] {
+				seen[arg] = true
+				unique = append(unique, arg)
+			}
+		}
+		rv = unique
+	}
+	return rv, nil
+}
+
+// splitArgNameAndValues handles the optional leading argument name of add, add_all, and
+// add_joined: if value is unbound, then argNameOrValue is the value.
+func splitArgNameAndValues(argNameOrValue starlark.Value, value starlark.Value) (string,
+	starlark.Value, error) {
+
+	if value == nil {
+		return "", argNameOrValue, nil
+	}
+	argName, ok := argNameOrValue.(starlark.String)
+	if !ok {
+		return "", nil, fmt.Errorf("argument name is not a string")
+	}
+	return string(argName), value, nil
+}
+
+func (self *Args) add(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var argNameOrValue, value starlark.Value
+	format := ""
+	if err := starlark.UnpackArgs("add", args, kwargs, "arg_name_or_value",
+		&argNameOrValue, "value?", &value, "format?", &format); err != nil {
+		return nil, err
+	}
+	argName, value, err := splitArgNameAndValues(argNameOrValue, value)
+	if err != nil {
+		return nil, fmt.Errorf("add: %v", err)
+	}
+
+	arg := argToString(value)
+	if format != "" {
+		if arg, err = formatArg(format, arg); err != nil {
+			return nil, fmt.Errorf("add: %v", err)
+		}
+	}
+	if argName != "" {
+		self.args = append(self.args, argName)
+	}
+	self.args = append(self.args, arg)
+	return self, nil
+}
+
+func (self *Args) addAll(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var argNameOrValues, valuesValue, mapEach starlark.Value
+	formatEach := ""
+	beforeEach := ""
+	omitIfEmpty := true
+	uniquify := false
+	// TODO(vtl): Support directory expansion.
+	expandDirectories := true
+	terminateWith := ""
+	allowClosure := false
+	if err := starlark.UnpackArgs("add_all", args, kwargs, "arg_name_or_values",
+		&argNameOrValues, "values?", &valuesValue, "map_each?", &mapEach, "format_each?",
+		&formatEach, "before_each?", &beforeEach, "omit_if_empty?", &omitIfEmpty,
+		"uniquify?", &uniquify, "expand_directories?", &expandDirectories,
+		"terminate_with?", &terminateWith, "allow_closure?", &allowClosure); err != nil {
+		return nil, err
+	}
+	argName, valuesValue, err := splitArgNameAndValues(argNameOrValues, valuesValue)
+	if err != nil {
+		return nil, fmt.Errorf("add_all: %v", err)
+	}
+
+	expanded, err := expandValues(thread, valuesValue, mapEach, formatEach, uniquify)
+	if err != nil {
+		return nil, fmt.Errorf("add_all: %v", err)
+	}
+	if len(expanded) == 0 && omitIfEmpty {
+		return self, nil
+	}
+	if argName != "" {
+		self.args = append(self.args, argName)
+	}
+	for _, arg := range expanded {
+		if beforeEach != "" {
+			self.args = append(self.args, beforeEach)
+		}
+		self.args = append(self.args, arg)
+	}
+	if terminateWith != "" {
+		self.args = append(self.args, terminateWith)
+	}
+	return self, nil
+}
+
+func (self *Args) addJoined(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var argNameOrValues, valuesValue, mapEach starlark.Value
+	joinWith := ""
+	formatEach := ""
+	formatJoined := ""
+	omitIfEmpty := true
+	uniquify := false
+	expandDirectories := true
+	allowClosure := false
+	if err := starlark.UnpackArgs("add_joined", args, kwargs, "arg_name_or_values",
+		&argNameOrValues, "values?", &valuesValue, "join_with", &joinWith, "map_each?",
+		&mapEach, "format_each?", &formatEach, "format_joined?", &formatJoined,
+		"omit_if_empty?", &omitIfEmpty, "uniquify?", &uniquify, "expand_directories?",
+		&expandDirectories, "allow_closure?", &allowClosure); err != nil {
+		return nil, err
+	}
+	argName, valuesValue, err := splitArgNameAndValues(argNameOrValues, valuesValue)
+	if err != nil {
+		return nil, fmt.Errorf("add_joined: %v", err)
+	}
+
+	expanded, err := expandValues(thread, valuesValue, mapEach, formatEach, uniquify)
+	if err != nil {
+		return nil, fmt.Errorf("add_joined: %v", err)
+	}
+	if len(expanded) == 0 && omitIfEmpty {
+		return self, nil
+	}
+	joined := strings.Join(expanded, joinWith)
+	if formatJoined != "" {
+		if joined, err = formatArg(formatJoined, joined); err != nil {
+			return nil, fmt.Errorf("add_joined: %v", err)
+		}
+	}
+	if argName != "" {
+		self.args = append(self.args, argName)
+	}
+	self.args = append(self.args, joined)
+	return self, nil
+}
+
+func (self *Args) Attr(name string) (starlark.Value, error) {
+	// mutator wraps methods that modify the Args.
+	mutator := func(fn func(thread *starlark.Thread, args starlark.Tuple,
+		kwargs []starlark.Tuple) (starlark.Value, error)) func(thread *starlark.Thread,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		return func(thread *starlark.Thread, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			if self.frozen {
+				return nil, fmt.Errorf("%v: cannot modify frozen Args", name)
+			}
+			return fn(thread, args, kwargs)
+		}
+	}
+
+	switch name {
+	case "add":
+		return newMethod(name, self, mutator(self.add)), nil
+	case "add_all":
+		return newMethod(name, self, mutator(self.addAll)), nil
+	case "add_joined":
+		return newMethod(name, self, mutator(self.addJoined)), nil
+	case "set_param_file_format", "use_param_file":
+		// TODO(vtl): Support param files (for now, arguments are always passed directly).
+		return newMethod(name, self, mutator(func(thread *starlark.Thread,
+			args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			return self, nil
+		})), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *Args) AttrNames() []string {
+	return []string{"add", "add_all", "add_joined", "set_param_file_format", "use_param_file"}
+}
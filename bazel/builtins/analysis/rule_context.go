@@ -0,0 +1,738 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package analysis // import "src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// ResolveFunc returns the (analysis) result for the target with the given label, which must
+// already have been analyzed.
+type ResolveFunc func(label core.Label) *Result
+
+// RuleContext is the ctx value given to the implementation function of a user-defined rule.
+type RuleContext struct {
+	label   core.Label
+	target  *rules.StarlarkRuleTarget
+	resolve ResolveFunc
+	result  *Result
+
+	workspaceName core.WorkspaceName
+
+	// declaredFiles are the files declared by the rule (in the order declared), including its
+	// predeclared outputs.
+	declaredFiles        []*values.File
+	declaredFilesByLabel map[core.Label]*values.File
+
+	// generated contains the labels of the declared files that are generated by (registered)
+	// actions.
+	generated map[core.Label]bool
+
+	// predeclaredOutputs are the files of the output attributes (in order of declaration), and
+	// executable is the rule's executable (for executable rules), if declared.
+	predeclaredOutputs []*values.File
+	executable         *values.File
+
+	attr    starlark.Value
+	actions *Actions
+}
+
+var _ starlark.HasAttrs = (*RuleContext)(nil)
+
+// newRuleContext creates a new RuleContext for the given target. It declares the files of the
+// target's output attributes and checks the targets referred to by its label attributes.
+func newRuleContext(workspaceName core.WorkspaceName, target *rules.StarlarkRuleTarget,
+	resolve ResolveFunc) (*RuleContext, error) {
+
+	label := target.Label()
+	rv := &RuleContext{
+		label:                label,
+		target:               target,
+		resolve:              resolve,
+		result:               &Result{Label: label, Kind: target.Kind()},
+		workspaceName:        workspaceName,
+		declaredFilesByLabel: make(map[core.Label]*values.File),
+		generated:            make(map[core.Label]bool),
+	}
+	rv.actions = &Actions{rv}
+
+	for _, attrName := range target.Rule().AttrNames() {
+		if !isOutputAttrType(target.Rule().Attr(attrName).AttrType) {
+			continue
+		}
+		value, _ := target.Attr(attrName)
+		for _, l := range labelsOf(value) {
+			file, err := rv.declareFile(l, false)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %v invalid: %v", attrName, err)
+			}
+			rv.predeclaredOutputs = append(rv.predeclaredOutputs, file)
+		}
+	}
+
+	if err := rv.checkDeps(); err != nil {
+		return nil, err
+	}
+	attr, err := rv.makeAttr()
+	if err != nil {
+		return nil, err
+	}
+	rv.attr = attr
+	return rv, nil
+}
+
+// isFileResult returns whether the result is for a (source or generated) file target.
+func isFileResult(result *Result) bool {
+	return result.Kind == "source file" || result.Kind == "generated file"
+}
+
+// hasAllowedFileType returns whether the file has one of the given file types (extensions).
+func hasAllowedFileType(label core.Label, fileTypes []string) bool {
+	for _, fileType := range fileTypes {
+		if strings.HasSuffix(string(label.Target), fileType) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeFileTypes describes the allowed file types for error messages.
+func describeFileTypes(fileTypes []string) string {
+	if len(fileTypes) == 0 {
+		return "no files"
+	}
+	return strings.Join(fileTypes, ", ")
+}
+
+// hasProviders returns whether the result has all of the given (required) providers. Legacy
+// (string) providers aren't supported, so they're assumed to be present.
+func hasProviders(result *Result, providers []starlark.Value) bool {
+	for _, provider := range providers {
+		if p, ok := provider.(*values.Provider); ok && result.Provider(p) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// describeProviders describes (one alternative of) required providers for error messages.
+func describeProviders(providers []starlark.Value) string {
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		if p, ok := provider.(*values.Provider); ok {
+			names[i] = "'" + p.Name() + "'"
+		} else {
+			names[i] = "'" + string(provider.(starlark.String)) + "'"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// hasRequiredProviders returns whether the result satisfies (any alternative of) the attribute's
+// required providers. It's always true if the attribute has no required providers, or if the
+// result's providers are incomplete (so that they can't be checked).
+func hasRequiredProviders(result *Result, attr *values.Attr) bool {
+	if attr.Providers == nil || result.IncompleteProviders {
+		return true
+	}
+	for _, alternative := range attr.Providers {
+		if hasProviders(result, alternative) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDep checks that the target with the given result may be referred to by the given label
+// attribute (as Bazel does, according to the attribute's allow_files, allow_rules, and providers).
+func checkDep(result *Result, attr *values.Attr) error {
+	if isFileResult(result) {
+		if !attr.AllowFiles {
+			return fmt.Errorf("%v '%v' is misplaced here (expected no files)",
+				result.Kind, result.Label)
+		}
+		if attr.AllowedFileTypes != nil &&
+			!hasAllowedFileType(result.Label, attr.AllowedFileTypes) {
+
+			return fmt.Errorf("%v '%v' is misplaced here (expected %v)", result.Kind,
+				result.Label, describeFileTypes(attr.AllowedFileTypes))
+		}
+		return nil
+	}
+
+	if attr.AllowRules != nil {
+		for _, kind := range attr.AllowRules {
+			if kind == result.Kind {
+				return nil
+			}
+		}
+		// Like Bazel, a rule that isn't allowed is still accepted if it has the required
+		// providers (if any).
+		if attr.Providers == nil || !hasRequiredProviders(result, attr) {
+			return fmt.Errorf("rule '%v' is misplaced here (expected %v)", result.Label,
+				strings.Join(attr.AllowRules, ", "))
+		}
+		return nil
+	}
+	if !hasRequiredProviders(result, attr) {
+		alternatives := make([]string, len(attr.Providers))
+		for i, alternative := range attr.Providers {
+			alternatives[i] = describeProviders(alternative)
+		}
+		return fmt.Errorf("'%v' does not have mandatory providers: %v", result.Label,
+			strings.Join(alternatives, " or "))
+	}
+	return nil
+}
+
+// checkDeps checks the targets referred to by the target's label attributes (see checkDep).
+func (self *RuleContext) checkDeps() error {
+	rule := self.target.Rule()
+	for _, attrName := range rule.AttrNames() {
+		attr := rule.Attr(attrName)
+		if !isLabelAttrType(attr.AttrType) {
+			continue
+		}
+		value, _ := self.target.Attr(attrName)
+		for _, label := range labelsOf(value) {
+			result, err := self.resolveLabel(label)
+			if err != nil {
+				return err
+			}
+			if err := checkDep(result, attr); err != nil {
+				return fmt.Errorf("attribute %v invalid: %v", attrName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// declareFile declares a generated file (or directory) with the given label, which must be in the
+// rule's package.
+func (self *RuleContext) declareFile(label core.Label, isDirectory bool) (*values.File, error) {
+	if label.Workspace != self.label.Workspace || label.Package != self.label.Package {
+		return nil, fmt.Errorf("file %v is not in package %v", label, self.label.Package)
+	}
+	file := values.NewGeneratedFile(label, isDirectory)
+	if _, exists := self.declaredFilesByLabel[label]; exists {
+		return nil, fmt.Errorf("file %v has already been declared", file.ShortPath())
+	}
+	self.declaredFiles = append(self.declaredFiles, file)
+	self.declaredFilesByLabel[label] = file
+	return file, nil
+}
+
+// registerAction registers an action, whose outputs must be files declared by the rule that
+// aren't generated by any other action.
+func (self *RuleContext) registerAction(action *core.Action) error {
+	for _, output := range action.Outputs {
+		if self.declaredFilesByLabel[output] == nil {
+			return fmt.Errorf("output %v was not declared by %v", output, self.label)
+		}
+		if self.generated[output] {
+			return fmt.Errorf("output %v is already generated by another action",
+				output)
+		}
+	}
+	for _, output := range action.Outputs {
+		self.generated[output] = true
+	}
+	action.Owner = self.label
+	self.result.Actions = append(self.result.Actions, action)
+	return nil
+}
+
+// getExecutable returns the rule's executable (declaring it if necessary).
+func (self *RuleContext) getExecutable() (*values.File, error) {
+	if self.executable == nil {
+		file, err := self.declareFile(self.label, false)
+		if err != nil {
+			return nil, err
+		}
+		self.executable = file
+	}
+	return self.executable, nil
+}
+
+// isLabelAttrType returns whether attributes of the given type refer to (dependency) targets.
+func isLabelAttrType(attrType values.AttrType) bool {
+	return attrType == values.AttrTypeLabel || attrType == values.AttrTypeLabelList ||
+		attrType == values.AttrTypeLabelKeyedStringDict
+}
+
+// isOutputAttrType returns whether attributes of the given type declare outputs.
+func isOutputAttrType(attrType values.AttrType) bool {
+	return attrType == values.AttrTypeOutput || attrType == values.AttrTypeOutputList
+}
+
+// resolveLabel returns the result for the given label, or an error if there's none (which
+// shouldn't happen if the dependencies were analyzed first).
+func (self *RuleContext) resolveLabel(label core.Label) (*Result, error) {
+	result := self.resolve(label)
+	if result == nil {
+		return nil, fmt.Errorf("dependency %v has not been analyzed", label)
+	}
+	return result, nil
+}
+
+// targetValue returns the Target value for the given label.
+func (self *RuleContext) targetValue(label core.Label) (starlark.Value, error) {
+	result, err := self.resolveLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	return NewTargetValue(result), nil
+}
+
+// labelsOf returns the labels in the (converted) value of a label, label_list, or
+// label_keyed_string_dict attribute.
+func labelsOf(value interface{}) []core.Label {
+	switch v := value.(type) {
+	case core.Label:
+		return []core.Label{v}
+	case []core.Label:
+		return v
+	case map[core.Label]string:
+		rv := make([]core.Label, 0, len(v))
+		for k := range v {
+			rv = append(rv, k)
+		}
+		sort.Slice(rv, func(i, j int) bool { return rv[i].String() < rv[j].String() })
+		return rv
+	default:
+		return nil
+	}
+}
+
+// makeAttr makes the ctx.attr value: a struct containing the target's attributes, with the values
+// of label attributes converted to Targets and of output attributes converted to Files.
+func (self *RuleContext) makeAttr() (starlark.Value, error) {
+	fields := starlark.StringDict{}
+	for _, item := range rules.TargetAttrs(self.target).Items() {
+		name := string(item[0].(starlark.String))
+		if name == "kind" || strings.HasPrefix(name, "generator_") {
+			continue
+		}
+		fields[name] = item[1]
+	}
+
+	rule := self.target.Rule()
+	for _, attrName := range rule.AttrNames() {
+		if self.target.AttrConfigurable(attrName) != nil {
+			return nil, fmt.Errorf("attribute %v is unresolved", attrName)
+		}
+		value, _ := self.target.Attr(attrName)
+		switch rule.Attr(attrName).AttrType {
+		case values.AttrTypeLabel:
+			if value == nil {
+				fields[attrName] = starlark.None
+				break
+			}
+			targetValue, err := self.targetValue(value.(core.Label))
+			if err != nil {
+				return nil, err
+			}
+			fields[attrName] = targetValue
+		case values.AttrTypeLabelList:
+			labels := labelsOf(value)
+			elems := make([]starlark.Value, len(labels))
+			for i, label := range labels {
+				targetValue, err := self.targetValue(label)
+				if err != nil {
+					return nil, err
+				}
+				elems[i] = targetValue
+			}
+			fields[attrName] = starlark.NewList(elems)
+		case values.AttrTypeLabelKeyedStringDict:
+			d := starlark.NewDict(0)
+			m, _ := value.(map[core.Label]string)
+			for _, label := range labelsOf(value) {
+				targetValue, err := self.targetValue(label)
+				if err != nil {
+					return nil, err
+				}
+				d.SetKey(targetValue, starlark.String(m[label]))
+			}
+			fields[attrName] = d
+		case values.AttrTypeOutput:
+			if value == nil {
+				fields[attrName] = starlark.None
+			} else {
+				fields[attrName] = self.outputFile(value.(core.Label))
+			}
+		case values.AttrTypeOutputList:
+			labels := labelsOf(value)
+			elems := make([]starlark.Value, len(labels))
+			for i, label := range labels {
+				elems[i] = self.outputFile(label)
+			}
+			fields[attrName] = starlark.NewList(elems)
+		}
+	}
+
+	rv := starlarkstruct.FromStringDict(starlark.String("attr"), fields)
+	rv.Freeze()
+	return rv, nil
+}
+
+// outputFile returns the (predeclared) file for an output attribute's label.
+func (self *RuleContext) outputFile(label core.Label) *values.File {
+	return self.declaredFilesByLabel[label]
+}
+
+// makeFiles makes the ctx.files, ctx.file, or ctx.executable value (for which is "files",
+// "file", or "executable", respectively).
+func (self *RuleContext) makeFiles(which string) (starlark.Value, error) {
+	fields := starlark.StringDict{}
+	rule := self.target.Rule()
+	for _, attrName := range rule.AttrNames() {
+		attr := rule.Attr(attrName)
+		if !isLabelAttrType(attr.AttrType) {
+			continue
+		}
+		value, _ := self.target.Attr(attrName)
+		labels := labelsOf(value)
+
+		switch which {
+		case "files":
+			elems := []starlark.Value{}
+			for _, label := range labels {
+				result, err := self.resolveLabel(label)
+				if err != nil {
+					return nil, err
+				}
+				for _, file := range result.Files() {
+					elems = append(elems, file)
+				}
+			}
+			fields[attrName] = starlark.NewList(elems)
+		case "file":
+			if !attr.SingleFile {
+				continue
+			}
+			fields[attrName] = starlark.None
+			if len(labels) == 1 {
+				result, err := self.resolveLabel(labels[0])
+				if err != nil {
+					return nil, err
+				}
+				files := result.Files()
+				if len(files) != 1 {
+					return nil, fmt.Errorf("attribute %v invalid: %v must "+
+						"produce a single file", attrName, labels[0])
+				}
+				fields[attrName] = files[0]
+			}
+		case "executable":
+			if !attr.Executable || attr.AttrType != values.AttrTypeLabel {
+				continue
+			}
+			fields[attrName] = starlark.None
+			if len(labels) == 1 {
+				result, err := self.resolveLabel(labels[0])
+				if err != nil {
+					return nil, err
+				}
+				if executable := result.Executable(); executable != nil {
+					fields[attrName] = executable
+				} else if files := result.Files(); len(files) == 1 {
+					fields[attrName] = files[0]
+				}
+			}
+		}
+	}
+	return starlarkstruct.FromStringDict(starlark.String(which), fields), nil
+}
+
+// outputs is the ctx.outputs value, which (for executable rules) declares the executable lazily.
+type outputs struct {
+	ctx *RuleContext
+}
+
+var _ starlark.HasAttrs = (*outputs)(nil)
+
+func (self *outputs) String() string {
+	return fmt.Sprintf("<outputs for %v>", self.ctx.label)
+}
+
+func (self *outputs) Type() string {
+	return "outputs"
+}
+
+// Freeze does nothing, since outputs values are immutable.
+func (self *outputs) Freeze() {}
+
+func (self *outputs) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *outputs) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", self.Type())
+}
+
+func (self *outputs) Attr(name string) (starlark.Value, error) {
+	rule := self.ctx.target.Rule()
+	if name == "executable" && rule.IsExecutable() {
+		return self.ctx.getExecutable()
+	}
+	if attr := rule.Attr(name); attr != nil && isOutputAttrType(attr.AttrType) {
+		value, err := self.ctx.attr.(starlark.HasAttrs).Attr(name)
+		if err != nil {
+			return nil, err
+		}
+		if list, ok := value.(*starlark.List); ok {
+			// Like Bazel, give a (new) list of the files.
+			elems, _ := toValues(list)
+			return starlark.NewList(elems), nil
+		}
+		return value, nil
+	}
+	return nil, nil
+}
+
+func (self *outputs) AttrNames() []string {
+	rule := self.ctx.target.Rule()
+	rv := []string{}
+	for _, attrName := range rule.AttrNames() {
+		if isOutputAttrType(rule.Attr(attrName).AttrType) {
+			rv = append(rv, attrName)
+		}
+	}
+	if rule.IsExecutable() {
+		rv = append(rv, "executable")
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// toolchains is the ctx.toolchains value. Toolchains aren't supported, so indexing always gives
+// None.
+type toolchains struct{}
+
+var _ starlark.Mapping = toolchains{}
+
+func (toolchains) String() string        { return "<toolchains>" }
+func (toolchains) Type() string          { return "toolchains" }
+func (toolchains) Freeze()               {}
+func (toolchains) Truth() starlark.Bool  { return starlark.True }
+func (toolchains) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: toolchains") }
+
+// TODO(vtl): Support toolchains.
+func (toolchains) Get(key starlark.Value) (starlark.Value, bool, error) {
+	return starlark.None, true, nil
+}
+
+// buildFilePath returns the path (relative to the workspace root) of the BUILD file in which the
+// target was instantiated.
+func (self *RuleContext) buildFilePath() string {
+	name := "BUILD"
+	if provenance := self.target.Provenance(); provenance != nil &&
+		len(provenance.CallStack) > 0 {
+		filename := provenance.CallStack[0].Pos.Filename()
+		if i := strings.LastIndex(filename, ":"); i >= 0 {
+			name = filename[i+1:]
+		}
+	}
+	return path.Join(string(self.label.Package), name)
+}
+
+// runfiles implements ctx.runfiles().
+func (self *RuleContext) runfiles(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var files, transitiveFiles starlark.Value
+	collectData := false
+	collectDefault := false
+	var symlinks, rootSymlinks starlark.Value
+	if err := starlark.UnpackArgs("runfiles", args, kwargs, "files?", &files,
+		"transitive_files?", &transitiveFiles, "collect_data?", &collectData,
+		"collect_default?", &collectDefault, "symlinks?", &symlinks, "root_symlinks?",
+		&rootSymlinks); err != nil {
+		return nil, err
+	}
+
+	filesList, err := toFiles(files)
+	if err != nil {
+		return nil, fmt.Errorf("runfiles: argument files invalid: %v", err)
+	}
+	transitive := []*values.Depset{}
+	switch t := transitiveFiles.(type) {
+	case nil, starlark.NoneType:
+	case *values.Depset:
+		transitive = append(transitive, t)
+	default:
+		return nil, fmt.Errorf("runfiles: argument transitive_files invalid: value is " +
+			"not a depset")
+	}
+	depset, err := newFilesDepset(filesList, transitive)
+	if err != nil {
+		return nil, fmt.Errorf("runfiles: %v", err)
+	}
+	// TODO(vtl): Support collect_data, collect_default, symlinks, and root_symlinks.
+	return &Runfiles{depset}, nil
+}
+
+func (self *RuleContext) String() string {
+	return fmt.Sprintf("<rule context for %v>", self.label)
+}
+
+func (self *RuleContext) Type() string {
+	return "ctx"
+}
+
+// Freeze does nothing, since the rule context itself is immutable.
+func (self *RuleContext) Freeze() {}
+
+func (self *RuleContext) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *RuleContext) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", self.Type())
+}
+
+// newPathStruct creates a struct with just a path field (like a root).
+func newPathStruct(p string) starlark.Value {
+	return starlarkstruct.FromStringDict(starlark.String("root"), starlark.StringDict{
+		"path": starlark.String(p),
+	})
+}
+
+func (self *RuleContext) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "actions":
+		return self.actions, nil
+	case "attr":
+		return self.attr, nil
+	case "bin_dir", "genfiles_dir":
+		return newPathStruct(values.BinDir), nil
+	case "build_file_path":
+		return starlark.String(self.buildFilePath()), nil
+	case "configuration":
+		return starlarkstruct.FromStringDict(starlark.String("configuration"),
+			starlark.StringDict{
+				"coverage_enabled":    starlark.False,
+				"default_shell_env":   starlark.NewDict(0),
+				"host_path_separator": starlark.String(":"),
+			}), nil
+	case "disabled_features", "features":
+		return starlark.NewList(nil), nil
+	case "executable", "file", "files":
+		return self.makeFiles(name)
+	case "fragments":
+		return starlarkstruct.FromStringDict(starlark.String("fragments"), nil), nil
+	case "label":
+		return values.NewLabel(self.label), nil
+	case "outputs":
+		return &outputs{self}, nil
+	case "runfiles":
+		return newMethod(name, self, self.runfiles), nil
+	case "toolchains":
+		return toolchains{}, nil
+	case "var":
+		return starlark.NewDict(0), nil
+	case "workspace_name":
+		return starlark.String(self.workspaceName), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *RuleContext) AttrNames() []string {
+	return []string{"actions", "attr", "bin_dir", "build_file_path", "configuration",
+		"disabled_features", "executable", "features", "file", "files", "fragments",
+		"genfiles_dir", "label", "outputs", "runfiles", "toolchains", "var",
+		"workspace_name"}
+}
+
+// toProviders converts the return value of an implementation function to a list of provider
+// instances: it may be None, a list of provider instances, or a (legacy) struct with a providers
+// field.
+func toProviders(value starlark.Value) ([]*values.Struct, error) {
+	if s, ok := value.(*values.Struct); ok && s.Provider() == nil {
+		providers, err := s.Attr("providers")
+		if err != nil || providers == nil {
+			// TODO(vtl): Support legacy providers.
+			return nil, nil
+		}
+		value = providers
+	}
+	if value == starlark.None {
+		return nil, nil
+	}
+	if _, ok := value.(*values.Depset); ok {
+		return nil, fmt.Errorf("return value is not a list of providers")
+	}
+	elems, err := toValues(value)
+	if err != nil {
+		return nil, fmt.Errorf("return value is not a list of providers")
+	}
+
+	rv := make([]*values.Struct, len(elems))
+	seen := map[*values.Provider]bool{}
+	for i, elem := range elems {
+		instance, ok := elem.(*values.Struct)
+		if !ok || instance.Provider() == nil {
+			return nil, fmt.Errorf("return value invalid: %v is not a provider "+
+				"instance", elem.Type())
+		}
+		if seen[instance.Provider()] {
+			return nil, fmt.Errorf("return value invalid: multiple instances of %v",
+				instance.Provider().Name())
+		}
+		seen[instance.Provider()] = true
+		rv[i] = instance
+	}
+	return rv, nil
+}
+
+// AnalyzeTarget analyzes a target of a user-defined rule by running the rule's implementation
+// function (on the given thread). The targets that it depends on must already have been analyzed
+// (and their results must be available via resolve).
+func AnalyzeTarget(thread *starlark.Thread, target *rules.StarlarkRuleTarget,
+	resolve ResolveFunc) (*Result, error) {
+
+	ctx, err := newRuleContext(core.GetContext(thread).WorkspaceName(), target, resolve)
+	if err != nil {
+		return nil, err
+	}
+	returnValue, err := starlark.Call(thread, target.Rule().Implementation(),
+		starlark.Tuple{ctx}, nil)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := toProviders(returnValue)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", target.Rule().Name(), err)
+	}
+	ctx.result.Providers = providers
+
+	if ctx.result.Provider(values.DefaultInfo) == nil {
+		files := append([]*values.File{}, ctx.predeclaredOutputs...)
+		if ctx.executable != nil {
+			files = append(files, ctx.executable)
+		}
+		ctx.result.Providers = append(ctx.result.Providers,
+			newDefaultInfo(files, ctx.executable))
+	}
+
+	for _, file := range ctx.declaredFiles {
+		if !ctx.generated[file.Label()] {
+			return nil, fmt.Errorf("%v: declared output %v is not generated by any "+
+				"action", target.Rule().Name(), file.ShortPath())
+		}
+	}
+	return ctx.result, nil
+}
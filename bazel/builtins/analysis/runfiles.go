@@ -0,0 +1,104 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package analysis // import "src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+)
+
+// Runfiles is a Bazel runfiles value (as created by ctx.runfiles()): a set of files needed at run
+// time. Symlinks aren't supported.
+type Runfiles struct {
+	files *values.Depset
+}
+
+var _ starlark.HasAttrs = (*Runfiles)(nil)
+
+// Files returns the runfiles' files.
+func (self *Runfiles) Files() []*values.File {
+	rv, _ := toFiles(self.files)
+	return rv
+}
+
+func (self *Runfiles) String() string {
+	return fmt.Sprintf("<runfiles %v>", self.files)
+}
+
+func (self *Runfiles) Type() string {
+	return "runfiles"
+}
+
+// Freeze does nothing, since runfiles are immutable.
+func (self *Runfiles) Freeze() {}
+
+func (self *Runfiles) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Runfiles) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", self.Type())
+}
+
+// merge merges the given runfiles into a new Runfiles.
+func (self *Runfiles) merge(others []*Runfiles) (*Runfiles, error) {
+	transitive := []*values.Depset{self.files}
+	for _, other := range others {
+		transitive = append(transitive, other.files)
+	}
+	files, err := newFilesDepset(nil, transitive)
+	if err != nil {
+		return nil, err
+	}
+	return &Runfiles{files}, nil
+}
+
+func (self *Runfiles) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "files":
+		return self.files, nil
+	case "empty_filenames", "root_symlinks", "symlinks":
+		return newFilesDepset(nil, nil)
+	case "merge":
+		return newMethod("merge", self, func(thread *starlark.Thread, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			var other *Runfiles
+			if err := starlark.UnpackPositionalArgs("merge", args, kwargs, 1,
+				&other); err != nil {
+				return nil, err
+			}
+			return self.merge([]*Runfiles{other})
+		}), nil
+	case "merge_all":
+		return newMethod("merge_all", self, func(thread *starlark.Thread,
+			args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			var othersValue *starlark.List
+			if err := starlark.UnpackPositionalArgs("merge_all", args, kwargs, 1,
+				&othersValue); err != nil {
+				return nil, err
+			}
+			others := make([]*Runfiles, othersValue.Len())
+			for i := range others {
+				var ok bool
+				if others[i], ok = othersValue.Index(i).(*Runfiles); !ok {
+					return nil, fmt.Errorf("merge_all: invalid element: " +
+						"value is not a runfiles")
+				}
+			}
+			return self.merge(others)
+		}), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *Runfiles) AttrNames() []string {
+	return []string{"empty_filenames", "files", "merge", "merge_all", "root_symlinks",
+		"symlinks"}
+}
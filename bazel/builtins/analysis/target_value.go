@@ -0,0 +1,75 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package analysis // import "src.tricot.io/public/bazel2x/bazel/builtins/analysis"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+)
+
+// TargetValue is a Bazel Target value (as given to implementation functions for the dependencies
+// of a target, e.g., ctx.attr.deps), giving access to the dependency's providers.
+type TargetValue struct {
+	result *Result
+}
+
+var _ starlark.HasAttrs = (*TargetValue)(nil)
+var _ starlark.Mapping = (*TargetValue)(nil)
+
+// NewTargetValue creates a new TargetValue for the given (analysis) result.
+func NewTargetValue(result *Result) *TargetValue {
+	return &TargetValue{result}
+}
+
+func (self *TargetValue) String() string {
+	return fmt.Sprintf("<target %v>", self.result.Label)
+}
+
+func (self *TargetValue) Type() string {
+	return "Target"
+}
+
+// Freeze does nothing, since targets are immutable.
+func (self *TargetValue) Freeze() {}
+
+func (self *TargetValue) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *TargetValue) Hash() (uint32, error) {
+	return starlark.String(self.result.Label.String()).Hash()
+}
+
+// Get implements indexing by provider (e.g., target[DefaultInfo]) and the in operator.
+func (self *TargetValue) Get(key starlark.Value) (starlark.Value, bool, error) {
+	provider, ok := key.(*values.Provider)
+	if !ok {
+		return nil, false, fmt.Errorf("type Target only supports indexing by object "+
+			"constructors, got %v instead", key.Type())
+	}
+	instance := self.result.Provider(provider)
+	if instance == nil {
+		return nil, false, fmt.Errorf("%v doesn't contain declared provider %v", self,
+			provider.Name())
+	}
+	return instance, true, nil
+}
+
+func (self *TargetValue) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "label":
+		return values.NewLabel(self.result.Label), nil
+	case "files":
+		return newFilesDepset(self.result.Files(), nil)
+	default:
+		return nil, nil
+	}
+}
+
+func (self *TargetValue) AttrNames() []string {
+	return []string{"files", "label"}
+}
@@ -7,9 +7,12 @@ package args // import "src.tricot.io/public/bazel2x/bazel/builtins/args"
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	"go.starlark.net/starlark"
 
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
@@ -19,14 +22,157 @@ type ProcessArgsTarget interface {
 	DidProcessArgs(ctx core.Context) error
 }
 
+// ConfigurableArgsTarget is implemented by ProcessArgsTargets that accept select() values for
+// (configurable) arguments. For such arguments, the field is left unset and ProcessArgs instead
+// calls SetConfigurable with the unresolved value.
+type ConfigurableArgsTarget interface {
+	ProcessArgsTarget
+	SetConfigurable(argName string, value *core.Configurable)
+
+	// Configurables returns the unresolved values given using select(), keyed by argument name.
+	Configurables() map[string]*core.Configurable
+}
+
+// ResolvedArgsTarget is implemented by ConfigurableArgsTargets whose arguments given using select()
+// can be resolved (see ResolveConfigurables). For the targets of native rules, its methods are
+// generated from the schemas (like ArgsBinder's).
+type ResolvedArgsTarget interface {
+	ConfigurableArgsTarget
+
+	// SetResolvedArg sets the field for the given argument to its resolved value (or leaves it
+	// unset if value is nil). It returns the field's "bazel" tag, or "" if there is no such
+	// field.
+	SetResolvedArg(argName string, value interface{}) string
+
+	// DidResolveArgs calls DidProcessArgs for the argument groups embedded in the target (in
+	// order) and then for the target itself, as when processing its arguments.
+	DidResolveArgs(ctx core.Context) error
+}
+
+// DynamicArgsTarget is implemented by ResolvedArgsTargets that have arguments that don't
+// correspond to (tagged) fields, so that their resolved values can be set.
+type DynamicArgsTarget interface {
+	ResolvedArgsTarget
+
+	// SetDynamicArg sets the (resolved) value of the given argument (nil if unset), which is no
+	// longer unresolved. It returns false if there is no such argument, and an error if the
+	// resolved value is invalid.
+	SetDynamicArg(argName string, value interface{}) (bool, error)
+}
+
+// ArgsBinder is implemented by ProcessArgsTargets that bind their arguments themselves (e.g., using
+// code generated from a schema) instead of having ProcessArgs do so using reflection. BindArgs
+// should bind each argument using BindArg and then call DidProcessArgs (of any embedded argument
+// groups, in order, and then of the target itself).
+type ArgsBinder interface {
+	ProcessArgsTarget
+	BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+		configurableTarget ConfigurableArgsTarget) error
+}
+
+// ParseTag parses a "bazel" field tag, which has the form "<name>[!][,<option>]*". A trailing "!"
+// on the name indicates that the argument is required. The following options are supported:
+//   - "nonconfigurable", which indicates that the argument may not be given using select();
+//   - "output", which indicates that the argument's labels are outputs (see CheckOutputs); and
+//   - "license", which indicates that the argument's strings are license types (see
+//     CheckLicenses).
+func ParseTag(tag string) (argName string, argRequired bool, argNonconfigurable bool) {
+	parts := strings.Split(tag, ",")
+	argName = parts[0]
+	if argName[len(argName)-1] == '!' {
+		argRequired = true
+		argName = argName[:len(argName)-1]
+	}
+	for _, option := range parts[1:] {
+		switch option {
+		case "nonconfigurable":
+			argNonconfigurable = true
+		case "output", "license":
+		default:
+			panic(tag)
+		}
+	}
+	return
+}
+
+// hasTagOption returns whether the given "bazel" field tag has the given option.
+func hasTagOption(tag string, option string) bool {
+	for _, o := range strings.Split(tag, ",")[1:] {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOutputs checks that the labels in the (converted) value of an output argument (a label or
+// a list of labels) are files in the current package.
+func CheckOutputs(argName string, value interface{}, ctx core.Context) error {
+	var labels []core.Label
+	switch v := value.(type) {
+	case nil:
+	case core.Label:
+		labels = []core.Label{v}
+	case []core.Label:
+		labels = v
+	default:
+		panic(v)
+	}
+	pkg := ctx.Label()
+	for _, label := range labels {
+		if label.Workspace != pkg.Workspace || label.Package != pkg.Package {
+			return fmt.Errorf("argument %v invalid: invalid element: output %v is "+
+				"not in package %v", argName, label, pkg.Package)
+		}
+	}
+	return nil
+}
+
+// licenseTypes are the valid license types (see
+// https://docs.bazel.build/versions/master/be/functions.html#licenses).
+var licenseTypes = map[string]bool{
+	"by_exception_only": true,
+	"none":              true,
+	"notice":            true,
+	"permanent":         true,
+	"reciprocal":        true,
+	"restricted":        true,
+	"unencumbered":      true,
+}
+
+// CheckLicenses checks that the strings in the (converted) value of a license argument (a list of
+// strings) are license types or exceptions (of the form "exception=<label>").
+func CheckLicenses(argName string, value interface{}, ctx core.Context) error {
+	licenses, _ := value.([]string)
+	for _, license := range licenses {
+		if strings.HasPrefix(license, "exception=") {
+			exception := starlark.String(strings.TrimPrefix(license, "exception="))
+			if _, err := toLabel(exception, ctx); err != nil {
+				return fmt.Errorf("argument %v invalid: invalid element: invalid "+
+					"exception: %v", argName, err)
+			}
+			continue
+		}
+		if !licenseTypes[license] {
+			return fmt.Errorf("argument %v invalid: invalid element: unknown license "+
+				"type %q", argName, license)
+		}
+	}
+	return nil
+}
+
 func toLabel(value starlark.Value, ctx core.Context) (core.Label, error) {
+	if l, ok := value.(*values.Label); ok {
+		return l.Label(), nil
+	}
 	s, ok := value.(starlark.String)
 	if !ok {
-		return core.Label{}, fmt.Errorf("label value is not a string")
+		return core.Label{}, fmt.Errorf("label value is not a string or Label")
 	}
 
-	// If it's a valid target name (e.g., a filename), then we accept it as such.
-	// TODO(vtl): This means that we always accept filenames as labels, which is too lax.
+	// If it's a valid target name (e.g., a filename), then we accept it as such (like Bazel,
+	// which treats, e.g., "a.txt" as ":a.txt"). Whether it actually refers to a target
+	// (possibly an existing source file) is checked once the package has been loaded.
 	if core.TargetName(s).IsValid() {
 		label := core.Label{
 			Workspace: ctx.Label().Workspace,
@@ -46,18 +192,106 @@ func toLabel(value starlark.Value, ctx core.Context) (core.Label, error) {
 	return label, nil
 }
 
-func setArg(argName string, value starlark.Value, ctx core.Context, dest reflect.Value) error {
-	if !dest.CanSet() {
-		panic(dest)
+// ConvertArg converts a single (non-select()) value for an argument, as if for a field of type
+// destType (which must be a pointer type supported by ProcessArgs). The result is of type
+// destType.Elem(), or nil if the value is None.
+func ConvertArg(argName string, value starlark.Value, ctx core.Context,
+	destType reflect.Type) (interface{}, error) {
+
+	if value == starlark.None {
+		return nil, nil
+	}
+	tmp := reflect.New(destType)
+	if err := setArg(argName, value, ctx, tmp.Interface()); err != nil {
+		return nil, err
+	}
+	return tmp.Elem().Elem().Interface(), nil
+}
+
+// ConvertConfigurableArg converts a select() value for an argument, as if for a field of type
+// destType (see ConvertArg).
+func ConvertConfigurableArg(argName string, value *values.Select, ctx core.Context,
+	destType reflect.Type) (*core.Configurable, error) {
+
+	return ConvertConfigurableArgWith(argName, value, ctx,
+		func(v starlark.Value) (interface{}, error) {
+			return ConvertArg(argName, v, ctx, destType)
+		})
+}
+
+// ConvertConfigurableArgWith converts a select() value for an argument, using convert to convert
+// each single (non-select()) value (i.e., the plain values and the values of the branches).
+func ConvertConfigurableArgWith(argName string, value *values.Select, ctx core.Context,
+	convert func(v starlark.Value) (interface{}, error)) (*core.Configurable, error) {
+
+	configurable := &core.Configurable{Parts: make([]core.ConfigurablePart, len(value.Parts))}
+	for i, part := range value.Parts {
+		if part.Selector == nil {
+			v, err := convert(part.Value)
+			if err != nil {
+				return nil, err
+			}
+			configurable.Parts[i].Value = v
+			continue
+		}
+
+		branches := []core.SelectBranch{}
+		// keys maps the (normalized) conditions to the keys given for them, since
+		// different keys (e.g., ":foo" and "//pkg:foo") may refer to the same condition.
+		keys := map[core.Label]starlark.Value{}
+		for _, item := range part.Selector.Conditions.Items() {
+			condition := core.ConditionsDefault
+			if s, ok := item[0].(starlark.String); !ok ||
+				string(s) != core.ConditionsDefault.String() {
+				var err error
+				condition, err = toLabel(item[0], ctx)
+				if err != nil {
+					return nil, fmt.Errorf("argument %v invalid: invalid "+
+						"select() condition: %v", argName, err)
+				}
+			}
+			if key, ok := keys[condition]; ok {
+				return nil, fmt.Errorf("argument %v invalid: select() keys %v "+
+					"and %v both refer to %v", argName, key, item[0],
+					condition)
+			}
+			keys[condition] = item[0]
+			v, err := convert(item[1])
+			if err != nil {
+				return nil, err
+			}
+			branches = append(branches,
+				core.SelectBranch{Condition: condition, Value: v})
+		}
+		configurable.Parts[i].Branches = branches
+		configurable.Parts[i].NoMatchError = part.Selector.NoMatchError
 	}
+	return configurable, nil
+}
 
-	switch dest.Interface().(type) {
-	case *bool:
-		// TODO(vtl): This means we accept anything for bool arguments; is this what we
-		// want? (Bazel accepts at least True/False and 1/0; I'm not sure what else.)
-		boolValue := bool(value.Truth())
-		dest.Set(reflect.ValueOf(&boolValue))
-	case *int64:
+// setArg converts a single (non-select()) value for an argument and sets the field pointed to by
+// dest (which must be a pointer to a field of a type supported by ProcessArgs) to point to it.
+func setArg(argName string, value starlark.Value, ctx core.Context, dest interface{}) error {
+	switch dp := dest.(type) {
+	case **bool:
+		// Like Bazel, accept True/False and 1/0.
+		var boolValue bool
+		switch v := value.(type) {
+		case starlark.Bool:
+			boolValue = bool(v)
+		case starlark.Int:
+			n, ok := v.Int64()
+			if !ok || (n != 0 && n != 1) {
+				return fmt.Errorf("argument %v invalid: expected value of type "+
+					"'bool' (True, False, 1, or 0) instead of %v", argName, v)
+			}
+			boolValue = n == 1
+		default:
+			return fmt.Errorf("argument %v invalid: expected value of type 'bool' "+
+				"instead of '%v'", argName, value.Type())
+		}
+		*dp = &boolValue
+	case **int64:
 		n, ok := value.(starlark.Int)
 		if !ok {
 			return fmt.Errorf("argument %v invalid: value is not an integer", argName)
@@ -66,21 +300,40 @@ func setArg(argName string, value starlark.Value, ctx core.Context, dest reflect
 		if !ok {
 			return fmt.Errorf("argument %v invalid: integer out of range", argName)
 		}
-		dest.Set(reflect.ValueOf(&intValue))
-	case *string:
+		*dp = &intValue
+	case **string:
 		s, ok := value.(starlark.String)
 		if !ok {
 			return fmt.Errorf("argument %v invalid: value is not a string", argName)
 		}
 		stringValue := string(s)
-		dest.Set(reflect.ValueOf(&stringValue))
-	case *core.Label:
+		*dp = &stringValue
+	case **core.Label:
 		labelValue, err := toLabel(value, ctx)
 		if err != nil {
 			return fmt.Errorf("argument %v invalid: %v", argName, err)
 		}
-		dest.Set(reflect.ValueOf(&labelValue))
-	case *[]string:
+		*dp = &labelValue
+	case **[]int64:
+		l, ok := value.(*starlark.List)
+		if !ok {
+			return fmt.Errorf("argument %v invalid: value is not a list", argName)
+		}
+		listValue := make([]int64, l.Len())
+		for i := 0; i < l.Len(); i++ {
+			n, ok := l.Index(i).(starlark.Int)
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid element: value is "+
+					"not an integer", argName)
+			}
+			listValue[i], ok = n.Int64()
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid element: integer "+
+					"out of range", argName)
+			}
+		}
+		*dp = &listValue
+	case **[]string:
 		l, ok := value.(*starlark.List)
 		if !ok {
 			return fmt.Errorf("argument %v invalid: value is not a list", argName)
@@ -94,32 +347,182 @@ func setArg(argName string, value starlark.Value, ctx core.Context, dest reflect
 			}
 			listValue[i] = string(s)
 		}
-		dest.Set(reflect.ValueOf(&listValue))
-	case *[]core.Label:
+		*dp = &listValue
+	case **[]core.Label:
 		l, ok := value.(*starlark.List)
 		if !ok {
 			return fmt.Errorf("argument %v invalid: value is not a list", argName)
 		}
 		listValue := make([]core.Label, l.Len())
+		seen := make(map[core.Label]bool, l.Len())
 		for i := 0; i < l.Len(); i++ {
 			labelValue, err := toLabel(l.Index(i), ctx)
 			if err != nil {
 				return fmt.Errorf("argument %v invalid: invalid element: %v",
 					argName, err)
 			}
+			if seen[labelValue] {
+				return fmt.Errorf("argument %v invalid: label %v is duplicated",
+					argName, labelValue)
+			}
+			seen[labelValue] = true
 			listValue[i] = labelValue
 		}
-		dest.Set(reflect.ValueOf(&listValue))
+		*dp = &listValue
+	case **map[string]string:
+		d, ok := value.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("argument %v invalid: value is not a dict", argName)
+		}
+		mapValue := make(map[string]string, d.Len())
+		for _, item := range d.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid key: value is "+
+					"not a string", argName)
+			}
+			v, ok := item[1].(starlark.String)
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid value for key %v: "+
+					"value is not a string", argName, k)
+			}
+			mapValue[string(k)] = string(v)
+		}
+		*dp = &mapValue
+	case **map[core.Label]string:
+		d, ok := value.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("argument %v invalid: value is not a dict", argName)
+		}
+		mapValue := make(map[core.Label]string, d.Len())
+		for _, item := range d.Items() {
+			k, err := toLabel(item[0], ctx)
+			if err != nil {
+				return fmt.Errorf("argument %v invalid: invalid key: %v", argName,
+					err)
+			}
+			if _, ok := mapValue[k]; ok {
+				return fmt.Errorf("argument %v invalid: duplicate key %v", argName,
+					k)
+			}
+			v, ok := item[1].(starlark.String)
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid value for key %v: "+
+					"value is not a string", argName, item[0])
+			}
+			mapValue[k] = string(v)
+		}
+		*dp = &mapValue
+	case **map[string][]string:
+		d, ok := value.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("argument %v invalid: value is not a dict", argName)
+		}
+		mapValue := make(map[string][]string, d.Len())
+		for _, item := range d.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid key: value is "+
+					"not a string", argName)
+			}
+			l, ok := item[1].(*starlark.List)
+			if !ok {
+				return fmt.Errorf("argument %v invalid: invalid value for key %v: "+
+					"value is not a list", argName, k)
+			}
+			listValue := make([]string, l.Len())
+			for i := 0; i < l.Len(); i++ {
+				s, ok := l.Index(i).(starlark.String)
+				if !ok {
+					return fmt.Errorf("argument %v invalid: invalid value for "+
+						"key %v: invalid element: value is not a string",
+						argName, k)
+				}
+				listValue[i] = string(s)
+			}
+			mapValue[string(k)] = listValue
+		}
+		*dp = &mapValue
 	default:
 		panic(dest)
 	}
 	return nil
 }
 
+// checkArg performs the checks required by the options in the tag for an argument that was set
+// to the given (converted) value.
+func checkArg(argName string, tag string, value interface{}, ctx core.Context) error {
+	if hasTagOption(tag, "output") {
+		if err := CheckOutputs(argName, value, ctx); err != nil {
+			return err
+		}
+	}
+	if hasTagOption(tag, "license") {
+		if err := CheckLicenses(argName, value, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkResolvedArg performs the checks on the resolved value of an argument given using select()
+// that can't be performed on the values of its parts (e.g., a list of labels given as a
+// concatenation may have duplicates even if the lists concatenated don't).
+func checkResolvedArg(argName string, value interface{}) error {
+	if labels, ok := value.([]core.Label); ok {
+		seen := make(map[core.Label]bool, len(labels))
+		for _, label := range labels {
+			if seen[label] {
+				return fmt.Errorf("argument %v invalid: label %v is duplicated",
+					argName, label)
+			}
+			seen[label] = true
+		}
+	}
+	return nil
+}
+
+// BindArg binds the argument described by the given "bazel" field tag (see ParseTag), removing it
+// from kwargs: if it was given, the field pointed to by dest (which must be a pointer to a field of
+// a type supported by ProcessArgs) is set or, if it was given using select(), the unresolved value
+// is set on configurableTarget (which may be nil if the target doesn't accept select() values).
+func BindArg(kwargs map[string]starlark.Value, tag string, ctx core.Context,
+	configurableTarget ConfigurableArgsTarget, dest interface{}) error {
+
+	argName, argRequired, argNonconfigurable := ParseTag(tag)
+
+	arg, ok := kwargs[argName]
+	if !ok {
+		if argRequired {
+			return fmt.Errorf("target argument %v required", argName)
+		}
+		return nil
+	}
+	delete(kwargs, argName)
+
+	if sel, ok := arg.(*values.Select); ok {
+		if configurableTarget == nil || argNonconfigurable {
+			return fmt.Errorf("argument %v invalid: not configurable", argName)
+		}
+		configurable, err := ConvertConfigurableArg(argName, sel, ctx,
+			reflect.TypeOf(dest).Elem())
+		if err != nil {
+			return err
+		}
+		configurableTarget.SetConfigurable(argName, configurable)
+		return nil
+	}
+
+	if err := setArg(argName, arg, ctx, dest); err != nil {
+		return err
+	}
+	return checkArg(argName, tag, reflect.ValueOf(dest).Elem().Elem().Interface(), ctx)
+}
+
 var processArgsTargetType = reflect.TypeOf((*ProcessArgsTarget)(nil)).Elem()
 
 func processRuleArgsHelper(kwargs map[string]starlark.Value, ctx core.Context,
-	targetVp reflect.Value) error {
+	targetVp reflect.Value, configurableTarget ConfigurableArgsTarget) error {
 
 	if !targetVp.Type().Implements(processArgsTargetType) {
 		panic(targetVp)
@@ -139,27 +542,19 @@ func processRuleArgsHelper(kwargs map[string]starlark.Value, ctx core.Context,
 	for i := 0; i < typ.NumField(); i++ {
 		typf := typ.Field(i)
 		vf := v.Field(i)
-		if argName, ok := typf.Tag.Lookup("bazel"); ok {
+		if tag, ok := typf.Tag.Lookup("bazel"); ok {
 			if !vf.CanSet() {
 				panic(vf)
 			}
-
-			argRequired := false
-			if argName[len(argName)-1] == '!' {
-				argRequired = true
-				argName = argName[:len(argName)-1]
-			}
-
-			if arg, ok := kwargs[argName]; ok {
-				delete(kwargs, argName)
-				setArg(argName, arg, ctx, vf)
-			} else if argRequired {
-				return fmt.Errorf("target argument %v required", argName)
+			err := BindArg(kwargs, tag, ctx, configurableTarget, vf.Addr().Interface())
+			if err != nil {
+				return err
 			}
 		} else if vf.Kind() == reflect.Struct {
 			vfa := vf.Addr()
 			if vfa.Type().Implements(processArgsTargetType) {
-				if err := processRuleArgsHelper(kwargs, ctx, vfa); err != nil {
+				if err := processRuleArgsHelper(kwargs, ctx, vfa,
+					configurableTarget); err != nil {
 					return err
 				}
 			}
@@ -173,8 +568,8 @@ func processRuleArgsHelper(kwargs map[string]starlark.Value, ctx core.Context,
 	return errorValue.Interface().(error)
 }
 
-// ProcessArgs processes kwargs for the given target. Currently, it always requires that all
-// arguments be kwargs.
+// ProcessArgs processes kwargs for the given target (using its BindArgs if it is an ArgsBinder, and
+// otherwise using reflection). Currently, it always requires that all arguments be kwargs.
 func ProcessArgs(args starlark.Tuple, kwargs []starlark.Tuple, ctx core.Context,
 	target ProcessArgsTarget) error {
 
@@ -187,21 +582,78 @@ func ProcessArgs(args starlark.Tuple, kwargs []starlark.Tuple, ctx core.Context,
 		kwargs2[string(elem[0].(starlark.String))] = elem[1]
 	}
 
-	targetVp := reflect.ValueOf(target)
-	if targetVp.Kind() != reflect.Ptr {
-		panic(targetVp)
+	configurableTarget, _ := target.(ConfigurableArgsTarget)
+	if binder, ok := target.(ArgsBinder); ok {
+		if err := binder.BindArgs(kwargs2, ctx, configurableTarget); err != nil {
+			return err
+		}
+	} else {
+		targetVp := reflect.ValueOf(target)
+		if targetVp.Kind() != reflect.Ptr {
+			panic(targetVp)
+		}
+		err := processRuleArgsHelper(kwargs2, ctx, targetVp, configurableTarget)
+		if err != nil {
+			return err
+		}
 	}
 
-	err := processRuleArgsHelper(kwargs2, ctx, targetVp)
-	if err != nil {
-		return err
+	if len(kwargs2) > 0 {
+		unknown := make([]string, 0, len(kwargs2))
+		for k := range kwargs2 {
+			unknown = append(unknown, k)
+		}
+		sort.Strings(unknown)
+		if len(unknown) == 1 {
+			return fmt.Errorf("unknown keyword argument %v", unknown[0])
+		}
+		return fmt.Errorf("unknown keyword arguments %v", strings.Join(unknown, ", "))
 	}
 
-	// This is a conditional posing as a loop.
-	// TODO(vtl): Map iteration order isn't deterministic, so it's weird.
-	for k := range kwargs2 {
-		return fmt.Errorf("unknown keyword argument %v", k)
+	return nil
+}
+
+// ResolveConfigurables resolves the values of the arguments of target that were given using
+// select(), for the configuration described by matcher, and sets the corresponding fields
+// (leaving them unset if the resolved value is None). Dynamic arguments (of DynamicArgsTargets)
+// take precedence over fields. Afterwards, the target has no unresolved values. Since the
+// resolved values are checked as when processing the arguments (and DidProcessArgs is called
+// again), ctx should be the context of the BUILD file in which the target was instantiated.
+func ResolveConfigurables(target ResolvedArgsTarget, matcher core.ConditionMatcher,
+	ctx core.Context) error {
+
+	configurables := target.Configurables()
+	argNames := make([]string, 0, len(configurables))
+	for argName := range configurables {
+		argNames = append(argNames, argName)
 	}
+	sort.Strings(argNames)
 
-	return nil
+	for _, argName := range argNames {
+		value, err := configurables[argName].Resolve(matcher)
+		if err != nil {
+			return fmt.Errorf("argument %v: %v", argName, err)
+		}
+		if err := checkResolvedArg(argName, value); err != nil {
+			return err
+		}
+		if dynamicTarget, ok := target.(DynamicArgsTarget); ok {
+			isDynamic, err := dynamicTarget.SetDynamicArg(argName, value)
+			if err != nil {
+				return err
+			}
+			if isDynamic {
+				continue
+			}
+		}
+		tag := target.SetResolvedArg(argName, value)
+		if tag == "" {
+			panic(argName)
+		}
+		target.SetConfigurable(argName, nil)
+		if err := checkArg(argName, tag, value, ctx); err != nil {
+			return err
+		}
+	}
+	return target.DidResolveArgs(ctx)
 }
@@ -10,6 +10,7 @@ import (
 
 	"src.tricot.io/public/bazel2x/bazel/builtins/functions"
 	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
 	"src.tricot.io/public/bazel2x/bazel/builtins/workspace_rules"
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
@@ -28,123 +29,123 @@ func starlarkUnion(dicts ...starlark.StringDict) starlark.StringDict {
 var rulesGlobals = starlark.StringDict{
 	// Android Rules
 	// https://docs.bazel.build/versions/master/be/android.html
-	"android_binary":               rules.NotImplemented("android_binary"),
-	"aar_import":                   rules.NotImplemented("aar_import"),
-	"android_library":              rules.NotImplemented("android_library"),
-	"android_instrumentation_test": rules.NotImplemented("android_instrumentation_test"),
-	"android_local_test":           rules.NotImplemented("android_local_test"),
-	"android_device":               rules.NotImplemented("android_device"),
+	"android_binary":               rules.GenericRule("android_binary"),
+	"aar_import":                   rules.GenericRule("aar_import"),
+	"android_library":              rules.GenericRule("android_library"),
+	"android_instrumentation_test": rules.GenericRule("android_instrumentation_test"),
+	"android_local_test":           rules.GenericRule("android_local_test"),
+	"android_device":               rules.GenericRule("android_device"),
 	"android_ndk_repository":       rules.NotImplemented("android_ndk_repository"),
 	"android_sdk_repository":       rules.NotImplemented("android_sdk_repository"),
 
 	// C/C++ Rules
 	// https://docs.bazel.build/versions/master/be/c-cpp.html
 	"cc_binary":          rules.CcBinary,
-	"cc_import":          rules.NotImplemented("cc_import"),
+	"cc_import":          rules.GenericRule("cc_import"),
 	"cc_library":         rules.CcLibrary,
-	"cc_proto_library":   rules.NotImplemented("cc_proto_library"),
-	"fdo_prefetch_hints": rules.NotImplemented("fdo_prefetch_hints"),
-	"fdo_profile":        rules.NotImplemented("fdo_profile"),
+	"cc_proto_library":   rules.GenericRule("cc_proto_library"),
+	"fdo_prefetch_hints": rules.GenericRule("fdo_prefetch_hints"),
+	"fdo_profile":        rules.GenericRule("fdo_profile"),
 	"cc_test":            rules.CcTest,
-	"cc_toolchain":       rules.NotImplemented("cc_toolchain"),
-	"cc_toolchain_suite": rules.NotImplemented("cc_toolchain_suite"),
+	"cc_toolchain":       rules.GenericRule("cc_toolchain"),
+	"cc_toolchain_suite": rules.GenericRule("cc_toolchain_suite"),
 
 	// Java Rules
 	// https://docs.bazel.build/versions/master/be/java.html
-	"java_binary":                rules.NotImplemented("java_binary"),
-	"java_import":                rules.NotImplemented("java_import"),
-	"java_library":               rules.NotImplemented("java_library"),
-	"java_lite_proto_library":    rules.NotImplemented("java_lite_proto_library"),
-	"java_proto_library":         rules.NotImplemented("java_proto_library"),
-	"java_test":                  rules.NotImplemented("java_test"),
-	"java_package_configuration": rules.NotImplemented("java_package_configuration"),
-	"java_plugin":                rules.NotImplemented("java_plugin"),
-	"java_runtime":               rules.NotImplemented("java_runtime"),
-	"java_toolchain":             rules.NotImplemented("java_toolchain"),
+	"java_binary":                rules.GenericRule("java_binary"),
+	"java_import":                rules.GenericRule("java_import"),
+	"java_library":               rules.GenericRule("java_library"),
+	"java_lite_proto_library":    rules.GenericRule("java_lite_proto_library"),
+	"java_proto_library":         rules.GenericRule("java_proto_library"),
+	"java_test":                  rules.GenericRule("java_test"),
+	"java_package_configuration": rules.GenericRule("java_package_configuration"),
+	"java_plugin":                rules.GenericRule("java_plugin"),
+	"java_runtime":               rules.GenericRule("java_runtime"),
+	"java_toolchain":             rules.GenericRule("java_toolchain"),
 
 	// Objective-C Rules
 	// https://docs.bazel.build/versions/master/be/objective-c.html
-	"apple_binary":         rules.NotImplemented("apple_binary"),
-	"apple_static_library": rules.NotImplemented("apple_static_library"),
-	"j2objc_library":       rules.NotImplemented("j2objc_library"),
-	"objc_import":          rules.NotImplemented("objc_import"),
-	"objc_library":         rules.NotImplemented("objc_library"),
-	"objc_proto_library":   rules.NotImplemented("objc_proto_library"),
+	"apple_binary":         rules.GenericRule("apple_binary"),
+	"apple_static_library": rules.GenericRule("apple_static_library"),
+	"j2objc_library":       rules.GenericRule("j2objc_library"),
+	"objc_import":          rules.GenericRule("objc_import"),
+	"objc_library":         rules.GenericRule("objc_library"),
+	"objc_proto_library":   rules.GenericRule("objc_proto_library"),
 
 	// Protocol Buffer Rules
 	// https://docs.bazel.build/versions/master/be/protocol-buffer.html
-	"proto_lang_toolchain": rules.NotImplemented("proto_lang_toolchain"),
-	"proto_library":        rules.NotImplemented("proto_library"),
+	"proto_lang_toolchain": rules.GenericRule("proto_lang_toolchain"),
+	"proto_library":        rules.GenericRule("proto_library"),
 
 	// Python Rules
 	// https://docs.bazel.build/versions/master/be/python.html
-	"py_binary":  rules.NotImplemented("py_binary"),
-	"py_library": rules.NotImplemented("py_library"),
-	"py_test":    rules.NotImplemented("py_test"),
-	"py_runtime": rules.NotImplemented("py_runtime"),
+	"py_binary":  rules.GenericRule("py_binary"),
+	"py_library": rules.GenericRule("py_library"),
+	"py_test":    rules.GenericRule("py_test"),
+	"py_runtime": rules.GenericRule("py_runtime"),
 
 	// Shell Rules
 	// https://docs.bazel.build/versions/master/be/shell.html
-	"sh_binary":  rules.NotImplemented("sh_binary"),
-	"sh_library": rules.NotImplemented("sh_library"),
-	"sh_test":    rules.NotImplemented("sh_test"),
+	"sh_binary":  rules.GenericRule("sh_binary"),
+	"sh_library": rules.GenericRule("sh_library"),
+	"sh_test":    rules.GenericRule("sh_test"),
 
 	// Extra Actions Rules
 	// https://docs.bazel.build/versions/master/be/extra-actions.html
-	"action_listener": rules.NotImplemented("action_listener"),
-	"extra_action":    rules.NotImplemented("extra_action"),
+	"action_listener": rules.GenericRule("action_listener"),
+	"extra_action":    rules.GenericRule("extra_action"),
 
 	// General Rules
 	// https://docs.bazel.build/versions/master/be/general.html
-	"filegroup":      rules.NotImplemented("filegroup"),
-	"genquery":       rules.NotImplemented("genquery"),
-	"test_suite":     rules.NotImplemented("test_suite"),
-	"alias":          rules.NotImplemented("alias"),
-	"config_setting": rules.NotImplemented("config_setting"),
-	"genrule":        rules.NotImplemented("genrule"),
+	"filegroup":      rules.GenericRule("filegroup"),
+	"genquery":       rules.GenericRule("genquery"),
+	"test_suite":     rules.GenericRule("test_suite"),
+	"alias":          rules.GenericRule("alias"),
+	"config_setting": rules.ConfigSetting,
+	"genrule":        rules.Genrule,
 
 	// Platform Rules
 	// https://docs.bazel.build/versions/master/be/platform.html
-	"constraint_setting": rules.NotImplemented("constraint_setting"),
-	"constraint_value":   rules.NotImplemented("constraint_value"),
-	"platform":           rules.NotImplemented("platform"),
-	"toolchain":          rules.NotImplemented("toolchain"),
+	"constraint_setting": rules.ConstraintSetting,
+	"constraint_value":   rules.ConstraintValue,
+	"platform":           rules.Platform,
+	"toolchain":          rules.GenericRule("toolchain"),
 }
 
 // commonGlobals are globals that are common to BUILD, .bzl, and WORKSPACE files.
 var commonGlobals = starlark.StringDict{
-	"depset": functions.NotImplemented("depset"),
-	"fail":   functions.NotImplemented("fail"),
-	"select": functions.NotImplemented("select"),
+	"Label":  functions.Label,
+	"depset": functions.Depset,
+	"fail":   functions.Fail,
+	"select": functions.Select,
 	// TODO(vtl): This probably doesn't belong here.
-	"struct": functions.NotImplemented("struct"),
+	"struct": functions.Struct,
 }
 
 // buildAndbzlCommonGlobals are globals that are common to BUILD and .bzl files.
 var buildAndbzlCommonGlobals = starlark.StringDict{
 	"configuration_field": functions.NotImplemented("configuration_field"),
-	"native": &starlarkstruct.Module{
-		Name: "native",
-		// Note: Rules will be added separately.
-		Members: starlarkUnion(
-			starlark.StringDict{
-				// Non-rule Members
-				// https://docs.bazel.build/versions/master/skylark/lib/native.html
-				"existing_rule": functions.NotImplemented("existing_rule"),
-				"existing_rules": functions.NotImplementedRv("existing_rules",
-					&starlark.List{}),
-				"exports_files":   functions.NotImplemented("exports_files"),
-				"glob":            functions.NotImplemented("glob"),
-				"package_group":   functions.NotImplemented("package_group"),
-				"package_name":    functions.NotImplemented("package_name"),
-				"repository_name": functions.NotImplemented("repository_name"),
-			},
-			rulesGlobals,
-		),
-	},
 }
 
-// buildGlobals are globals for BUILD files.
+// nativeMembers are the non-rule members of native (in BUILD and .bzl files); the rules are added
+// separately.
+//
+// These are documented in:
+//   https://docs.bazel.build/versions/master/skylark/lib/native.html
+var nativeMembers = starlark.StringDict{
+	"existing_rule":          functions.ExistingRule,
+	"existing_rules":         functions.ExistingRules,
+	"exports_files":          functions.ExportsFiles,
+	"glob":                   functions.Glob,
+	"module_name":            functions.ModuleName,
+	"module_version":         functions.ModuleVersion,
+	"package_group":          functions.NotImplemented("package_group"),
+	"package_name":           functions.PackageName,
+	"package_relative_label": functions.PackageRelativeLabel,
+	"repository_name":        functions.RepositoryName,
+}
+
+// buildGlobals are globals (other than rules) only for BUILD files.
 //
 // These (and others) are variously documented in:
 //   https://docs.bazel.build/versions/master/skylark/lib/globals.html
@@ -155,84 +156,75 @@ var buildAndbzlCommonGlobals = starlark.StringDict{
 //   analysis_test_transition is not supported.
 //   PACKAGE_NAME has actually been "removed" from Bazel (using it now causes an error).
 //   REPOSITORY_NAME has actually been "removed" from Bazel (using it now causes an error).
-var buildGlobals = starlarkUnion(
-	commonGlobals,
-	buildAndbzlCommonGlobals,
-	rulesGlobals,
-	starlark.StringDict{
-		"existing_rules":  functions.NotImplementedRv("existing_rules", &starlark.List{}),
-		"exports_files":   functions.NotImplemented("exports_files"),
-		"glob":            functions.NotImplemented("glob"),
-		"package":         functions.NotImplemented("package"),
-		"package_group":   functions.NotImplemented("package_group"),
-		"package_name":    functions.NotImplemented("package_name"),
-		"repository_name": functions.NotImplemented("repository_name"),
-	},
-)
+var buildGlobals = starlark.StringDict{
+	"existing_rules":         functions.ExistingRules,
+	"exports_files":          functions.ExportsFiles,
+	"glob":                   functions.Glob,
+	"module_name":            functions.ModuleName,
+	"module_version":         functions.ModuleVersion,
+	"package":                functions.Package,
+	"package_group":          functions.NotImplemented("package_group"),
+	"package_name":           functions.PackageName,
+	"package_relative_label": functions.PackageRelativeLabel,
+	"repository_name":        functions.RepositoryName,
+}
 
-var bzlGlobals = starlarkUnion(
-	commonGlobals,
-	buildAndbzlCommonGlobals,
-	starlark.StringDict{
-		"aspect":   functions.NotImplemented("aspect"),
-		"provider": functions.NotImplemented("provider"),
-		"repository_rule": functions.NotImplementedRv("repository_rule",
-			functions.NotImplemented("repository_rule_rv")),
-		"rule": functions.NotImplementedRv("rule", functions.NotImplemented("rule_rv")),
-
-		// https://docs.bazel.build/versions/master/skylark/lib/attr.html
-		"attr": &starlarkstruct.Module{
-			Name: "attr",
-			Members: starlark.StringDict{
-				"bool":     functions.NotImplemented("bool"),
-				"int":      functions.NotImplemented("int"),
-				"int_list": functions.NotImplemented("int_list"),
-				"label":    functions.NotImplemented("label"),
-				"label_keyed_string_dict": functions.NotImplemented(
-					"label_keyed_string_dict"),
-				"label_list":       functions.NotImplemented("label_list"),
-				"license":          functions.NotImplemented("license"),
-				"output":           functions.NotImplemented("output"),
-				"output_list":      functions.NotImplemented("output_list"),
-				"string":           functions.NotImplemented("string"),
-				"string_dict":      functions.NotImplemented("string_dict"),
-				"string_list":      functions.NotImplemented("string_list"),
-				"string_list_dict": functions.NotImplemented("string_list_dict"),
-			},
+// bzlGlobals are globals only for .bzl files.
+var bzlGlobals = starlark.StringDict{
+	"DefaultInfo":     values.DefaultInfo,
+	"OutputGroupInfo": values.OutputGroupInfo,
+	"aspect":          functions.Aspect,
+	"provider":        functions.Provider,
+	"repository_rule": functions.RepositoryRule,
+	"rule":            functions.Rule,
+
+	// https://docs.bazel.build/versions/master/skylark/lib/attr.html
+	"attr": &starlarkstruct.Module{
+		Name: "attr",
+		Members: starlark.StringDict{
+			"bool":                    functions.AttrBool,
+			"int":                     functions.AttrInt,
+			"int_list":                functions.AttrIntList,
+			"label":                   functions.AttrLabel,
+			"label_keyed_string_dict": functions.AttrLabelKeyedStringDict,
+			"label_list":              functions.AttrLabelList,
+			"license":                 functions.AttrLicense,
+			"output":                  functions.AttrOutput,
+			"output_list":             functions.AttrOutputList,
+			"string":                  functions.AttrString,
+			"string_dict":             functions.AttrStringDict,
+			"string_list":             functions.AttrStringList,
+			"string_list_dict":        functions.AttrStringListDict,
 		},
 	},
-)
+}
 
-var workspaceGlobals = starlarkUnion(
-	commonGlobals,
-	starlark.StringDict{
-		"register_execution_platforms": functions.NotImplemented(
-			"register_execution_platforms"),
-		"register_toolchains": functions.NotImplemented("register_toolchains"),
-		"workspace":           workspace_rules.Workspace,
-
-		// Workspace Rules
-		// https://docs.bazel.build/versions/master/be/workspace.html
-		"bind":                 workspace_rules.NotImplemented("bind"),
-		"local_repository":     workspace_rules.NotImplemented("local_repository"),
-		"maven_jar":            workspace_rules.NotImplemented("maven_jar"),
-		"maven_server":         workspace_rules.NotImplemented("maven_server"),
-		"new_local_repository": workspace_rules.NotImplemented("new_local_repository"),
-		"xcode_config":         workspace_rules.NotImplemented("xcode_config"),
-		"xcode_version":        workspace_rules.NotImplemented("xcode_version"),
-	},
-)
+// workspaceGlobals are globals (other than workspace rules) only for WORKSPACE files.
+var workspaceGlobals = starlark.StringDict{
+	"register_execution_platforms": functions.NotImplemented("register_execution_platforms"),
+	"register_toolchains":          functions.NotImplemented("register_toolchains"),
+	"workspace":                    workspace_rules.Workspace,
+}
 
-// InitialGlobals returns the initial globals (builtins) for executing a Bazel file.
+// workspaceRules are workspace rules (exposed as globals in WORKSPACE files).
+//
+// These are documented in:
+//   https://docs.bazel.build/versions/master/be/workspace.html
+var workspaceRules = starlark.StringDict{
+	"bind":                 workspace_rules.NotImplemented("bind"),
+	"local_repository":     workspace_rules.NotImplemented("local_repository"),
+	"maven_jar":            workspace_rules.NotImplemented("maven_jar"),
+	"maven_server":         workspace_rules.NotImplemented("maven_server"),
+	"new_local_repository": workspace_rules.NotImplemented("new_local_repository"),
+	"xcode_config":         workspace_rules.NotImplemented("xcode_config"),
+	"xcode_version":        workspace_rules.NotImplemented("xcode_version"),
+}
+
+// defaultRegistry is the registry used by InitialGlobals.
+var defaultRegistry = NewDefaultRegistry()
+
+// InitialGlobals returns the default initial globals (builtins) for executing a Bazel file (see
+// Registry.InitialGlobals).
 func InitialGlobals(fileType core.FileType) starlark.StringDict {
-	switch fileType {
-	case core.FileTypeBuild:
-		return buildGlobals
-	case core.FileTypeBzl:
-		return bzlGlobals
-	case core.FileTypeWorkspace:
-		return workspaceGlobals
-	default:
-		panic(fileType)
-	}
+	return defaultRegistry.InitialGlobals(fileType)
 }
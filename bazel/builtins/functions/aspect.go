@@ -0,0 +1,59 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Aspect implements the Bazel aspect function.
+var Aspect = newFunction("aspect",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		var implementation starlark.Callable
+		var attrAspectsValue starlark.Value = starlark.NewList(nil)
+		var attrsValue starlark.Value = starlark.None
+		doc := ""
+		// TODO(vtl): Support (some of) these.
+		var requiredProviders, requiredAspectProviders, provides, requires, fragments,
+			hostFragments, toolchains, incompatibleUseToolchainTransition,
+			applyToGeneratingRules, execCompatibleWith, execGroups,
+			subrules starlark.Value
+		if err := starlark.UnpackArgs("aspect", args, kwargs,
+			"implementation", &implementation,
+			"attr_aspects?", &attrAspectsValue,
+			"attrs?", &attrsValue,
+			"required_providers?", &requiredProviders,
+			"required_aspect_providers?", &requiredAspectProviders,
+			"provides?", &provides,
+			"requires?", &requires,
+			"fragments?", &fragments,
+			"host_fragments?", &hostFragments,
+			"toolchains?", &toolchains,
+			"incompatible_use_toolchain_transition?",
+			&incompatibleUseToolchainTransition,
+			"doc?", &doc,
+			"apply_to_generating_rules?", &applyToGeneratingRules,
+			"exec_compatible_with?", &execCompatibleWith,
+			"exec_groups?", &execGroups,
+			"subrules?", &subrules); err != nil {
+			return nil, err
+		}
+
+		attrAspects, err := toStringSlice(attrAspectsValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument attr_aspects invalid: %v", err)
+		}
+		attrNames, attrs, err := toAttrs(attrsValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument attrs invalid: %v", err)
+		}
+		return values.NewAspect(implementation, attrAspects, attrNames, attrs, doc)
+	})
@@ -0,0 +1,301 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// attrArgNames are the names of the arguments accepted by the functions of the attr module, by
+// attribute type (in addition to "default", "doc", "mandatory" and "configurable", which are
+// accepted by all of them except that output and output_list have no "default").
+var attrArgNames = map[values.AttrType][]string{
+	values.AttrTypeBool:    {},
+	values.AttrTypeInt:     {"values"},
+	values.AttrTypeIntList: {"allow_empty", "non_empty"},
+	values.AttrTypeLabel: {"executable", "allow_files", "allow_single_file", "providers",
+		"allow_rules", "cfg", "aspects", "flags", "single_file"},
+	values.AttrTypeLabelKeyedStringDict: {"allow_empty", "non_empty", "allow_files",
+		"allow_rules", "providers", "flags", "cfg", "aspects"},
+	values.AttrTypeLabelList: {"allow_empty", "non_empty", "allow_files", "allow_rules",
+		"providers", "flags", "cfg", "aspects"},
+	values.AttrTypeLicense:        {},
+	values.AttrTypeOutput:         {},
+	values.AttrTypeOutputList:     {"allow_empty", "non_empty"},
+	values.AttrTypeString:         {"values"},
+	values.AttrTypeStringDict:     {"allow_empty", "non_empty"},
+	values.AttrTypeStringList:     {"allow_empty", "non_empty"},
+	values.AttrTypeStringListDict: {"allow_empty", "non_empty"},
+}
+
+// isAttrArg returns whether the function of the attr module for the given type accepts the given
+// argument.
+func isAttrArg(attrType values.AttrType, argName string) bool {
+	switch argName {
+	case "doc", "mandatory", "configurable":
+		return true
+	case "default":
+		return attrType != values.AttrTypeOutput && attrType != values.AttrTypeOutputList
+	}
+	for _, name := range attrArgNames[attrType] {
+		if name == argName {
+			return true
+		}
+	}
+	return false
+}
+
+// toBool converts a starlark bool to a bool.
+func toBool(value starlark.Value) (bool, error) {
+	b, ok := value.(starlark.Bool)
+	if !ok {
+		return false, fmt.Errorf("value is not a bool")
+	}
+	return bool(b), nil
+}
+
+// toAllowFiles converts the value of the allow_files (or allow_single_file) argument (a bool or a
+// list or tuple of file extensions) to whether files are allowed and the allowed file extensions
+// (nil if any file is allowed).
+func toAllowFiles(value starlark.Value) (bool, []string, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return false, nil, nil
+	case starlark.Bool:
+		return bool(v), nil, nil
+	case *starlark.List, starlark.Tuple:
+		fileTypes, err := toStringSlice(v)
+		if err != nil {
+			return false, nil, err
+		}
+		return true, fileTypes, nil
+	default:
+		return false, nil, fmt.Errorf("value is not a bool or list")
+	}
+}
+
+// toProviderList converts a list (or tuple) of providers (or, for legacy providers, strings).
+func toProviderList(l starlark.Indexable) ([]starlark.Value, error) {
+	rv := make([]starlark.Value, l.Len())
+	for i := range rv {
+		switch l.Index(i).(type) {
+		case *values.Provider, starlark.String:
+			rv[i] = l.Index(i)
+		default:
+			return nil, fmt.Errorf("invalid element: value is not a provider")
+		}
+	}
+	return rv, nil
+}
+
+// toAttrProviders converts the value of the providers argument (a list of providers, or a list of
+// lists of providers, where any list may also be a tuple) to a list of alternatives (each a list
+// of providers).
+func toAttrProviders(value starlark.Value) ([][]starlark.Value, error) {
+	l, ok := toSequence(value)
+	if !ok {
+		return nil, fmt.Errorf("value is not a list")
+	}
+	if l.Len() == 0 {
+		return nil, nil
+	}
+
+	if _, isList := toSequence(l.Index(0)); !isList {
+		// A single alternative.
+		alternative, err := toProviderList(l)
+		if err != nil {
+			return nil, err
+		}
+		return [][]starlark.Value{alternative}, nil
+	}
+	rv := make([][]starlark.Value, l.Len())
+	for i := range rv {
+		alternative, ok := toSequence(l.Index(i))
+		if !ok {
+			return nil, fmt.Errorf("invalid element: value is not a list")
+		}
+		var err error
+		if rv[i], err = toProviderList(alternative); err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// toAttrAspects converts the value of the aspects argument (a list or tuple of aspects).
+func toAttrAspects(value starlark.Value) ([]*values.Aspect, error) {
+	l, ok := toSequence(value)
+	if !ok {
+		return nil, fmt.Errorf("value is not a list")
+	}
+	rv := make([]*values.Aspect, l.Len())
+	for i := range rv {
+		if rv[i], ok = l.Index(i).(*values.Aspect); !ok {
+			return nil, fmt.Errorf("invalid element: value is not an aspect")
+		}
+	}
+	return rv, nil
+}
+
+// setAttrArg sets the field(s) of attr corresponding to the given argument.
+func setAttrArg(attr *values.Attr, argName string, value starlark.Value) error {
+	var err error
+	switch argName {
+	case "default":
+		value.Freeze()
+		attr.Default = value
+	case "doc":
+		doc, ok := value.(starlark.String)
+		if !ok {
+			return fmt.Errorf("value is not a string")
+		}
+		attr.Doc = string(doc)
+	case "mandatory":
+		attr.Mandatory, err = toBool(value)
+	case "configurable":
+		var configurable bool
+		configurable, err = toBool(value)
+		attr.Nonconfigurable = !configurable
+	case "values":
+		l, ok := toSequence(value)
+		if !ok {
+			return fmt.Errorf("value is not a list")
+		}
+		l.Freeze()
+		attr.Values = make([]starlark.Value, l.Len())
+		for i := range attr.Values {
+			attr.Values[i] = l.Index(i)
+		}
+	case "allow_empty":
+		attr.AllowEmpty, err = toBool(value)
+	case "non_empty":
+		var nonEmpty bool
+		nonEmpty, err = toBool(value)
+		attr.AllowEmpty = !nonEmpty
+	case "allow_files":
+		attr.AllowFiles, attr.AllowedFileTypes, err = toAllowFiles(value)
+	case "allow_single_file":
+		attr.AllowFiles, attr.AllowedFileTypes, err = toAllowFiles(value)
+		attr.SingleFile = attr.AllowFiles
+	case "single_file":
+		attr.SingleFile, err = toBool(value)
+	case "executable":
+		attr.Executable, err = toBool(value)
+	case "providers":
+		attr.Providers, err = toAttrProviders(value)
+	case "allow_rules":
+		if value != starlark.None {
+			attr.AllowRules, err = toStringSlice(value)
+		}
+	case "cfg":
+		if s, ok := value.(starlark.String); ok && s != "target" && s != "exec" &&
+			s != "host" {
+			return fmt.Errorf("invalid value %v", s)
+		}
+		if value != starlark.None {
+			value.Freeze()
+			attr.Cfg = value
+		}
+	case "aspects":
+		attr.Aspects, err = toAttrAspects(value)
+	case "flags":
+		// Legacy flags are ignored.
+		_, err = toStringSlice(value)
+	default:
+		panic(argName)
+	}
+	return err
+}
+
+// newAttr creates an attribute schema of the given type, from the arguments to the corresponding
+// function of the attr module.
+func newAttr(attrType values.AttrType, args starlark.Tuple, kwargs []starlark.Tuple) (*values.Attr,
+	error) {
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("unexpected positional arguments")
+	}
+
+	rv := &values.Attr{AttrType: attrType, AllowEmpty: true}
+	for _, kwarg := range kwargs {
+		argName := string(kwarg[0].(starlark.String))
+		if !isAttrArg(attrType, argName) {
+			return nil, fmt.Errorf("unexpected keyword argument %v", argName)
+		}
+		if err := setAttrArg(rv, argName, kwarg[1]); err != nil {
+			return nil, fmt.Errorf("argument %v invalid: %v", argName, err)
+		}
+	}
+	if rv.Executable && rv.Cfg == nil {
+		return nil, fmt.Errorf("argument cfg required when executable is True")
+	}
+	return rv, nil
+}
+
+// newAttrFunction creates a function of the attr module (e.g., attr.string), which returns an
+// attribute schema of the given type.
+func newAttrFunction(attrType values.AttrType) *starlark.Builtin {
+	return newFunction(string(attrType), func(ctx core.Context, args starlark.Tuple,
+		kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		return newAttr(attrType, args, kwargs)
+	})
+}
+
+// Functions of the attr module.
+var (
+	AttrBool                 = newAttrFunction(values.AttrTypeBool)
+	AttrInt                  = newAttrFunction(values.AttrTypeInt)
+	AttrIntList              = newAttrFunction(values.AttrTypeIntList)
+	AttrLabel                = newAttrFunction(values.AttrTypeLabel)
+	AttrLabelKeyedStringDict = newAttrFunction(values.AttrTypeLabelKeyedStringDict)
+	AttrLabelList            = newAttrFunction(values.AttrTypeLabelList)
+	AttrLicense              = newAttrFunction(values.AttrTypeLicense)
+	AttrOutput               = newAttrFunction(values.AttrTypeOutput)
+	AttrOutputList           = newAttrFunction(values.AttrTypeOutputList)
+	AttrString               = newAttrFunction(values.AttrTypeString)
+	AttrStringDict           = newAttrFunction(values.AttrTypeStringDict)
+	AttrStringList           = newAttrFunction(values.AttrTypeStringList)
+	AttrStringListDict       = newAttrFunction(values.AttrTypeStringListDict)
+)
+
+// attrNameRegexp matches valid attribute names (which are starlark identifiers).
+var attrNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// toAttrs converts an attrs argument (of rule, aspect, or repository_rule; a dict mapping
+// attribute names to attribute schemas, or None) to (ordered) attribute names and schemas.
+func toAttrs(value starlark.Value) ([]string, []*values.Attr, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil, nil
+	case *starlark.Dict:
+		attrNames := make([]string, 0, v.Len())
+		attrs := make([]*values.Attr, 0, v.Len())
+		for _, item := range v.Items() {
+			attrName, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid key: value is not a string")
+			}
+			if !attrNameRegexp.MatchString(string(attrName)) {
+				return nil, nil, fmt.Errorf("invalid attribute name %v", attrName)
+			}
+			attr, ok := item[1].(*values.Attr)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid value for key %v: value is "+
+					"not an attribute", attrName)
+			}
+			attrNames = append(attrNames, string(attrName))
+			attrs = append(attrs, attr)
+		}
+		return attrNames, attrs, nil
+	default:
+		return nil, nil, fmt.Errorf("value is not a dict")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// toValueSlice converts a starlark list (or None) to a []starlark.Value.
+func toValueSlice(value starlark.Value) ([]starlark.Value, error) {
+	if value == starlark.None {
+		return nil, nil
+	}
+	l, ok := value.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("value is not a list")
+	}
+	rv := make([]starlark.Value, l.Len())
+	for i := 0; i < l.Len(); i++ {
+		rv[i] = l.Index(i)
+	}
+	return rv, nil
+}
+
+// toDepsetSlice converts a starlark list of depsets (or None) to a []*values.Depset.
+func toDepsetSlice(value starlark.Value) ([]*values.Depset, error) {
+	elems, err := toValueSlice(value)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]*values.Depset, len(elems))
+	for i, elem := range elems {
+		d, ok := elem.(*values.Depset)
+		if !ok {
+			return nil, fmt.Errorf("invalid element: value is not a depset")
+		}
+		rv[i] = d
+	}
+	return rv, nil
+}
+
+// Depset implements the Bazel depset function.
+var Depset = newFunction("depset",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		var directValue starlark.Value = starlark.None
+		order := string(values.DepsetOrderDefault)
+		var transitiveValue starlark.Value = starlark.None
+		if err := starlark.UnpackArgs("depset", args, kwargs, "direct?", &directValue,
+			"order?", &order, "transitive?", &transitiveValue); err != nil {
+			return nil, err
+		}
+
+		direct, err := toValueSlice(directValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument direct invalid: %v", err)
+		}
+		transitive, err := toDepsetSlice(transitiveValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument transitive invalid: %v", err)
+		}
+		return values.NewDepset(values.DepsetOrder(order), direct, transitive)
+	})
@@ -0,0 +1,68 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// currentPackageTargets returns the targets (so far) of the package being evaluated.
+func currentPackageTargets(ctx core.Context) *core.PackageTargets {
+	return ctx.BuildTargets()[ctx.Label().Workspace][ctx.Label().Package]
+}
+
+// isRuleTarget returns whether the target is a rule target (as opposed to a file target).
+func isRuleTarget(target core.Target) bool {
+	_, isSourceFile := target.(*core.SourceFileTarget)
+	return !isSourceFile
+}
+
+// ExistingRule implements the Bazel existing_rule function.
+var ExistingRule = newFunction("existing_rule",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		var name string
+		if err := starlark.UnpackArgs("existing_rule", args, kwargs, "name",
+			&name); err != nil {
+			return nil, err
+		}
+
+		target, ok := currentPackageTargets(ctx).TargetsByName[core.TargetName(name)]
+		if !ok || !isRuleTarget(target) {
+			return starlark.None, nil
+		}
+		return rules.TargetAttrs(target), nil
+	})
+
+// ExistingRules implements the Bazel existing_rules function.
+var ExistingRules = newFunction("existing_rules",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		if err := starlark.UnpackPositionalArgs("existing_rules", args, kwargs,
+			0); err != nil {
+			return nil, err
+		}
+
+		packageTargets := currentPackageTargets(ctx)
+		rv := starlark.NewDict(len(packageTargets.TargetList))
+		for _, target := range packageTargets.TargetList {
+			if !isRuleTarget(target) {
+				continue
+			}
+			rv.SetKey(starlark.String(target.Label().Target), rules.TargetAttrs(target))
+		}
+		rv.Freeze()
+		return rv, nil
+	})
@@ -0,0 +1,86 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// exportsFilesArgs are the arguments to exports_files().
+type exportsFilesArgs struct {
+	Srcs       *[]string     `bazel:"srcs!"`
+	Visibility *[]core.Label `bazel:"visibility"`
+	Licenses   *[]string     `bazel:"licenses,license"`
+}
+
+var _ builtins_args.ProcessArgsTarget = (*exportsFilesArgs)(nil)
+
+func (self *exportsFilesArgs) DidProcessArgs(ctx core.Context) error {
+	if self.Srcs == nil {
+		return fmt.Errorf("argument srcs invalid: value is not a list")
+	}
+	for _, src := range *self.Srcs {
+		if !core.TargetName(src).IsValid() {
+			return fmt.Errorf("argument srcs invalid: invalid file name %v", src)
+		}
+	}
+	return nil
+}
+
+// positionalToKwargs converts positional arguments to keyword arguments with the given names
+// (for functions whose arguments are processed using ProcessArgs, which only accepts keyword
+// arguments).
+func positionalToKwargs(args starlark.Tuple, kwargs []starlark.Tuple,
+	argNames ...string) ([]starlark.Tuple, error) {
+
+	if len(args) > len(argNames) {
+		return nil, fmt.Errorf("got %v positional arguments, want at most %v", len(args),
+			len(argNames))
+	}
+	rv := make([]starlark.Tuple, 0, len(args)+len(kwargs))
+	for i, arg := range args {
+		rv = append(rv, starlark.Tuple{starlark.String(argNames[i]), arg})
+	}
+	return append(rv, kwargs...), nil
+}
+
+// ExportsFiles implements the Bazel exports_files function, which creates (exported) source file
+// targets.
+var ExportsFiles = newFunction("exports_files",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		kwargs, err := positionalToKwargs(args, kwargs, "srcs", "visibility", "licenses")
+		if err != nil {
+			return nil, err
+		}
+		a := &exportsFilesArgs{}
+		if err := builtins_args.ProcessArgs(nil, kwargs, ctx, a); err != nil {
+			return nil, err
+		}
+
+		packageTargets := currentPackageTargets(ctx)
+		for _, src := range *a.Srcs {
+			target := core.NewSourceFileTarget(core.Label{
+				Workspace: ctx.Label().Workspace,
+				Package:   ctx.Label().Package,
+				Target:    core.TargetName(src),
+			})
+			target.Exported = true
+			target.Visibility = a.Visibility
+			target.Licenses = a.Licenses
+			if err := packageTargets.Add(target); err != nil {
+				return nil, err
+			}
+		}
+		return starlark.None, nil
+	})
@@ -0,0 +1,61 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// failArgToString converts an argument of fail to a string (like str()).
+func failArgToString(value starlark.Value) string {
+	if s, ok := value.(starlark.String); ok {
+		return string(s)
+	}
+	return value.String()
+}
+
+// Fail implements the Bazel fail function, which causes execution to fail with an error. Unlike
+// for other functions, the error is not annotated with the label of the file being executed,
+// since the backtrace (of the resulting starlark.EvalError) already contains the location.
+var Fail = starlark.NewBuiltin("fail", func(thread *starlark.Thread, _ *starlark.Builtin,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var msg starlark.Value = starlark.None
+	var attr starlark.Value = starlark.None
+	sep := " "
+	for _, kwarg := range kwargs {
+		switch name := string(kwarg[0].(starlark.String)); name {
+		case "msg":
+			msg = kwarg[1]
+		case "attr":
+			attr = kwarg[1]
+		case "sep":
+			s, ok := kwarg[1].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("argument sep invalid: value is not a " +
+					"string")
+			}
+			sep = string(s)
+		default:
+			return nil, fmt.Errorf("unexpected keyword argument %v", name)
+		}
+	}
+
+	parts := []string{}
+	if msg != starlark.None {
+		parts = append(parts, failArgToString(msg))
+	}
+	for _, arg := range args {
+		parts = append(parts, failArgToString(arg))
+	}
+	message := strings.Join(parts, sep)
+
+	if attr != starlark.None {
+		message = fmt.Sprintf("attribute %v: %v", failArgToString(attr), message)
+	}
+	return nil, fmt.Errorf("%v", message)
+})
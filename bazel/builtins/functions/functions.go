@@ -3,3 +3,80 @@
 
 // Package functions contains implementations of various Bazel (non-rule) functions.
 package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// newFunction creates a new function starlark.Builtin. Errors returned by impl are annotated with
+// the label of the file being executed and the function name.
+func newFunction(fnName string, impl func(ctx core.Context, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error)) *starlark.Builtin {
+
+	return newThreadFunction(fnName, func(_ *starlark.Thread, ctx core.Context,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		return impl(ctx, args, kwargs)
+	})
+}
+
+// newThreadFunction is like newFunction, but impl is also given the thread on which the function
+// is called (e.g., to record calls; see core.RecordNotImplementedCall).
+func newThreadFunction(fnName string, impl func(thread *starlark.Thread, ctx core.Context,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)) *starlark.Builtin {
+
+	return starlark.NewBuiltin(fnName, func(thread *starlark.Thread, _ *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		ctx := core.GetContext(thread)
+
+		rv, err := impl(thread, ctx, args, kwargs)
+		if err != nil {
+			return starlark.None, fmt.Errorf("%v: %v: %v", ctx.Label(), fnName, err)
+		}
+
+		return rv, nil
+	})
+}
+
+// toSequence returns the given value as an Indexable if it is a list or tuple.
+func toSequence(value starlark.Value) (starlark.Indexable, bool) {
+	switch v := value.(type) {
+	case *starlark.List:
+		return v, true
+	case starlark.Tuple:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// toStringSlice converts a starlark list (or tuple) of strings to a []string.
+func toStringSlice(value starlark.Value) ([]string, error) {
+	l, ok := toSequence(value)
+	if !ok {
+		return nil, fmt.Errorf("value is not a list")
+	}
+	rv := make([]string, l.Len())
+	for i := 0; i < l.Len(); i++ {
+		s, ok := l.Index(i).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid element: value is not a string")
+		}
+		rv[i] = string(s)
+	}
+	return rv, nil
+}
+
+// fromStringSlice converts a []string to a starlark list of strings.
+func fromStringSlice(ss []string) *starlark.List {
+	elems := make([]starlark.Value, len(ss))
+	for i, s := range ss {
+		elems[i] = starlark.String(s)
+	}
+	return starlark.NewList(elems)
+}
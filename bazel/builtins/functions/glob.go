@@ -0,0 +1,58 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+	"os"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+	"src.tricot.io/public/bazel2x/bazel/utils"
+)
+
+// Glob implements the Bazel glob function.
+var Glob = newFunction("glob",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+
+		var includeValue starlark.Value
+		var excludeValue starlark.Value = starlark.NewList(nil)
+		var excludeDirectories starlark.Int = starlark.MakeInt(1)
+		var allowEmpty starlark.Bool = starlark.True
+		if err := starlark.UnpackArgs("glob", args, kwargs, "include", &includeValue,
+			"exclude?", &excludeValue, "exclude_directories?", &excludeDirectories,
+			"allow_empty?", &allowEmpty); err != nil {
+			return nil, err
+		}
+
+		include, err := toStringSlice(includeValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument include invalid: %v", err)
+		}
+		exclude, err := toStringSlice(excludeValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument exclude invalid: %v", err)
+		}
+
+		label := ctx.Label()
+		readDir := func(dirPath string) ([]os.FileInfo, error) {
+			return ctx.ReadSourceDir(label.Workspace, dirPath)
+		}
+		options := utils.GlobOptions{
+			ExcludeDirectories: excludeDirectories.Sign() != 0,
+			AllowEmpty:         bool(allowEmpty),
+		}
+		matches, err := utils.Glob(readDir, string(label.Package), include, exclude,
+			options)
+		if err != nil {
+			return nil, err
+		}
+		return fromStringSlice(matches), nil
+	})
@@ -0,0 +1,54 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// callerLabel returns the label of the file containing the caller of the currently-executing
+// builtin (e.g., a .bzl file defining a macro), or the label of the file being executed if it can't
+// be determined.
+func callerLabel(thread *starlark.Thread) core.Label {
+	ctx := core.GetContext(thread)
+	if thread.CallStackDepth() > 1 {
+		label, err := core.ParseLabel(ctx.Label().Workspace, "",
+			thread.CallFrame(1).Pos.Filename())
+		if err == nil {
+			return label
+		}
+	}
+	return ctx.Label()
+}
+
+// Label implements the Bazel Label constructor. Unlike strings used as labels in attributes, which
+// are relative to the current package, the label string is relative to the package of the file in
+// which Label() is called.
+var Label = starlark.NewBuiltin("Label", func(thread *starlark.Thread, _ *starlark.Builtin,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var input starlark.Value
+	if err := starlark.UnpackArgs("Label", args, kwargs, "input", &input); err != nil {
+		return nil, err
+	}
+
+	switch v := input.(type) {
+	case *values.Label:
+		return v, nil
+	case starlark.String:
+		relativeTo := callerLabel(thread)
+		label, err := values.ParseRelativeLabel(relativeTo, string(v))
+		if err != nil {
+			return nil, fmt.Errorf("%v: Label: %v", relativeTo, err)
+		}
+		return values.NewLabel(label), nil
+	default:
+		return nil, fmt.Errorf("Label: argument input invalid: not a string or Label")
+	}
+})
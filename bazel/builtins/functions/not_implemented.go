@@ -5,9 +5,12 @@ package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functio
 
 import (
 	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
 // NotImplemented is used for Bazel functions (including rules) that we haven't implemented (yet).
+// Calls are recorded (see core.RecordNotImplementedCall).
 //
 // Note: While some functions are executed at the top-level (for their side effects), some are used
 // for their return values, in which case the None return will cause execution to fail.
@@ -15,16 +18,23 @@ func NotImplemented(fnName string) *starlark.Builtin {
 	return starlark.NewBuiltin(fnName, func(thread *starlark.Thread, _ *starlark.Builtin,
 		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
+		if err := core.RecordNotImplementedCall(thread, fnName); err != nil {
+			return starlark.None, err
+		}
 		return starlark.None, nil
 	})
 }
 
 // NotImplementedRv is used for Bazel functions that we haven't implemented (yet), which are
-// required to return a value other than None.
+// required to return a value other than None. Calls are recorded (see
+// core.RecordNotImplementedCall).
 func NotImplementedRv(fnName string, rv starlark.Value) *starlark.Builtin {
 	return starlark.NewBuiltin(fnName, func(thread *starlark.Thread, _ *starlark.Builtin,
 		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
+		if err := core.RecordNotImplementedCall(thread, fnName); err != nil {
+			return starlark.None, err
+		}
 		return rv, nil
 	})
 }
@@ -0,0 +1,157 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// checkBuildFile checks that a BUILD[.bazel] file is being executed (functions that refer to "the
+// current package" may only be called while executing a BUILD file, possibly from a macro).
+func checkBuildFile(ctx core.Context) error {
+	if ctx.FileType() != core.FileTypeBuild {
+		return fmt.Errorf("can only be called while evaluating a BUILD[.bazel] file")
+	}
+	return nil
+}
+
+// PackageName implements the Bazel package_name function.
+var PackageName = newFunction("package_name",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		if err := starlark.UnpackPositionalArgs("package_name", args, kwargs,
+			0); err != nil {
+			return nil, err
+		}
+		return starlark.String(ctx.Label().Package), nil
+	})
+
+// RepositoryName implements the Bazel repository_name function. Like Bazel, this returns "@" for
+// the main repository.
+var RepositoryName = newFunction("repository_name",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		if err := starlark.UnpackPositionalArgs("repository_name", args, kwargs,
+			0); err != nil {
+			return nil, err
+		}
+		return starlark.String("@" + string(ctx.Label().Workspace)), nil
+	})
+
+// PackageRelativeLabel implements the Bazel package_relative_label function, which converts a
+// label string (or Label) to a Label, relative to the current package.
+var PackageRelativeLabel = newFunction("package_relative_label",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		var input starlark.Value
+		if err := starlark.UnpackArgs("package_relative_label", args, kwargs, "input",
+			&input); err != nil {
+			return nil, err
+		}
+
+		switch v := input.(type) {
+		case *values.Label:
+			return v, nil
+		case starlark.String:
+			// A plain target name (e.g., "foo") is relative to the current package.
+			label, err := values.ParseRelativeLabel(ctx.Label(), string(v))
+			if err != nil {
+				return nil, fmt.Errorf("argument input invalid: %v", err)
+			}
+			return values.NewLabel(label), nil
+		default:
+			return nil, fmt.Errorf("argument input invalid: value is not a string or " +
+				"Label")
+		}
+	})
+
+// ModuleName implements the Bazel module_name function. Since Bzlmod is not supported, it always
+// returns None.
+var ModuleName = newFunction("module_name",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := starlark.UnpackPositionalArgs("module_name", args, kwargs,
+			0); err != nil {
+			return nil, err
+		}
+		return starlark.None, nil
+	})
+
+// ModuleVersion implements the Bazel module_version function. Since Bzlmod is not supported, it
+// always returns None.
+var ModuleVersion = newFunction("module_version",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := starlark.UnpackPositionalArgs("module_version", args, kwargs,
+			0); err != nil {
+			return nil, err
+		}
+		return starlark.None, nil
+	})
+
+// packageArgs are the arguments to package().
+type packageArgs struct {
+	DefaultVisibility         *[]core.Label `bazel:"default_visibility"`
+	DefaultTestonly           *bool         `bazel:"default_testonly"`
+	DefaultDeprecation        *string       `bazel:"default_deprecation"`
+	DefaultApplicableLicenses *[]core.Label `bazel:"default_applicable_licenses"`
+	Features                  *[]string     `bazel:"features"`
+}
+
+var _ builtins_args.ProcessArgsTarget = (*packageArgs)(nil)
+
+func (self *packageArgs) DidProcessArgs(ctx core.Context) error {
+	return nil
+}
+
+// Package implements the Bazel package function, which sets the current package's metadata. It
+// may be called at most once, before any rules are called.
+var Package = newFunction("package",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if err := checkBuildFile(ctx); err != nil {
+			return nil, err
+		}
+		packageTargets := currentPackageTargets(ctx)
+		if packageTargets.Metadata != nil {
+			return nil, fmt.Errorf("can only be called once per package")
+		}
+		if len(packageTargets.TargetList) > 0 {
+			return nil, fmt.Errorf("must be called before any rules")
+		}
+
+		a := &packageArgs{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, a); err != nil {
+			return nil, err
+		}
+		packageTargets.Metadata = &core.PackageMetadata{
+			DefaultVisibility:         a.DefaultVisibility,
+			DefaultTestonly:           a.DefaultTestonly,
+			DefaultDeprecation:        a.DefaultDeprecation,
+			DefaultApplicableLicenses: a.DefaultApplicableLicenses,
+			Features:                  a.Features,
+		}
+		return starlark.None, nil
+	})
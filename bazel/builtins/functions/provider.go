@@ -0,0 +1,58 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// toProviderFields converts the fields argument of provider (a list of field names or a dict
+// mapping field names to their documentation, or None) to a []string.
+func toProviderFields(value starlark.Value) ([]string, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *starlark.List:
+		return toStringSlice(v)
+	case *starlark.Dict:
+		rv := make([]string, 0, v.Len())
+		for _, item := range v.Items() {
+			field, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("invalid key: value is not a string")
+			}
+			if _, ok := item[1].(starlark.String); !ok {
+				return nil, fmt.Errorf("invalid value: value is not a string")
+			}
+			rv = append(rv, string(field))
+		}
+		return rv, nil
+	default:
+		return nil, fmt.Errorf("value is not a list or dict")
+	}
+}
+
+// Provider implements the Bazel provider function.
+var Provider = newFunction("provider",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		doc := ""
+		var fieldsValue starlark.Value = starlark.None
+		if err := starlark.UnpackArgs("provider", args, kwargs, "doc?", &doc, "fields?",
+			&fieldsValue); err != nil {
+			return nil, err
+		}
+
+		fields, err := toProviderFields(fieldsValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument fields invalid: %v", err)
+		}
+		return values.NewProvider(doc, fields), nil
+	})
@@ -0,0 +1,49 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/workspace_rules"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// RepositoryRule implements the Bazel repository_rule function.
+var RepositoryRule = newFunction("repository_rule",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		var implementation starlark.Callable
+		var attrsValue starlark.Value = starlark.None
+		local := false
+		var environValue starlark.Value = starlark.NewList(nil)
+		configure := false
+		doc := ""
+		// TODO(vtl): Support remotable.
+		var remotable starlark.Value
+		if err := starlark.UnpackArgs("repository_rule", args, kwargs,
+			"implementation", &implementation,
+			"attrs?", &attrsValue,
+			"local?", &local,
+			"environ?", &environValue,
+			"configure?", &configure,
+			"remotable?", &remotable,
+			"doc?", &doc); err != nil {
+			return nil, err
+		}
+
+		attrNames, attrs, err := toAttrs(attrsValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument attrs invalid: %v", err)
+		}
+		environ, err := toStringSlice(environValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument environ invalid: %v", err)
+		}
+		return workspace_rules.NewStarlarkRepositoryRule(implementation, attrNames, attrs,
+			local, environ, configure, doc)
+	})
@@ -0,0 +1,96 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Rule implements the Bazel rule function. Arguments that we don't support (yet) are accepted, but
+// recorded as calls to builtins that we haven't implemented (e.g., "rule(cfg)"), except for those
+// that don't affect how we interpret rules (like fragments).
+var Rule = newThreadFunction("rule",
+	func(thread *starlark.Thread, ctx core.Context, args starlark.Tuple,
+		kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		var implementation starlark.Callable
+		test := false
+		var attrsValue starlark.Value = starlark.None
+		executable := false
+		doc := ""
+		var fragments, hostFragments, skylarkTestable,
+			incompatibleUseToolchainTransition starlark.Value
+		// TODO(vtl): Support (some of) these.
+		var outputs, outputToGenfiles, toolchains, provides, execCompatibleWith,
+			analysisTest, buildSetting, cfg, execGroups, initializer, parent,
+			extendable, subrules starlark.Value
+		if err := starlark.UnpackArgs("rule", args, kwargs,
+			"implementation", &implementation,
+			"test?", &test,
+			"attrs?", &attrsValue,
+			"outputs?", &outputs,
+			"executable?", &executable,
+			"output_to_genfiles?", &outputToGenfiles,
+			"fragments?", &fragments,
+			"host_fragments?", &hostFragments,
+			"_skylark_testable?", &skylarkTestable,
+			"toolchains?", &toolchains,
+			"incompatible_use_toolchain_transition?",
+			&incompatibleUseToolchainTransition,
+			"doc?", &doc,
+			"provides?", &provides,
+			"exec_compatible_with?", &execCompatibleWith,
+			"analysis_test?", &analysisTest,
+			"build_setting?", &buildSetting,
+			"cfg?", &cfg,
+			"exec_groups?", &execGroups,
+			"initializer?", &initializer,
+			"parent?", &parent,
+			"extendable?", &extendable,
+			"subrules?", &subrules); err != nil {
+			return nil, err
+		}
+
+		notImplementedArgs := []struct {
+			argName string
+			value   starlark.Value
+		}{
+			{"outputs", outputs},
+			{"output_to_genfiles", outputToGenfiles},
+			{"toolchains", toolchains},
+			{"provides", provides},
+			{"exec_compatible_with", execCompatibleWith},
+			{"analysis_test", analysisTest},
+			{"build_setting", buildSetting},
+			{"cfg", cfg},
+			{"exec_groups", execGroups},
+			{"initializer", initializer},
+			{"parent", parent},
+			{"extendable", extendable},
+			{"subrules", subrules},
+		}
+		for _, arg := range notImplementedArgs {
+			// The arguments' default values are None (or False, for analysis_test).
+			if arg.value == nil || arg.value == starlark.None ||
+				arg.value == starlark.False {
+				continue
+			}
+			if err := core.RecordNotImplementedCall(thread,
+				"rule("+arg.argName+")"); err != nil {
+				return nil, err
+			}
+		}
+
+		attrNames, attrs, err := toAttrs(attrsValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument attrs invalid: %v", err)
+		}
+		return rules.NewStarlarkRule(ctx, implementation, attrNames, attrs, doc, test,
+			executable)
+	})
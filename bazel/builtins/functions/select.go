@@ -0,0 +1,45 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Select implements the Bazel select function.
+var Select = newFunction("select",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		var conditions *starlark.Dict
+		var noMatchError string
+		if err := starlark.UnpackArgs("select", args, kwargs, "x", &conditions,
+			"no_match_error?", &noMatchError); err != nil {
+			return nil, err
+		}
+
+		if conditions.Len() == 0 {
+			return nil, fmt.Errorf("select({}) with an empty dictionary can never " +
+				"resolve because it includes no conditions to match")
+		}
+		for _, key := range conditions.Keys() {
+			switch key.(type) {
+			case starlark.String, *values.Label:
+			default:
+				return nil, fmt.Errorf("invalid key %v: keys must be labels", key)
+			}
+		}
+
+		// Copy the dictionary, since we'll freeze it.
+		conditionsCopy := &starlark.Dict{}
+		for _, item := range conditions.Items() {
+			conditionsCopy.SetKey(item[0], item[1])
+		}
+		return values.NewSelect(conditionsCopy, noMatchError), nil
+	})
@@ -0,0 +1,25 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package functions // import "src.tricot.io/public/bazel2x/bazel/builtins/functions"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Struct implements the Bazel struct function.
+var Struct = newFunction("struct",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value,
+		error) {
+
+		if len(args) > 0 {
+			return nil, fmt.Errorf("unexpected positional arguments")
+		}
+		return values.NewStruct(starlarkstruct.Default, kwargs)
+	})
@@ -0,0 +1,161 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package builtins // import "src.tricot.io/public/bazel2x/bazel/builtins"
+
+import (
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// fileTypes are the file types for which a Registry provides globals.
+var fileTypes = []core.FileType{core.FileTypeBuild, core.FileTypeBzl, core.FileTypeWorkspace}
+
+// Registry contains the builtins (rules, functions, workspace rules, and members of native) from
+// which the initial globals for executing Bazel files are made. Registering a builtin with the
+// same name as an existing one (of the same kind) overrides it.
+//
+// A Registry should not be modified while files are being executed using its globals.
+type Registry struct {
+	// rules are the rules, which are globals in BUILD files and members of native.
+	rules starlark.StringDict
+
+	// functions are the (non-rule) globals, keyed by file type.
+	functions map[core.FileType]starlark.StringDict
+
+	// nativeMembers are the non-rule members of native, keyed by file type (BUILD or .bzl).
+	nativeMembers map[core.FileType]starlark.StringDict
+
+	// workspaceRules are the workspace rules, which are globals in WORKSPACE files.
+	workspaceRules starlark.StringDict
+
+	// initialGlobals caches the initial globals, keyed by file type. It is cleared whenever a
+	// builtin is registered.
+	initialGlobals map[core.FileType]starlark.StringDict
+}
+
+// checkFileType panics if fileType isn't one for which a Registry provides globals.
+func checkFileType(fileType core.FileType) {
+	for _, ft := range fileTypes {
+		if ft == fileType {
+			return
+		}
+	}
+	panic(fileType)
+}
+
+// NewRegistry creates a new, empty Registry.
+func NewRegistry() *Registry {
+	rv := &Registry{
+		rules:          starlark.StringDict{},
+		functions:      map[core.FileType]starlark.StringDict{},
+		nativeMembers:  map[core.FileType]starlark.StringDict{},
+		workspaceRules: starlark.StringDict{},
+	}
+	for _, fileType := range fileTypes {
+		rv.functions[fileType] = starlark.StringDict{}
+		rv.nativeMembers[fileType] = starlark.StringDict{}
+	}
+	return rv
+}
+
+// NewDefaultRegistry creates a new Registry containing the default builtins (i.e., those that we
+// implement), which can then be added to or overridden.
+func NewDefaultRegistry() *Registry {
+	rv := NewRegistry()
+	for name, rule := range rulesGlobals {
+		rv.RegisterRule(name, rule)
+	}
+	for _, fileType := range fileTypes {
+		for name, fn := range commonGlobals {
+			rv.RegisterFunction(fileType, name, fn)
+		}
+	}
+	for _, fileType := range []core.FileType{core.FileTypeBuild, core.FileTypeBzl} {
+		for name, fn := range buildAndbzlCommonGlobals {
+			rv.RegisterFunction(fileType, name, fn)
+		}
+		for name, member := range nativeMembers {
+			rv.RegisterNativeMember(fileType, name, member)
+		}
+	}
+	for name, fn := range buildGlobals {
+		rv.RegisterFunction(core.FileTypeBuild, name, fn)
+	}
+	for name, fn := range bzlGlobals {
+		rv.RegisterFunction(core.FileTypeBzl, name, fn)
+	}
+	for name, fn := range workspaceGlobals {
+		rv.RegisterFunction(core.FileTypeWorkspace, name, fn)
+	}
+	for name, rule := range workspaceRules {
+		rv.RegisterWorkspaceRule(name, rule)
+	}
+	return rv
+}
+
+// RegisterRule registers a rule (see, e.g., rules.NewRule), which is a global in BUILD files and a
+// member of native (in BUILD and .bzl files).
+func (self *Registry) RegisterRule(name string, rule starlark.Value) {
+	self.rules[name] = rule
+	self.initialGlobals = nil
+}
+
+// RegisterFunction registers a (non-rule) global for files of the given type.
+func (self *Registry) RegisterFunction(fileType core.FileType, name string, fn starlark.Value) {
+	checkFileType(fileType)
+	self.functions[fileType][name] = fn
+	self.initialGlobals = nil
+}
+
+// RegisterNativeMember registers a non-rule member of native for files of the given type (which
+// must be core.FileTypeBuild or core.FileTypeBzl).
+func (self *Registry) RegisterNativeMember(fileType core.FileType, name string,
+	member starlark.Value) {
+
+	if fileType != core.FileTypeBuild && fileType != core.FileTypeBzl {
+		panic(fileType)
+	}
+	self.nativeMembers[fileType][name] = member
+	self.initialGlobals = nil
+}
+
+// RegisterWorkspaceRule registers a workspace rule, which is a global in WORKSPACE files.
+func (self *Registry) RegisterWorkspaceRule(name string, rule starlark.Value) {
+	self.workspaceRules[name] = rule
+	self.initialGlobals = nil
+}
+
+// InitialGlobals returns the initial globals (builtins) for executing a Bazel file of the given
+// type. Rules (resp. workspace rules) take precedence over functions of the same name. The result
+// should not be modified.
+func (self *Registry) InitialGlobals(fileType core.FileType) starlark.StringDict {
+	checkFileType(fileType)
+	if rv, ok := self.initialGlobals[fileType]; ok {
+		return rv
+	}
+
+	var rv starlark.StringDict
+	switch fileType {
+	case core.FileTypeBuild:
+		rv = starlarkUnion(self.functions[fileType], self.rules)
+	case core.FileTypeBzl:
+		rv = starlarkUnion(self.functions[fileType])
+	case core.FileTypeWorkspace:
+		rv = starlarkUnion(self.functions[fileType], self.workspaceRules)
+	}
+	if fileType == core.FileTypeBuild || fileType == core.FileTypeBzl {
+		rv["native"] = &starlarkstruct.Module{
+			Name:    "native",
+			Members: starlarkUnion(self.nativeMembers[fileType], self.rules),
+		}
+	}
+
+	if self.initialGlobals == nil {
+		self.initialGlobals = make(map[core.FileType]starlark.StringDict)
+	}
+	self.initialGlobals[fileType] = rv
+	return rv
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package builtins_test
+
+import (
+	"testing"
+
+	"go.starlark.net/starlark"
+
+	. "src.tricot.io/public/bazel2x/bazel/builtins"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// nativeMember returns the given member of native in the globals (or nil if there's no such
+// member or no native).
+func nativeMember(globals starlark.StringDict, name string) starlark.Value {
+	native, ok := globals["native"].(starlark.HasAttrs)
+	if !ok {
+		return nil
+	}
+	member, _ := native.Attr(name)
+	return member
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewDefaultRegistry()
+
+	buildGlobals := registry.InitialGlobals(core.FileTypeBuild)
+	if buildGlobals["cc_library"] == nil || nativeMember(buildGlobals, "cc_library") == nil {
+		t.Errorf("cc_library missing from BUILD globals or native")
+	}
+	if buildGlobals["rule"] != nil {
+		t.Errorf("rule unexpectedly in BUILD globals")
+	}
+	if nativeMember(registry.InitialGlobals(core.FileTypeBzl), "glob") == nil {
+		t.Errorf("native.glob missing from .bzl globals")
+	}
+	workspaceGlobals := registry.InitialGlobals(core.FileTypeWorkspace)
+	if workspaceGlobals["cc_library"] != nil || workspaceGlobals["native"] != nil {
+		t.Errorf("cc_library or native unexpectedly in WORKSPACE globals")
+	}
+
+	myRule := starlark.NewBuiltin("my_rule", nil)
+	myBzlMember := starlark.NewBuiltin("my_member", nil)
+	myWorkspaceRule := starlark.NewBuiltin("my_workspace_rule", nil)
+	registry.RegisterRule("my_rule", myRule)
+	registry.RegisterRule("cc_library", myRule)
+	registry.RegisterNativeMember(core.FileTypeBzl, "my_member", myBzlMember)
+	registry.RegisterFunction(core.FileTypeBuild, "answer", starlark.MakeInt(42))
+	registry.RegisterWorkspaceRule("my_workspace_rule", myWorkspaceRule)
+
+	buildGlobals = registry.InitialGlobals(core.FileTypeBuild)
+	for _, name := range []string{"my_rule", "cc_library"} {
+		if buildGlobals[name] != myRule || nativeMember(buildGlobals, name) != myRule {
+			t.Errorf("%v not registered in BUILD globals and native", name)
+		}
+	}
+	if buildGlobals["answer"] == nil {
+		t.Errorf("answer not registered in BUILD globals")
+	}
+	if nativeMember(buildGlobals, "my_member") != nil {
+		t.Errorf("my_member unexpectedly in native in BUILD globals")
+	}
+	bzlGlobals := registry.InitialGlobals(core.FileTypeBzl)
+	if nativeMember(bzlGlobals, "my_member") != myBzlMember ||
+		nativeMember(bzlGlobals, "my_rule") != myRule {
+		t.Errorf("my_member or my_rule not registered in native in .bzl globals")
+	}
+	if bzlGlobals["my_rule"] != nil || bzlGlobals["answer"] != nil {
+		t.Errorf("my_rule or answer unexpectedly in .bzl globals")
+	}
+	if registry.InitialGlobals(core.FileTypeWorkspace)["my_workspace_rule"] != myWorkspaceRule {
+		t.Errorf("my_workspace_rule not registered in WORKSPACE globals")
+	}
+
+	// The default registry is unaffected.
+	if InitialGlobals(core.FileTypeBuild)["my_rule"] != nil {
+		t.Errorf("my_rule unexpectedly in default BUILD globals")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// checkOneOf checks that the value of a string argument (if set) is one of the allowed values.
+func checkOneOf(argName string, value *string, allowed ...string) error {
+	if value == nil {
+		return nil
+	}
+	for _, a := range allowed {
+		if *value == a {
+			return nil
+		}
+	}
+	quoted := make([]string, len(allowed))
+	for i, a := range allowed {
+		quoted[i] = fmt.Sprintf("%q", a)
+	}
+	return fmt.Errorf("argument %v invalid: has to be one of %v instead of %q", argName,
+		strings.Join(quoted, ", "), *value)
+}
+
+// checkStamp checks that the value of a (cc_binary-style) stamp argument (if set) is -1, 0, or 1.
+func checkStamp(value *int64) error {
+	if value != nil && (*value < -1 || *value > 1) {
+		return fmt.Errorf("argument stamp invalid: has to be -1, 0, or 1 instead of %v",
+			*value)
+	}
+	return nil
+}
+
+// checkIncludes checks that the paths in an includes argument (if set) are relative paths (to the
+// current package) that don't point outside the workspace.
+func checkIncludes(argName string, includes *[]string, ctx core.Context) error {
+	if includes == nil {
+		return nil
+	}
+	for _, include := range *includes {
+		p := path.Clean(path.Join(string(ctx.Label().Package), include))
+		if path.IsAbs(include) || p == ".." || strings.HasPrefix(p, "../") {
+			return fmt.Errorf("argument %v invalid: invalid element: path %q points "+
+				"outside the workspace", argName, include)
+		}
+	}
+	return nil
+}
+
+// checkIncludePrefix checks that the value of an include_prefix argument (if set) is a relative
+// path without uplevel references.
+func checkIncludePrefix(value *string) error {
+	if value == nil {
+		return nil
+	}
+	if path.IsAbs(*value) {
+		return fmt.Errorf("argument include_prefix invalid: should be a relative path")
+	}
+	for _, segment := range strings.Split(*value, "/") {
+		if segment == ".." {
+			return fmt.Errorf("argument include_prefix invalid: should not contain " +
+				"uplevel references")
+		}
+	}
+	return nil
+}
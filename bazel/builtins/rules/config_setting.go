@@ -0,0 +1,21 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"fmt"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func (self *ConfigSettingTarget) DidProcessArgs(ctx core.Context) error {
+	if (self.Values == nil || len(*self.Values) == 0) &&
+		(self.DefineValues == nil || len(*self.DefineValues) == 0) &&
+		(self.FlagValues == nil || len(*self.FlagValues) == 0) &&
+		(self.ConstraintValues == nil || len(*self.ConstraintValues) == 0) {
+		return fmt.Errorf("either values, flag_values, define_values, or " +
+			"constraint_values must be specified and non-empty")
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func (self *ConstraintValueTarget) DidProcessArgs(ctx core.Context) error {
+	return nil
+}
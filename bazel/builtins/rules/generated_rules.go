@@ -0,0 +1,3045 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Code generated by genrules from the schemas in builtins/rules/schema. DO NOT EDIT.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"go.starlark.net/starlark"
+
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// TargetCommon contains the attributes common to all rules.
+type TargetCommon struct {
+	targetCommonState
+
+	// Name is the value of name, or nil if unset: the target's name.
+	Name *string `bazel:"name!,nonconfigurable"`
+
+	// Data is the value of data, or nil if unset: the files needed at runtime.
+	Data *[]core.Label `bazel:"data"`
+
+	// Visibility is the value of visibility, or nil if unset: the visibility of the target.
+	Visibility *[]core.Label `bazel:"visibility,nonconfigurable"`
+
+	// Toolchains is the value of toolchains, or nil if unset: the targets whose Make variables
+	// the target may access.
+	Toolchains *[]core.Label `bazel:"toolchains"`
+
+	// Deps is the value of deps, or nil if unset: the dependencies of the target.
+	Deps *[]core.Label `bazel:"deps"`
+
+	// Deprecation is the value of deprecation, or nil if unset: the deprecation warning for the
+	// target.
+	Deprecation *string `bazel:"deprecation"`
+
+	// Tags is the value of tags, or nil if unset: the target's tags.
+	Tags *[]string `bazel:"tags,nonconfigurable"`
+
+	// Testonly is the value of testonly, or nil if unset: whether only test targets may depend
+	// on the target.
+	Testonly *bool `bazel:"testonly,nonconfigurable"`
+
+	// Features is the value of features, or nil if unset: the features enabled (or, if prefixed
+	// by "-", disabled).
+	Features *[]string `bazel:"features"`
+
+	// Licenses is the value of licenses, or nil if unset: the license types of the target.
+	Licenses *[]string `bazel:"licenses,nonconfigurable,license"`
+
+	// CompatibleWith is the value of compatible_with, or nil if unset: the environments the
+	// target can be built for.
+	CompatibleWith *[]core.Label `bazel:"compatible_with,nonconfigurable"`
+
+	// Distribs is the value of distribs, or nil if unset: the distribution methods of the
+	// target.
+	Distribs *[]string `bazel:"distribs,nonconfigurable"`
+
+	// ExecCompatibleWith is the value of exec_compatible_with, or nil if unset: the constraints
+	// required of the execution platform.
+	ExecCompatibleWith *[]core.Label `bazel:"exec_compatible_with,nonconfigurable"`
+
+	// RestrictedTo is the value of restricted_to, or nil if unset: the only environments the
+	// target can be built for.
+	RestrictedTo *[]core.Label `bazel:"restricted_to,nonconfigurable"`
+
+	// ApplicableLicenses is the value of applicable_licenses, or nil if unset: the licenses
+	// that apply to the target.
+	ApplicableLicenses *[]core.Label `bazel:"applicable_licenses,nonconfigurable"`
+
+	// ExecProperties is the value of exec_properties, or nil if unset: the execution properties
+	// (added to the platform's).
+	ExecProperties *map[string]string `bazel:"exec_properties"`
+}
+
+var _ builtins_args.ProcessArgsTarget = (*TargetCommon)(nil)
+
+// bindGroupArgs binds the group's arguments (see builtins_args.BindArg) and then calls
+// DidProcessArgs.
+func (self *TargetCommon) bindGroupArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := builtins_args.BindArg(kwargs, "name!,nonconfigurable", ctx,
+		configurableTarget, &self.Name); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "data", ctx,
+		configurableTarget, &self.Data); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "visibility,nonconfigurable", ctx,
+		configurableTarget, &self.Visibility); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "toolchains", ctx,
+		configurableTarget, &self.Toolchains); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "deps", ctx,
+		configurableTarget, &self.Deps); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "deprecation", ctx,
+		configurableTarget, &self.Deprecation); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "tags,nonconfigurable", ctx,
+		configurableTarget, &self.Tags); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "testonly,nonconfigurable", ctx,
+		configurableTarget, &self.Testonly); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "features", ctx,
+		configurableTarget, &self.Features); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "licenses,nonconfigurable,license", ctx,
+		configurableTarget, &self.Licenses); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "compatible_with,nonconfigurable", ctx,
+		configurableTarget, &self.CompatibleWith); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "distribs,nonconfigurable", ctx,
+		configurableTarget, &self.Distribs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "exec_compatible_with,nonconfigurable", ctx,
+		configurableTarget, &self.ExecCompatibleWith); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "restricted_to,nonconfigurable", ctx,
+		configurableTarget, &self.RestrictedTo); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "applicable_licenses,nonconfigurable", ctx,
+		configurableTarget, &self.ApplicableLicenses); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "exec_properties", ctx,
+		configurableTarget, &self.ExecProperties); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// setGroupResolvedArg sets the field for the given argument to its resolved value if it is one of
+// the group's configurable arguments, returning the field's tag (see SetResolvedArg).
+func (self *TargetCommon) setGroupResolvedArg(argName string, value interface{}) string {
+	switch argName {
+	case "data":
+		if value == nil {
+			self.Data = nil
+		} else {
+			v := value.([]core.Label)
+			self.Data = &v
+		}
+		return "data"
+	case "toolchains":
+		if value == nil {
+			self.Toolchains = nil
+		} else {
+			v := value.([]core.Label)
+			self.Toolchains = &v
+		}
+		return "toolchains"
+	case "deps":
+		if value == nil {
+			self.Deps = nil
+		} else {
+			v := value.([]core.Label)
+			self.Deps = &v
+		}
+		return "deps"
+	case "deprecation":
+		if value == nil {
+			self.Deprecation = nil
+		} else {
+			v := value.(string)
+			self.Deprecation = &v
+		}
+		return "deprecation"
+	case "features":
+		if value == nil {
+			self.Features = nil
+		} else {
+			v := value.([]string)
+			self.Features = &v
+		}
+		return "features"
+	case "exec_properties":
+		if value == nil {
+			self.ExecProperties = nil
+		} else {
+			v := value.(map[string]string)
+			self.ExecProperties = &v
+		}
+		return "exec_properties"
+	}
+	return ""
+}
+
+// forEachGroupAttr calls fn for each of the group's attributes that is set (see forEachAttr).
+func (self *TargetCommon) forEachGroupAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	if self.Name != nil {
+		fn("name", *self.Name, nil)
+	} else {
+		visitConfigurable(configurableTarget, "name", fn)
+	}
+	if self.Data != nil {
+		fn("data", *self.Data, nil)
+	} else {
+		visitConfigurable(configurableTarget, "data", fn)
+	}
+	if self.Visibility != nil {
+		fn("visibility", *self.Visibility, nil)
+	} else {
+		visitConfigurable(configurableTarget, "visibility", fn)
+	}
+	if self.Toolchains != nil {
+		fn("toolchains", *self.Toolchains, nil)
+	} else {
+		visitConfigurable(configurableTarget, "toolchains", fn)
+	}
+	if self.Deps != nil {
+		fn("deps", *self.Deps, nil)
+	} else {
+		visitConfigurable(configurableTarget, "deps", fn)
+	}
+	if self.Deprecation != nil {
+		fn("deprecation", *self.Deprecation, nil)
+	} else {
+		visitConfigurable(configurableTarget, "deprecation", fn)
+	}
+	if self.Tags != nil {
+		fn("tags", *self.Tags, nil)
+	} else {
+		visitConfigurable(configurableTarget, "tags", fn)
+	}
+	if self.Testonly != nil {
+		fn("testonly", *self.Testonly, nil)
+	} else {
+		visitConfigurable(configurableTarget, "testonly", fn)
+	}
+	if self.Features != nil {
+		fn("features", *self.Features, nil)
+	} else {
+		visitConfigurable(configurableTarget, "features", fn)
+	}
+	if self.Licenses != nil {
+		fn("licenses", *self.Licenses, nil)
+	} else {
+		visitConfigurable(configurableTarget, "licenses", fn)
+	}
+	if self.CompatibleWith != nil {
+		fn("compatible_with", *self.CompatibleWith, nil)
+	} else {
+		visitConfigurable(configurableTarget, "compatible_with", fn)
+	}
+	if self.Distribs != nil {
+		fn("distribs", *self.Distribs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "distribs", fn)
+	}
+	if self.ExecCompatibleWith != nil {
+		fn("exec_compatible_with", *self.ExecCompatibleWith, nil)
+	} else {
+		visitConfigurable(configurableTarget, "exec_compatible_with", fn)
+	}
+	if self.RestrictedTo != nil {
+		fn("restricted_to", *self.RestrictedTo, nil)
+	} else {
+		visitConfigurable(configurableTarget, "restricted_to", fn)
+	}
+	if self.ApplicableLicenses != nil {
+		fn("applicable_licenses", *self.ApplicableLicenses, nil)
+	} else {
+		visitConfigurable(configurableTarget, "applicable_licenses", fn)
+	}
+	if self.ExecProperties != nil {
+		fn("exec_properties", *self.ExecProperties, nil)
+	} else {
+		visitConfigurable(configurableTarget, "exec_properties", fn)
+	}
+}
+
+// GetName returns the value of name, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommon) GetName() string {
+	if self.Name != nil {
+		return *self.Name
+	}
+	return ""
+}
+
+// GetData returns the value of data, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommon) GetData() []core.Label {
+	if self.Data != nil {
+		return *self.Data
+	}
+	return nil
+}
+
+// GetVisibility returns the value of visibility, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *TargetCommon) GetVisibility() []core.Label {
+	if self.Visibility != nil {
+		return *self.Visibility
+	}
+	return nil
+}
+
+// GetToolchains returns the value of toolchains, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *TargetCommon) GetToolchains() []core.Label {
+	if self.Toolchains != nil {
+		return *self.Toolchains
+	}
+	return nil
+}
+
+// GetDeps returns the value of deps, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommon) GetDeps() []core.Label {
+	if self.Deps != nil {
+		return *self.Deps
+	}
+	return nil
+}
+
+// GetDeprecation returns the value of deprecation, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *TargetCommon) GetDeprecation() string {
+	if self.Deprecation != nil {
+		return *self.Deprecation
+	}
+	return ""
+}
+
+// GetTags returns the value of tags, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommon) GetTags() []string {
+	if self.Tags != nil {
+		return *self.Tags
+	}
+	return nil
+}
+
+// GetTestonly returns the value of testonly, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *TargetCommon) GetTestonly() bool {
+	if self.Testonly != nil {
+		return *self.Testonly
+	}
+	return false
+}
+
+// GetFeatures returns the value of features, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *TargetCommon) GetFeatures() []string {
+	if self.Features != nil {
+		return *self.Features
+	}
+	return nil
+}
+
+// GetLicenses returns the value of licenses, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *TargetCommon) GetLicenses() []string {
+	if self.Licenses != nil {
+		return *self.Licenses
+	}
+	return nil
+}
+
+// GetCompatibleWith returns the value of compatible_with, or its default if it is unset (or given
+// using select() and unresolved).
+func (self *TargetCommon) GetCompatibleWith() []core.Label {
+	if self.CompatibleWith != nil {
+		return *self.CompatibleWith
+	}
+	return nil
+}
+
+// GetDistribs returns the value of distribs, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *TargetCommon) GetDistribs() []string {
+	if self.Distribs != nil {
+		return *self.Distribs
+	}
+	return nil
+}
+
+// GetExecCompatibleWith returns the value of exec_compatible_with, or its default if it is unset
+// (or given using select() and unresolved).
+func (self *TargetCommon) GetExecCompatibleWith() []core.Label {
+	if self.ExecCompatibleWith != nil {
+		return *self.ExecCompatibleWith
+	}
+	return nil
+}
+
+// GetRestrictedTo returns the value of restricted_to, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *TargetCommon) GetRestrictedTo() []core.Label {
+	if self.RestrictedTo != nil {
+		return *self.RestrictedTo
+	}
+	return nil
+}
+
+// GetApplicableLicenses returns the value of applicable_licenses, or its default if it is unset (or
+// given using select() and unresolved).
+func (self *TargetCommon) GetApplicableLicenses() []core.Label {
+	if self.ApplicableLicenses != nil {
+		return *self.ApplicableLicenses
+	}
+	return nil
+}
+
+// GetExecProperties returns the value of exec_properties, or its default if it is unset (or given
+// using select() and unresolved).
+func (self *TargetCommon) GetExecProperties() map[string]string {
+	if self.ExecProperties != nil {
+		return *self.ExecProperties
+	}
+	return nil
+}
+
+// TargetCommonTest contains the attributes common to all test rules.
+type TargetCommonTest struct {
+	// Args is the value of args, or nil if unset: the command line arguments for the test.
+	Args *[]string `bazel:"args"`
+
+	// Size is the value of size, or nil if unset: the test's size (small, medium, large, or
+	// enormous).
+	Size *string `bazel:"size"`
+
+	// Timeout is the value of timeout, or nil if unset: the test's timeout (short, moderate,
+	// long, or eternal).
+	Timeout *string `bazel:"timeout"`
+
+	// Flaky is the value of flaky, or nil if unset: whether the test is flaky.
+	Flaky *bool `bazel:"flaky"`
+
+	// Local is the value of local, or nil if unset: whether the test must be run locally
+	// (unsandboxed).
+	Local *bool `bazel:"local"`
+
+	// ShardCount is the value of shard_count, or nil if unset: the number of shards to use to
+	// run the test.
+	ShardCount *int64 `bazel:"shard_count"`
+}
+
+var _ builtins_args.ProcessArgsTarget = (*TargetCommonTest)(nil)
+
+// bindGroupArgs binds the group's arguments (see builtins_args.BindArg) and then calls
+// DidProcessArgs.
+func (self *TargetCommonTest) bindGroupArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := builtins_args.BindArg(kwargs, "args", ctx,
+		configurableTarget, &self.Args); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "size", ctx,
+		configurableTarget, &self.Size); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "timeout", ctx,
+		configurableTarget, &self.Timeout); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "flaky", ctx,
+		configurableTarget, &self.Flaky); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "local", ctx,
+		configurableTarget, &self.Local); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "shard_count", ctx,
+		configurableTarget, &self.ShardCount); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// setGroupResolvedArg sets the field for the given argument to its resolved value if it is one of
+// the group's configurable arguments, returning the field's tag (see SetResolvedArg).
+func (self *TargetCommonTest) setGroupResolvedArg(argName string, value interface{}) string {
+	switch argName {
+	case "args":
+		if value == nil {
+			self.Args = nil
+		} else {
+			v := value.([]string)
+			self.Args = &v
+		}
+		return "args"
+	case "size":
+		if value == nil {
+			self.Size = nil
+		} else {
+			v := value.(string)
+			self.Size = &v
+		}
+		return "size"
+	case "timeout":
+		if value == nil {
+			self.Timeout = nil
+		} else {
+			v := value.(string)
+			self.Timeout = &v
+		}
+		return "timeout"
+	case "flaky":
+		if value == nil {
+			self.Flaky = nil
+		} else {
+			v := value.(bool)
+			self.Flaky = &v
+		}
+		return "flaky"
+	case "local":
+		if value == nil {
+			self.Local = nil
+		} else {
+			v := value.(bool)
+			self.Local = &v
+		}
+		return "local"
+	case "shard_count":
+		if value == nil {
+			self.ShardCount = nil
+		} else {
+			v := value.(int64)
+			self.ShardCount = &v
+		}
+		return "shard_count"
+	}
+	return ""
+}
+
+// forEachGroupAttr calls fn for each of the group's attributes that is set (see forEachAttr).
+func (self *TargetCommonTest) forEachGroupAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	if self.Args != nil {
+		fn("args", *self.Args, nil)
+	} else {
+		visitConfigurable(configurableTarget, "args", fn)
+	}
+	if self.Size != nil {
+		fn("size", *self.Size, nil)
+	} else {
+		visitConfigurable(configurableTarget, "size", fn)
+	}
+	if self.Timeout != nil {
+		fn("timeout", *self.Timeout, nil)
+	} else {
+		visitConfigurable(configurableTarget, "timeout", fn)
+	}
+	if self.Flaky != nil {
+		fn("flaky", *self.Flaky, nil)
+	} else {
+		visitConfigurable(configurableTarget, "flaky", fn)
+	}
+	if self.Local != nil {
+		fn("local", *self.Local, nil)
+	} else {
+		visitConfigurable(configurableTarget, "local", fn)
+	}
+	if self.ShardCount != nil {
+		fn("shard_count", *self.ShardCount, nil)
+	} else {
+		visitConfigurable(configurableTarget, "shard_count", fn)
+	}
+}
+
+// GetArgs returns the value of args, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommonTest) GetArgs() []string {
+	if self.Args != nil {
+		return *self.Args
+	}
+	return nil
+}
+
+// GetSize returns the value of size, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommonTest) GetSize() string {
+	if self.Size != nil {
+		return *self.Size
+	}
+	return "medium"
+}
+
+// GetTimeout returns the value of timeout, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *TargetCommonTest) GetTimeout() string {
+	if self.Timeout != nil {
+		return *self.Timeout
+	}
+	return ""
+}
+
+// GetFlaky returns the value of flaky, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommonTest) GetFlaky() bool {
+	if self.Flaky != nil {
+		return *self.Flaky
+	}
+	return false
+}
+
+// GetLocal returns the value of local, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommonTest) GetLocal() bool {
+	if self.Local != nil {
+		return *self.Local
+	}
+	return false
+}
+
+// GetShardCount returns the value of shard_count, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *TargetCommonTest) GetShardCount() int64 {
+	if self.ShardCount != nil {
+		return *self.ShardCount
+	}
+	return 0
+}
+
+// TargetCommonBinary contains the attributes common to all binary rules.
+type TargetCommonBinary struct {
+	// Args is the value of args, or nil if unset: the command line arguments for the binary
+	// (when run).
+	Args *[]string `bazel:"args"`
+
+	// OutputLicenses is the value of output_licenses, or nil if unset: the licenses of the
+	// binary's outputs.
+	OutputLicenses *[]string `bazel:"output_licenses,license"`
+}
+
+var _ builtins_args.ProcessArgsTarget = (*TargetCommonBinary)(nil)
+
+// bindGroupArgs binds the group's arguments (see builtins_args.BindArg) and then calls
+// DidProcessArgs.
+func (self *TargetCommonBinary) bindGroupArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := builtins_args.BindArg(kwargs, "args", ctx,
+		configurableTarget, &self.Args); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "output_licenses,license", ctx,
+		configurableTarget, &self.OutputLicenses); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// setGroupResolvedArg sets the field for the given argument to its resolved value if it is one of
+// the group's configurable arguments, returning the field's tag (see SetResolvedArg).
+func (self *TargetCommonBinary) setGroupResolvedArg(argName string, value interface{}) string {
+	switch argName {
+	case "args":
+		if value == nil {
+			self.Args = nil
+		} else {
+			v := value.([]string)
+			self.Args = &v
+		}
+		return "args"
+	case "output_licenses":
+		if value == nil {
+			self.OutputLicenses = nil
+		} else {
+			v := value.([]string)
+			self.OutputLicenses = &v
+		}
+		return "output_licenses,license"
+	}
+	return ""
+}
+
+// forEachGroupAttr calls fn for each of the group's attributes that is set (see forEachAttr).
+func (self *TargetCommonBinary) forEachGroupAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	if self.Args != nil {
+		fn("args", *self.Args, nil)
+	} else {
+		visitConfigurable(configurableTarget, "args", fn)
+	}
+	if self.OutputLicenses != nil {
+		fn("output_licenses", *self.OutputLicenses, nil)
+	} else {
+		visitConfigurable(configurableTarget, "output_licenses", fn)
+	}
+}
+
+// GetArgs returns the value of args, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *TargetCommonBinary) GetArgs() []string {
+	if self.Args != nil {
+		return *self.Args
+	}
+	return nil
+}
+
+// GetOutputLicenses returns the value of output_licenses, or its default if it is unset (or given
+// using select() and unresolved).
+func (self *TargetCommonBinary) GetOutputLicenses() []string {
+	if self.OutputLicenses != nil {
+		return *self.OutputLicenses
+	}
+	return nil
+}
+
+// CcBinaryTarget is a target of the cc_binary rule (a C++ binary).
+type CcBinaryTarget struct {
+	TargetCommon
+	TargetCommonBinary
+
+	// Srcs is the value of srcs, or nil if unset: the source (and private header) files.
+	Srcs *[]core.Label `bazel:"srcs"`
+
+	// AdditionalLinkerInputs is the value of additional_linker_inputs, or nil if unset: the
+	// additional files to pass to the linker.
+	AdditionalLinkerInputs *[]core.Label `bazel:"additional_linker_inputs"`
+
+	// Copts is the value of copts, or nil if unset: the C++ compiler options.
+	Copts *[]string `bazel:"copts"`
+
+	// Defines is the value of defines, or nil if unset: the defines (also for dependents).
+	Defines *[]string `bazel:"defines"`
+
+	// Includes is the value of includes, or nil if unset: the include directories (relative to
+	// the package).
+	Includes *[]string `bazel:"includes"`
+
+	// Linkopts is the value of linkopts, or nil if unset: the linker options.
+	Linkopts *[]string `bazel:"linkopts"`
+
+	// Linkshared is the value of linkshared, or nil if unset: whether to create a shared
+	// library.
+	Linkshared *bool `bazel:"linkshared"`
+
+	// Linkstatic is the value of linkstatic, or nil if unset: whether to link statically.
+	Linkstatic *bool `bazel:"linkstatic"`
+
+	// Malloc is the value of malloc, or nil if unset: the malloc implementation to use.
+	Malloc *core.Label `bazel:"malloc"`
+
+	// Nocopts is the value of nocopts, or nil if unset: the (regular expressions for) C++
+	// compiler options to remove.
+	Nocopts *[]string `bazel:"nocopts"`
+
+	// Stamp is the value of stamp, or nil if unset: whether to stamp build information into the
+	// binary (1, 0, or -1 to only do so if --stamp is given).
+	Stamp *int64 `bazel:"stamp"`
+
+	// WinDefFile is the value of win_def_file, or nil if unset: the Windows DEF file to pass to
+	// the linker.
+	WinDefFile *core.Label `bazel:"win_def_file"`
+}
+
+var _ builtins_args.ArgsBinder = (*CcBinaryTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*CcBinaryTarget)(nil)
+var _ core.Target = (*CcBinaryTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *CcBinaryTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := self.TargetCommonBinary.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "srcs", ctx,
+		configurableTarget, &self.Srcs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "additional_linker_inputs", ctx,
+		configurableTarget, &self.AdditionalLinkerInputs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "copts", ctx,
+		configurableTarget, &self.Copts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "defines", ctx,
+		configurableTarget, &self.Defines); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "includes", ctx,
+		configurableTarget, &self.Includes); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkopts", ctx,
+		configurableTarget, &self.Linkopts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkshared", ctx,
+		configurableTarget, &self.Linkshared); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkstatic", ctx,
+		configurableTarget, &self.Linkstatic); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "malloc", ctx,
+		configurableTarget, &self.Malloc); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "nocopts", ctx,
+		configurableTarget, &self.Nocopts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "stamp", ctx,
+		configurableTarget, &self.Stamp); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "win_def_file", ctx,
+		configurableTarget, &self.WinDefFile); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *CcBinaryTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	if tag := self.TargetCommonBinary.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	switch argName {
+	case "srcs":
+		if value == nil {
+			self.Srcs = nil
+		} else {
+			v := value.([]core.Label)
+			self.Srcs = &v
+		}
+		return "srcs"
+	case "additional_linker_inputs":
+		if value == nil {
+			self.AdditionalLinkerInputs = nil
+		} else {
+			v := value.([]core.Label)
+			self.AdditionalLinkerInputs = &v
+		}
+		return "additional_linker_inputs"
+	case "copts":
+		if value == nil {
+			self.Copts = nil
+		} else {
+			v := value.([]string)
+			self.Copts = &v
+		}
+		return "copts"
+	case "defines":
+		if value == nil {
+			self.Defines = nil
+		} else {
+			v := value.([]string)
+			self.Defines = &v
+		}
+		return "defines"
+	case "includes":
+		if value == nil {
+			self.Includes = nil
+		} else {
+			v := value.([]string)
+			self.Includes = &v
+		}
+		return "includes"
+	case "linkopts":
+		if value == nil {
+			self.Linkopts = nil
+		} else {
+			v := value.([]string)
+			self.Linkopts = &v
+		}
+		return "linkopts"
+	case "linkshared":
+		if value == nil {
+			self.Linkshared = nil
+		} else {
+			v := value.(bool)
+			self.Linkshared = &v
+		}
+		return "linkshared"
+	case "linkstatic":
+		if value == nil {
+			self.Linkstatic = nil
+		} else {
+			v := value.(bool)
+			self.Linkstatic = &v
+		}
+		return "linkstatic"
+	case "malloc":
+		if value == nil {
+			self.Malloc = nil
+		} else {
+			v := value.(core.Label)
+			self.Malloc = &v
+		}
+		return "malloc"
+	case "nocopts":
+		if value == nil {
+			self.Nocopts = nil
+		} else {
+			v := value.([]string)
+			self.Nocopts = &v
+		}
+		return "nocopts"
+	case "stamp":
+		if value == nil {
+			self.Stamp = nil
+		} else {
+			v := value.(int64)
+			self.Stamp = &v
+		}
+		return "stamp"
+	case "win_def_file":
+		if value == nil {
+			self.WinDefFile = nil
+		} else {
+			v := value.(core.Label)
+			self.WinDefFile = &v
+		}
+		return "win_def_file"
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *CcBinaryTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	if err := self.TargetCommonBinary.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *CcBinaryTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	self.TargetCommonBinary.forEachGroupAttr(configurableTarget, fn)
+	if self.Srcs != nil {
+		fn("srcs", *self.Srcs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "srcs", fn)
+	}
+	if self.AdditionalLinkerInputs != nil {
+		fn("additional_linker_inputs", *self.AdditionalLinkerInputs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "additional_linker_inputs", fn)
+	}
+	if self.Copts != nil {
+		fn("copts", *self.Copts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "copts", fn)
+	}
+	if self.Defines != nil {
+		fn("defines", *self.Defines, nil)
+	} else {
+		visitConfigurable(configurableTarget, "defines", fn)
+	}
+	if self.Includes != nil {
+		fn("includes", *self.Includes, nil)
+	} else {
+		visitConfigurable(configurableTarget, "includes", fn)
+	}
+	if self.Linkopts != nil {
+		fn("linkopts", *self.Linkopts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkopts", fn)
+	}
+	if self.Linkshared != nil {
+		fn("linkshared", *self.Linkshared, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkshared", fn)
+	}
+	if self.Linkstatic != nil {
+		fn("linkstatic", *self.Linkstatic, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkstatic", fn)
+	}
+	if self.Malloc != nil {
+		fn("malloc", *self.Malloc, nil)
+	} else {
+		visitConfigurable(configurableTarget, "malloc", fn)
+	}
+	if self.Nocopts != nil {
+		fn("nocopts", *self.Nocopts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "nocopts", fn)
+	}
+	if self.Stamp != nil {
+		fn("stamp", *self.Stamp, nil)
+	} else {
+		visitConfigurable(configurableTarget, "stamp", fn)
+	}
+	if self.WinDefFile != nil {
+		fn("win_def_file", *self.WinDefFile, nil)
+	} else {
+		visitConfigurable(configurableTarget, "win_def_file", fn)
+	}
+}
+
+// GetSrcs returns the value of srcs, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcBinaryTarget) GetSrcs() []core.Label {
+	if self.Srcs != nil {
+		return *self.Srcs
+	}
+	return nil
+}
+
+// GetAdditionalLinkerInputs returns the value of additional_linker_inputs, or its default if it is
+// unset (or given using select() and unresolved).
+func (self *CcBinaryTarget) GetAdditionalLinkerInputs() []core.Label {
+	if self.AdditionalLinkerInputs != nil {
+		return *self.AdditionalLinkerInputs
+	}
+	return nil
+}
+
+// GetCopts returns the value of copts, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcBinaryTarget) GetCopts() []string {
+	if self.Copts != nil {
+		return *self.Copts
+	}
+	return nil
+}
+
+// GetDefines returns the value of defines, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcBinaryTarget) GetDefines() []string {
+	if self.Defines != nil {
+		return *self.Defines
+	}
+	return nil
+}
+
+// GetIncludes returns the value of includes, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcBinaryTarget) GetIncludes() []string {
+	if self.Includes != nil {
+		return *self.Includes
+	}
+	return nil
+}
+
+// GetLinkopts returns the value of linkopts, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcBinaryTarget) GetLinkopts() []string {
+	if self.Linkopts != nil {
+		return *self.Linkopts
+	}
+	return nil
+}
+
+// GetLinkshared returns the value of linkshared, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcBinaryTarget) GetLinkshared() bool {
+	if self.Linkshared != nil {
+		return *self.Linkshared
+	}
+	return false
+}
+
+// GetLinkstatic returns the value of linkstatic, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcBinaryTarget) GetLinkstatic() bool {
+	if self.Linkstatic != nil {
+		return *self.Linkstatic
+	}
+	return true
+}
+
+// GetMalloc returns the value of malloc, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcBinaryTarget) GetMalloc() core.Label {
+	if self.Malloc != nil {
+		return *self.Malloc
+	}
+	return core.Label{}
+}
+
+// GetNocopts returns the value of nocopts, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcBinaryTarget) GetNocopts() []string {
+	if self.Nocopts != nil {
+		return *self.Nocopts
+	}
+	return nil
+}
+
+// GetStamp returns the value of stamp, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcBinaryTarget) GetStamp() int64 {
+	if self.Stamp != nil {
+		return *self.Stamp
+	}
+	return -1
+}
+
+// GetWinDefFile returns the value of win_def_file, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcBinaryTarget) GetWinDefFile() core.Label {
+	if self.WinDefFile != nil {
+		return *self.WinDefFile
+	}
+	return core.Label{}
+}
+
+func (self *CcBinaryTarget) Kind() string {
+	return "cc_binary"
+}
+
+func (self *CcBinaryTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *CcBinaryTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// CcBinary implements the Bazel cc_binary rule.
+var CcBinary = NewRule("cc_binary",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &CcBinaryTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// CcLibraryTarget is a target of the cc_library rule (a C++ library).
+type CcLibraryTarget struct {
+	TargetCommon
+
+	// Srcs is the value of srcs, or nil if unset: the source (and private header) files.
+	Srcs *[]core.Label `bazel:"srcs"`
+
+	// Hdrs is the value of hdrs, or nil if unset: the public header files.
+	Hdrs *[]core.Label `bazel:"hdrs"`
+
+	// Alwayslink is the value of alwayslink, or nil if unset: whether to always link all the
+	// library's code.
+	Alwayslink *bool `bazel:"alwayslink"`
+
+	// Copts is the value of copts, or nil if unset: the C++ compiler options.
+	Copts *[]string `bazel:"copts"`
+
+	// Defines is the value of defines, or nil if unset: the defines (also for dependents).
+	Defines *[]string `bazel:"defines"`
+
+	// IncludePrefix is the value of include_prefix, or nil if unset: the prefix to add to the
+	// paths of the headers.
+	IncludePrefix *string `bazel:"include_prefix"`
+
+	// Includes is the value of includes, or nil if unset: the include directories (relative to
+	// the package).
+	Includes *[]string `bazel:"includes"`
+
+	// Linkopts is the value of linkopts, or nil if unset: the linker options (also for
+	// dependents).
+	Linkopts *[]string `bazel:"linkopts"`
+
+	// Linkstatic is the value of linkstatic, or nil if unset: whether to only build the static
+	// library.
+	Linkstatic *bool `bazel:"linkstatic"`
+
+	// Nocopts is the value of nocopts, or nil if unset: the (regexps for) C++ compiler options
+	// to remove.
+	Nocopts *[]string `bazel:"nocopts"`
+
+	// StripIncludePrefix is the value of strip_include_prefix, or nil if unset: the prefix to
+	// strip from the paths of the headers.
+	StripIncludePrefix *string `bazel:"strip_include_prefix"`
+
+	// TextualHdrs is the value of textual_hdrs, or nil if unset: the header files that can't be
+	// compiled on their own.
+	TextualHdrs *[]core.Label `bazel:"textual_hdrs"`
+
+	// WinDefFile is the value of win_def_file, or nil if unset: the Windows DEF file to pass to
+	// the linker.
+	WinDefFile *core.Label `bazel:"win_def_file"`
+}
+
+var _ builtins_args.ArgsBinder = (*CcLibraryTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*CcLibraryTarget)(nil)
+var _ core.Target = (*CcLibraryTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *CcLibraryTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "srcs", ctx,
+		configurableTarget, &self.Srcs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "hdrs", ctx,
+		configurableTarget, &self.Hdrs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "alwayslink", ctx,
+		configurableTarget, &self.Alwayslink); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "copts", ctx,
+		configurableTarget, &self.Copts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "defines", ctx,
+		configurableTarget, &self.Defines); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "include_prefix", ctx,
+		configurableTarget, &self.IncludePrefix); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "includes", ctx,
+		configurableTarget, &self.Includes); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkopts", ctx,
+		configurableTarget, &self.Linkopts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkstatic", ctx,
+		configurableTarget, &self.Linkstatic); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "nocopts", ctx,
+		configurableTarget, &self.Nocopts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "strip_include_prefix", ctx,
+		configurableTarget, &self.StripIncludePrefix); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "textual_hdrs", ctx,
+		configurableTarget, &self.TextualHdrs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "win_def_file", ctx,
+		configurableTarget, &self.WinDefFile); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *CcLibraryTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	switch argName {
+	case "srcs":
+		if value == nil {
+			self.Srcs = nil
+		} else {
+			v := value.([]core.Label)
+			self.Srcs = &v
+		}
+		return "srcs"
+	case "hdrs":
+		if value == nil {
+			self.Hdrs = nil
+		} else {
+			v := value.([]core.Label)
+			self.Hdrs = &v
+		}
+		return "hdrs"
+	case "alwayslink":
+		if value == nil {
+			self.Alwayslink = nil
+		} else {
+			v := value.(bool)
+			self.Alwayslink = &v
+		}
+		return "alwayslink"
+	case "copts":
+		if value == nil {
+			self.Copts = nil
+		} else {
+			v := value.([]string)
+			self.Copts = &v
+		}
+		return "copts"
+	case "defines":
+		if value == nil {
+			self.Defines = nil
+		} else {
+			v := value.([]string)
+			self.Defines = &v
+		}
+		return "defines"
+	case "include_prefix":
+		if value == nil {
+			self.IncludePrefix = nil
+		} else {
+			v := value.(string)
+			self.IncludePrefix = &v
+		}
+		return "include_prefix"
+	case "includes":
+		if value == nil {
+			self.Includes = nil
+		} else {
+			v := value.([]string)
+			self.Includes = &v
+		}
+		return "includes"
+	case "linkopts":
+		if value == nil {
+			self.Linkopts = nil
+		} else {
+			v := value.([]string)
+			self.Linkopts = &v
+		}
+		return "linkopts"
+	case "linkstatic":
+		if value == nil {
+			self.Linkstatic = nil
+		} else {
+			v := value.(bool)
+			self.Linkstatic = &v
+		}
+		return "linkstatic"
+	case "nocopts":
+		if value == nil {
+			self.Nocopts = nil
+		} else {
+			v := value.([]string)
+			self.Nocopts = &v
+		}
+		return "nocopts"
+	case "strip_include_prefix":
+		if value == nil {
+			self.StripIncludePrefix = nil
+		} else {
+			v := value.(string)
+			self.StripIncludePrefix = &v
+		}
+		return "strip_include_prefix"
+	case "textual_hdrs":
+		if value == nil {
+			self.TextualHdrs = nil
+		} else {
+			v := value.([]core.Label)
+			self.TextualHdrs = &v
+		}
+		return "textual_hdrs"
+	case "win_def_file":
+		if value == nil {
+			self.WinDefFile = nil
+		} else {
+			v := value.(core.Label)
+			self.WinDefFile = &v
+		}
+		return "win_def_file"
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *CcLibraryTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *CcLibraryTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	if self.Srcs != nil {
+		fn("srcs", *self.Srcs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "srcs", fn)
+	}
+	if self.Hdrs != nil {
+		fn("hdrs", *self.Hdrs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "hdrs", fn)
+	}
+	if self.Alwayslink != nil {
+		fn("alwayslink", *self.Alwayslink, nil)
+	} else {
+		visitConfigurable(configurableTarget, "alwayslink", fn)
+	}
+	if self.Copts != nil {
+		fn("copts", *self.Copts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "copts", fn)
+	}
+	if self.Defines != nil {
+		fn("defines", *self.Defines, nil)
+	} else {
+		visitConfigurable(configurableTarget, "defines", fn)
+	}
+	if self.IncludePrefix != nil {
+		fn("include_prefix", *self.IncludePrefix, nil)
+	} else {
+		visitConfigurable(configurableTarget, "include_prefix", fn)
+	}
+	if self.Includes != nil {
+		fn("includes", *self.Includes, nil)
+	} else {
+		visitConfigurable(configurableTarget, "includes", fn)
+	}
+	if self.Linkopts != nil {
+		fn("linkopts", *self.Linkopts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkopts", fn)
+	}
+	if self.Linkstatic != nil {
+		fn("linkstatic", *self.Linkstatic, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkstatic", fn)
+	}
+	if self.Nocopts != nil {
+		fn("nocopts", *self.Nocopts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "nocopts", fn)
+	}
+	if self.StripIncludePrefix != nil {
+		fn("strip_include_prefix", *self.StripIncludePrefix, nil)
+	} else {
+		visitConfigurable(configurableTarget, "strip_include_prefix", fn)
+	}
+	if self.TextualHdrs != nil {
+		fn("textual_hdrs", *self.TextualHdrs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "textual_hdrs", fn)
+	}
+	if self.WinDefFile != nil {
+		fn("win_def_file", *self.WinDefFile, nil)
+	} else {
+		visitConfigurable(configurableTarget, "win_def_file", fn)
+	}
+}
+
+// GetSrcs returns the value of srcs, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcLibraryTarget) GetSrcs() []core.Label {
+	if self.Srcs != nil {
+		return *self.Srcs
+	}
+	return nil
+}
+
+// GetHdrs returns the value of hdrs, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcLibraryTarget) GetHdrs() []core.Label {
+	if self.Hdrs != nil {
+		return *self.Hdrs
+	}
+	return nil
+}
+
+// GetAlwayslink returns the value of alwayslink, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcLibraryTarget) GetAlwayslink() bool {
+	if self.Alwayslink != nil {
+		return *self.Alwayslink
+	}
+	return false
+}
+
+// GetCopts returns the value of copts, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcLibraryTarget) GetCopts() []string {
+	if self.Copts != nil {
+		return *self.Copts
+	}
+	return nil
+}
+
+// GetDefines returns the value of defines, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcLibraryTarget) GetDefines() []string {
+	if self.Defines != nil {
+		return *self.Defines
+	}
+	return nil
+}
+
+// GetIncludePrefix returns the value of include_prefix, or its default if it is unset (or given
+// using select() and unresolved).
+func (self *CcLibraryTarget) GetIncludePrefix() string {
+	if self.IncludePrefix != nil {
+		return *self.IncludePrefix
+	}
+	return ""
+}
+
+// GetIncludes returns the value of includes, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcLibraryTarget) GetIncludes() []string {
+	if self.Includes != nil {
+		return *self.Includes
+	}
+	return nil
+}
+
+// GetLinkopts returns the value of linkopts, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcLibraryTarget) GetLinkopts() []string {
+	if self.Linkopts != nil {
+		return *self.Linkopts
+	}
+	return nil
+}
+
+// GetLinkstatic returns the value of linkstatic, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcLibraryTarget) GetLinkstatic() bool {
+	if self.Linkstatic != nil {
+		return *self.Linkstatic
+	}
+	return false
+}
+
+// GetNocopts returns the value of nocopts, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcLibraryTarget) GetNocopts() []string {
+	if self.Nocopts != nil {
+		return *self.Nocopts
+	}
+	return nil
+}
+
+// GetStripIncludePrefix returns the value of strip_include_prefix, or its default if it is unset
+// (or given using select() and unresolved).
+func (self *CcLibraryTarget) GetStripIncludePrefix() string {
+	if self.StripIncludePrefix != nil {
+		return *self.StripIncludePrefix
+	}
+	return ""
+}
+
+// GetTextualHdrs returns the value of textual_hdrs, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcLibraryTarget) GetTextualHdrs() []core.Label {
+	if self.TextualHdrs != nil {
+		return *self.TextualHdrs
+	}
+	return nil
+}
+
+// GetWinDefFile returns the value of win_def_file, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcLibraryTarget) GetWinDefFile() core.Label {
+	if self.WinDefFile != nil {
+		return *self.WinDefFile
+	}
+	return core.Label{}
+}
+
+func (self *CcLibraryTarget) Kind() string {
+	return "cc_library"
+}
+
+func (self *CcLibraryTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *CcLibraryTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// CcLibrary implements the Bazel cc_library rule.
+var CcLibrary = NewRule("cc_library",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &CcLibraryTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// CcTestTarget is a target of the cc_test rule (a C++ test).
+type CcTestTarget struct {
+	TargetCommon
+	TargetCommonTest
+
+	// Srcs is the value of srcs, or nil if unset: the source (and private header) files.
+	Srcs *[]core.Label `bazel:"srcs"`
+
+	// AdditionalLinkerInputs is the value of additional_linker_inputs, or nil if unset: the
+	// additional files to pass to the linker.
+	AdditionalLinkerInputs *[]core.Label `bazel:"additional_linker_inputs"`
+
+	// Copts is the value of copts, or nil if unset: the C++ compiler options.
+	Copts *[]string `bazel:"copts"`
+
+	// Defines is the value of defines, or nil if unset: the defines (also for dependents).
+	Defines *[]string `bazel:"defines"`
+
+	// Includes is the value of includes, or nil if unset: the include directories (relative to
+	// the package).
+	Includes *[]string `bazel:"includes"`
+
+	// Linkopts is the value of linkopts, or nil if unset: the linker options.
+	Linkopts *[]string `bazel:"linkopts"`
+
+	// Linkstatic is the value of linkstatic, or nil if unset: whether to link statically.
+	Linkstatic *bool `bazel:"linkstatic"`
+
+	// Malloc is the value of malloc, or nil if unset: the malloc implementation to use.
+	Malloc *core.Label `bazel:"malloc"`
+
+	// Nocopts is the value of nocopts, or nil if unset: the (regular expressions for) C++
+	// compiler options to remove.
+	Nocopts *[]string `bazel:"nocopts"`
+
+	// Stamp is the value of stamp, or nil if unset: whether to stamp build information into the
+	// binary (1, 0, or -1 to only do so if --stamp is given).
+	Stamp *int64 `bazel:"stamp"`
+
+	// WinDefFile is the value of win_def_file, or nil if unset: the Windows DEF file to pass to
+	// the linker.
+	WinDefFile *core.Label `bazel:"win_def_file"`
+
+	// Env is the value of env, or nil if unset: the environment variables to set when the test
+	// is run.
+	Env *map[string]string `bazel:"env"`
+
+	// EnvInherit is the value of env_inherit, or nil if unset: the environment variables to
+	// inherit when the test is run.
+	EnvInherit *[]string `bazel:"env_inherit"`
+}
+
+var _ builtins_args.ArgsBinder = (*CcTestTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*CcTestTarget)(nil)
+var _ core.Target = (*CcTestTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *CcTestTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := self.TargetCommonTest.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "srcs", ctx,
+		configurableTarget, &self.Srcs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "additional_linker_inputs", ctx,
+		configurableTarget, &self.AdditionalLinkerInputs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "copts", ctx,
+		configurableTarget, &self.Copts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "defines", ctx,
+		configurableTarget, &self.Defines); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "includes", ctx,
+		configurableTarget, &self.Includes); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkopts", ctx,
+		configurableTarget, &self.Linkopts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "linkstatic", ctx,
+		configurableTarget, &self.Linkstatic); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "malloc", ctx,
+		configurableTarget, &self.Malloc); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "nocopts", ctx,
+		configurableTarget, &self.Nocopts); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "stamp", ctx,
+		configurableTarget, &self.Stamp); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "win_def_file", ctx,
+		configurableTarget, &self.WinDefFile); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "env", ctx,
+		configurableTarget, &self.Env); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "env_inherit", ctx,
+		configurableTarget, &self.EnvInherit); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *CcTestTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	if tag := self.TargetCommonTest.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	switch argName {
+	case "srcs":
+		if value == nil {
+			self.Srcs = nil
+		} else {
+			v := value.([]core.Label)
+			self.Srcs = &v
+		}
+		return "srcs"
+	case "additional_linker_inputs":
+		if value == nil {
+			self.AdditionalLinkerInputs = nil
+		} else {
+			v := value.([]core.Label)
+			self.AdditionalLinkerInputs = &v
+		}
+		return "additional_linker_inputs"
+	case "copts":
+		if value == nil {
+			self.Copts = nil
+		} else {
+			v := value.([]string)
+			self.Copts = &v
+		}
+		return "copts"
+	case "defines":
+		if value == nil {
+			self.Defines = nil
+		} else {
+			v := value.([]string)
+			self.Defines = &v
+		}
+		return "defines"
+	case "includes":
+		if value == nil {
+			self.Includes = nil
+		} else {
+			v := value.([]string)
+			self.Includes = &v
+		}
+		return "includes"
+	case "linkopts":
+		if value == nil {
+			self.Linkopts = nil
+		} else {
+			v := value.([]string)
+			self.Linkopts = &v
+		}
+		return "linkopts"
+	case "linkstatic":
+		if value == nil {
+			self.Linkstatic = nil
+		} else {
+			v := value.(bool)
+			self.Linkstatic = &v
+		}
+		return "linkstatic"
+	case "malloc":
+		if value == nil {
+			self.Malloc = nil
+		} else {
+			v := value.(core.Label)
+			self.Malloc = &v
+		}
+		return "malloc"
+	case "nocopts":
+		if value == nil {
+			self.Nocopts = nil
+		} else {
+			v := value.([]string)
+			self.Nocopts = &v
+		}
+		return "nocopts"
+	case "stamp":
+		if value == nil {
+			self.Stamp = nil
+		} else {
+			v := value.(int64)
+			self.Stamp = &v
+		}
+		return "stamp"
+	case "win_def_file":
+		if value == nil {
+			self.WinDefFile = nil
+		} else {
+			v := value.(core.Label)
+			self.WinDefFile = &v
+		}
+		return "win_def_file"
+	case "env":
+		if value == nil {
+			self.Env = nil
+		} else {
+			v := value.(map[string]string)
+			self.Env = &v
+		}
+		return "env"
+	case "env_inherit":
+		if value == nil {
+			self.EnvInherit = nil
+		} else {
+			v := value.([]string)
+			self.EnvInherit = &v
+		}
+		return "env_inherit"
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *CcTestTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	if err := self.TargetCommonTest.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *CcTestTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	self.TargetCommonTest.forEachGroupAttr(configurableTarget, fn)
+	if self.Srcs != nil {
+		fn("srcs", *self.Srcs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "srcs", fn)
+	}
+	if self.AdditionalLinkerInputs != nil {
+		fn("additional_linker_inputs", *self.AdditionalLinkerInputs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "additional_linker_inputs", fn)
+	}
+	if self.Copts != nil {
+		fn("copts", *self.Copts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "copts", fn)
+	}
+	if self.Defines != nil {
+		fn("defines", *self.Defines, nil)
+	} else {
+		visitConfigurable(configurableTarget, "defines", fn)
+	}
+	if self.Includes != nil {
+		fn("includes", *self.Includes, nil)
+	} else {
+		visitConfigurable(configurableTarget, "includes", fn)
+	}
+	if self.Linkopts != nil {
+		fn("linkopts", *self.Linkopts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkopts", fn)
+	}
+	if self.Linkstatic != nil {
+		fn("linkstatic", *self.Linkstatic, nil)
+	} else {
+		visitConfigurable(configurableTarget, "linkstatic", fn)
+	}
+	if self.Malloc != nil {
+		fn("malloc", *self.Malloc, nil)
+	} else {
+		visitConfigurable(configurableTarget, "malloc", fn)
+	}
+	if self.Nocopts != nil {
+		fn("nocopts", *self.Nocopts, nil)
+	} else {
+		visitConfigurable(configurableTarget, "nocopts", fn)
+	}
+	if self.Stamp != nil {
+		fn("stamp", *self.Stamp, nil)
+	} else {
+		visitConfigurable(configurableTarget, "stamp", fn)
+	}
+	if self.WinDefFile != nil {
+		fn("win_def_file", *self.WinDefFile, nil)
+	} else {
+		visitConfigurable(configurableTarget, "win_def_file", fn)
+	}
+	if self.Env != nil {
+		fn("env", *self.Env, nil)
+	} else {
+		visitConfigurable(configurableTarget, "env", fn)
+	}
+	if self.EnvInherit != nil {
+		fn("env_inherit", *self.EnvInherit, nil)
+	} else {
+		visitConfigurable(configurableTarget, "env_inherit", fn)
+	}
+}
+
+// GetSrcs returns the value of srcs, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcTestTarget) GetSrcs() []core.Label {
+	if self.Srcs != nil {
+		return *self.Srcs
+	}
+	return nil
+}
+
+// GetAdditionalLinkerInputs returns the value of additional_linker_inputs, or its default if it is
+// unset (or given using select() and unresolved).
+func (self *CcTestTarget) GetAdditionalLinkerInputs() []core.Label {
+	if self.AdditionalLinkerInputs != nil {
+		return *self.AdditionalLinkerInputs
+	}
+	return nil
+}
+
+// GetCopts returns the value of copts, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcTestTarget) GetCopts() []string {
+	if self.Copts != nil {
+		return *self.Copts
+	}
+	return nil
+}
+
+// GetDefines returns the value of defines, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcTestTarget) GetDefines() []string {
+	if self.Defines != nil {
+		return *self.Defines
+	}
+	return nil
+}
+
+// GetIncludes returns the value of includes, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcTestTarget) GetIncludes() []string {
+	if self.Includes != nil {
+		return *self.Includes
+	}
+	return nil
+}
+
+// GetLinkopts returns the value of linkopts, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcTestTarget) GetLinkopts() []string {
+	if self.Linkopts != nil {
+		return *self.Linkopts
+	}
+	return nil
+}
+
+// GetLinkstatic returns the value of linkstatic, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcTestTarget) GetLinkstatic() bool {
+	if self.Linkstatic != nil {
+		return *self.Linkstatic
+	}
+	return false
+}
+
+// GetMalloc returns the value of malloc, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcTestTarget) GetMalloc() core.Label {
+	if self.Malloc != nil {
+		return *self.Malloc
+	}
+	return core.Label{}
+}
+
+// GetNocopts returns the value of nocopts, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *CcTestTarget) GetNocopts() []string {
+	if self.Nocopts != nil {
+		return *self.Nocopts
+	}
+	return nil
+}
+
+// GetStamp returns the value of stamp, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcTestTarget) GetStamp() int64 {
+	if self.Stamp != nil {
+		return *self.Stamp
+	}
+	return 0
+}
+
+// GetWinDefFile returns the value of win_def_file, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcTestTarget) GetWinDefFile() core.Label {
+	if self.WinDefFile != nil {
+		return *self.WinDefFile
+	}
+	return core.Label{}
+}
+
+// GetEnv returns the value of env, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *CcTestTarget) GetEnv() map[string]string {
+	if self.Env != nil {
+		return *self.Env
+	}
+	return nil
+}
+
+// GetEnvInherit returns the value of env_inherit, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *CcTestTarget) GetEnvInherit() []string {
+	if self.EnvInherit != nil {
+		return *self.EnvInherit
+	}
+	return nil
+}
+
+func (self *CcTestTarget) Kind() string {
+	return "cc_test"
+}
+
+func (self *CcTestTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *CcTestTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// CcTest implements the Bazel cc_test rule.
+var CcTest = NewRule("cc_test",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &CcTestTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// GenruleTarget is a target of the genrule rule (a general rule that generates files using a shell
+// command).
+type GenruleTarget struct {
+	TargetCommon
+
+	// Srcs is the value of srcs, or nil if unset: the input files.
+	Srcs *[]core.Label `bazel:"srcs"`
+
+	// Outs is the value of outs, or nil if unset: the output files.
+	Outs *[]core.Label `bazel:"outs!,nonconfigurable,output"`
+
+	// Cmd is the value of cmd, or nil if unset: the command to run.
+	Cmd *string `bazel:"cmd"`
+
+	// CmdBash is the value of cmd_bash, or nil if unset: the command to run using Bash
+	// (overriding cmd).
+	CmdBash *string `bazel:"cmd_bash"`
+
+	// CmdBat is the value of cmd_bat, or nil if unset: the command to run using cmd.exe
+	// (overriding cmd).
+	CmdBat *string `bazel:"cmd_bat"`
+
+	// CmdPs is the value of cmd_ps, or nil if unset: the command to run using PowerShell
+	// (overriding cmd).
+	CmdPs *string `bazel:"cmd_ps"`
+
+	// Tools is the value of tools, or nil if unset: the tools used by the command (built for
+	// the host).
+	Tools *[]core.Label `bazel:"tools"`
+
+	// ExecTools is the value of exec_tools, or nil if unset: the tools used by the command
+	// (built for execution).
+	ExecTools *[]core.Label `bazel:"exec_tools"`
+
+	// Message is the value of message, or nil if unset: the progress message to print.
+	Message *string `bazel:"message"`
+
+	// OutputLicenses is the value of output_licenses, or nil if unset: the licenses of the
+	// outputs.
+	OutputLicenses *[]string `bazel:"output_licenses,license"`
+
+	// OutputToBindir is the value of output_to_bindir, or nil if unset: whether to put the
+	// outputs in bin (instead of genfiles).
+	OutputToBindir *bool `bazel:"output_to_bindir,nonconfigurable"`
+
+	// Local is the value of local, or nil if unset: whether the command must be run locally
+	// (unsandboxed).
+	Local *bool `bazel:"local"`
+
+	// Executable is the value of executable, or nil if unset: whether the (single) output is
+	// executable.
+	Executable *bool `bazel:"executable,nonconfigurable"`
+
+	// Stamp is the value of stamp, or nil if unset: whether the command may use build
+	// information.
+	Stamp *bool `bazel:"stamp"`
+}
+
+var _ builtins_args.ArgsBinder = (*GenruleTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*GenruleTarget)(nil)
+var _ core.Target = (*GenruleTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *GenruleTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "srcs", ctx,
+		configurableTarget, &self.Srcs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "outs!,nonconfigurable,output", ctx,
+		configurableTarget, &self.Outs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "cmd", ctx,
+		configurableTarget, &self.Cmd); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "cmd_bash", ctx,
+		configurableTarget, &self.CmdBash); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "cmd_bat", ctx,
+		configurableTarget, &self.CmdBat); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "cmd_ps", ctx,
+		configurableTarget, &self.CmdPs); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "tools", ctx,
+		configurableTarget, &self.Tools); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "exec_tools", ctx,
+		configurableTarget, &self.ExecTools); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "message", ctx,
+		configurableTarget, &self.Message); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "output_licenses,license", ctx,
+		configurableTarget, &self.OutputLicenses); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "output_to_bindir,nonconfigurable", ctx,
+		configurableTarget, &self.OutputToBindir); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "local", ctx,
+		configurableTarget, &self.Local); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "executable,nonconfigurable", ctx,
+		configurableTarget, &self.Executable); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "stamp", ctx,
+		configurableTarget, &self.Stamp); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *GenruleTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	switch argName {
+	case "srcs":
+		if value == nil {
+			self.Srcs = nil
+		} else {
+			v := value.([]core.Label)
+			self.Srcs = &v
+		}
+		return "srcs"
+	case "cmd":
+		if value == nil {
+			self.Cmd = nil
+		} else {
+			v := value.(string)
+			self.Cmd = &v
+		}
+		return "cmd"
+	case "cmd_bash":
+		if value == nil {
+			self.CmdBash = nil
+		} else {
+			v := value.(string)
+			self.CmdBash = &v
+		}
+		return "cmd_bash"
+	case "cmd_bat":
+		if value == nil {
+			self.CmdBat = nil
+		} else {
+			v := value.(string)
+			self.CmdBat = &v
+		}
+		return "cmd_bat"
+	case "cmd_ps":
+		if value == nil {
+			self.CmdPs = nil
+		} else {
+			v := value.(string)
+			self.CmdPs = &v
+		}
+		return "cmd_ps"
+	case "tools":
+		if value == nil {
+			self.Tools = nil
+		} else {
+			v := value.([]core.Label)
+			self.Tools = &v
+		}
+		return "tools"
+	case "exec_tools":
+		if value == nil {
+			self.ExecTools = nil
+		} else {
+			v := value.([]core.Label)
+			self.ExecTools = &v
+		}
+		return "exec_tools"
+	case "message":
+		if value == nil {
+			self.Message = nil
+		} else {
+			v := value.(string)
+			self.Message = &v
+		}
+		return "message"
+	case "output_licenses":
+		if value == nil {
+			self.OutputLicenses = nil
+		} else {
+			v := value.([]string)
+			self.OutputLicenses = &v
+		}
+		return "output_licenses,license"
+	case "local":
+		if value == nil {
+			self.Local = nil
+		} else {
+			v := value.(bool)
+			self.Local = &v
+		}
+		return "local"
+	case "stamp":
+		if value == nil {
+			self.Stamp = nil
+		} else {
+			v := value.(bool)
+			self.Stamp = &v
+		}
+		return "stamp"
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *GenruleTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *GenruleTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	if self.Srcs != nil {
+		fn("srcs", *self.Srcs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "srcs", fn)
+	}
+	if self.Outs != nil {
+		fn("outs", *self.Outs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "outs", fn)
+	}
+	if self.Cmd != nil {
+		fn("cmd", *self.Cmd, nil)
+	} else {
+		visitConfigurable(configurableTarget, "cmd", fn)
+	}
+	if self.CmdBash != nil {
+		fn("cmd_bash", *self.CmdBash, nil)
+	} else {
+		visitConfigurable(configurableTarget, "cmd_bash", fn)
+	}
+	if self.CmdBat != nil {
+		fn("cmd_bat", *self.CmdBat, nil)
+	} else {
+		visitConfigurable(configurableTarget, "cmd_bat", fn)
+	}
+	if self.CmdPs != nil {
+		fn("cmd_ps", *self.CmdPs, nil)
+	} else {
+		visitConfigurable(configurableTarget, "cmd_ps", fn)
+	}
+	if self.Tools != nil {
+		fn("tools", *self.Tools, nil)
+	} else {
+		visitConfigurable(configurableTarget, "tools", fn)
+	}
+	if self.ExecTools != nil {
+		fn("exec_tools", *self.ExecTools, nil)
+	} else {
+		visitConfigurable(configurableTarget, "exec_tools", fn)
+	}
+	if self.Message != nil {
+		fn("message", *self.Message, nil)
+	} else {
+		visitConfigurable(configurableTarget, "message", fn)
+	}
+	if self.OutputLicenses != nil {
+		fn("output_licenses", *self.OutputLicenses, nil)
+	} else {
+		visitConfigurable(configurableTarget, "output_licenses", fn)
+	}
+	if self.OutputToBindir != nil {
+		fn("output_to_bindir", *self.OutputToBindir, nil)
+	} else {
+		visitConfigurable(configurableTarget, "output_to_bindir", fn)
+	}
+	if self.Local != nil {
+		fn("local", *self.Local, nil)
+	} else {
+		visitConfigurable(configurableTarget, "local", fn)
+	}
+	if self.Executable != nil {
+		fn("executable", *self.Executable, nil)
+	} else {
+		visitConfigurable(configurableTarget, "executable", fn)
+	}
+	if self.Stamp != nil {
+		fn("stamp", *self.Stamp, nil)
+	} else {
+		visitConfigurable(configurableTarget, "stamp", fn)
+	}
+}
+
+// GetSrcs returns the value of srcs, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetSrcs() []core.Label {
+	if self.Srcs != nil {
+		return *self.Srcs
+	}
+	return nil
+}
+
+// GetOuts returns the value of outs, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetOuts() []core.Label {
+	if self.Outs != nil {
+		return *self.Outs
+	}
+	return nil
+}
+
+// GetCmd returns the value of cmd, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetCmd() string {
+	if self.Cmd != nil {
+		return *self.Cmd
+	}
+	return ""
+}
+
+// GetCmdBash returns the value of cmd_bash, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *GenruleTarget) GetCmdBash() string {
+	if self.CmdBash != nil {
+		return *self.CmdBash
+	}
+	return ""
+}
+
+// GetCmdBat returns the value of cmd_bat, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *GenruleTarget) GetCmdBat() string {
+	if self.CmdBat != nil {
+		return *self.CmdBat
+	}
+	return ""
+}
+
+// GetCmdPs returns the value of cmd_ps, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetCmdPs() string {
+	if self.CmdPs != nil {
+		return *self.CmdPs
+	}
+	return ""
+}
+
+// GetTools returns the value of tools, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetTools() []core.Label {
+	if self.Tools != nil {
+		return *self.Tools
+	}
+	return nil
+}
+
+// GetExecTools returns the value of exec_tools, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *GenruleTarget) GetExecTools() []core.Label {
+	if self.ExecTools != nil {
+		return *self.ExecTools
+	}
+	return nil
+}
+
+// GetMessage returns the value of message, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *GenruleTarget) GetMessage() string {
+	if self.Message != nil {
+		return *self.Message
+	}
+	return ""
+}
+
+// GetOutputLicenses returns the value of output_licenses, or its default if it is unset (or given
+// using select() and unresolved).
+func (self *GenruleTarget) GetOutputLicenses() []string {
+	if self.OutputLicenses != nil {
+		return *self.OutputLicenses
+	}
+	return nil
+}
+
+// GetOutputToBindir returns the value of output_to_bindir, or its default if it is unset (or given
+// using select() and unresolved).
+func (self *GenruleTarget) GetOutputToBindir() bool {
+	if self.OutputToBindir != nil {
+		return *self.OutputToBindir
+	}
+	return false
+}
+
+// GetLocal returns the value of local, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetLocal() bool {
+	if self.Local != nil {
+		return *self.Local
+	}
+	return false
+}
+
+// GetExecutable returns the value of executable, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *GenruleTarget) GetExecutable() bool {
+	if self.Executable != nil {
+		return *self.Executable
+	}
+	return false
+}
+
+// GetStamp returns the value of stamp, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *GenruleTarget) GetStamp() bool {
+	if self.Stamp != nil {
+		return *self.Stamp
+	}
+	return false
+}
+
+func (self *GenruleTarget) Kind() string {
+	return "genrule"
+}
+
+func (self *GenruleTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *GenruleTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// Genrule implements the Bazel genrule rule.
+var Genrule = NewRule("genrule",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &GenruleTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// ConfigSettingTarget is a target of the config_setting rule (a configuration condition (for
+// select())).
+type ConfigSettingTarget struct {
+	TargetCommon
+
+	// Values is the value of values, or nil if unset: the required flag values.
+	Values *map[string]string `bazel:"values,nonconfigurable"`
+
+	// DefineValues is the value of define_values, or nil if unset: the required --define
+	// values.
+	DefineValues *map[string]string `bazel:"define_values,nonconfigurable"`
+
+	// FlagValues is the value of flag_values, or nil if unset: the required custom flag values.
+	FlagValues *map[core.Label]string `bazel:"flag_values,nonconfigurable"`
+
+	// ConstraintValues is the value of constraint_values, or nil if unset: the constraint
+	// values the target platform must have.
+	ConstraintValues *[]core.Label `bazel:"constraint_values,nonconfigurable"`
+}
+
+var _ builtins_args.ArgsBinder = (*ConfigSettingTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*ConfigSettingTarget)(nil)
+var _ core.Target = (*ConfigSettingTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *ConfigSettingTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "values,nonconfigurable", ctx,
+		configurableTarget, &self.Values); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "define_values,nonconfigurable", ctx,
+		configurableTarget, &self.DefineValues); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "flag_values,nonconfigurable", ctx,
+		configurableTarget, &self.FlagValues); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "constraint_values,nonconfigurable", ctx,
+		configurableTarget, &self.ConstraintValues); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *ConfigSettingTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *ConfigSettingTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *ConfigSettingTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	if self.Values != nil {
+		fn("values", *self.Values, nil)
+	} else {
+		visitConfigurable(configurableTarget, "values", fn)
+	}
+	if self.DefineValues != nil {
+		fn("define_values", *self.DefineValues, nil)
+	} else {
+		visitConfigurable(configurableTarget, "define_values", fn)
+	}
+	if self.FlagValues != nil {
+		fn("flag_values", *self.FlagValues, nil)
+	} else {
+		visitConfigurable(configurableTarget, "flag_values", fn)
+	}
+	if self.ConstraintValues != nil {
+		fn("constraint_values", *self.ConstraintValues, nil)
+	} else {
+		visitConfigurable(configurableTarget, "constraint_values", fn)
+	}
+}
+
+// GetValues returns the value of values, or its default if it is unset (or given using select() and
+// unresolved).
+func (self *ConfigSettingTarget) GetValues() map[string]string {
+	if self.Values != nil {
+		return *self.Values
+	}
+	return nil
+}
+
+// GetDefineValues returns the value of define_values, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *ConfigSettingTarget) GetDefineValues() map[string]string {
+	if self.DefineValues != nil {
+		return *self.DefineValues
+	}
+	return nil
+}
+
+// GetFlagValues returns the value of flag_values, or its default if it is unset (or given using
+// select() and unresolved).
+func (self *ConfigSettingTarget) GetFlagValues() map[core.Label]string {
+	if self.FlagValues != nil {
+		return *self.FlagValues
+	}
+	return nil
+}
+
+// GetConstraintValues returns the value of constraint_values, or its default if it is unset (or
+// given using select() and unresolved).
+func (self *ConfigSettingTarget) GetConstraintValues() []core.Label {
+	if self.ConstraintValues != nil {
+		return *self.ConstraintValues
+	}
+	return nil
+}
+
+func (self *ConfigSettingTarget) Kind() string {
+	return "config_setting"
+}
+
+func (self *ConfigSettingTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *ConfigSettingTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// ConfigSetting implements the Bazel config_setting rule.
+var ConfigSetting = NewRule("config_setting",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &ConfigSettingTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// ConstraintSettingTarget is a target of the constraint_setting rule (a constraint setting (a
+// dimension along which platforms may differ)).
+type ConstraintSettingTarget struct {
+	TargetCommon
+
+	// DefaultConstraintValue is the value of default_constraint_value, or nil if unset: the
+	// value for platforms that don't specify one.
+	DefaultConstraintValue *core.Label `bazel:"default_constraint_value,nonconfigurable"`
+}
+
+var _ builtins_args.ArgsBinder = (*ConstraintSettingTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*ConstraintSettingTarget)(nil)
+var _ core.Target = (*ConstraintSettingTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *ConstraintSettingTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "default_constraint_value,nonconfigurable", ctx,
+		configurableTarget, &self.DefaultConstraintValue); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *ConstraintSettingTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *ConstraintSettingTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *ConstraintSettingTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	if self.DefaultConstraintValue != nil {
+		fn("default_constraint_value", *self.DefaultConstraintValue, nil)
+	} else {
+		visitConfigurable(configurableTarget, "default_constraint_value", fn)
+	}
+}
+
+// GetDefaultConstraintValue returns the value of default_constraint_value, or its default if it is
+// unset (or given using select() and unresolved).
+func (self *ConstraintSettingTarget) GetDefaultConstraintValue() core.Label {
+	if self.DefaultConstraintValue != nil {
+		return *self.DefaultConstraintValue
+	}
+	return core.Label{}
+}
+
+func (self *ConstraintSettingTarget) Kind() string {
+	return "constraint_setting"
+}
+
+func (self *ConstraintSettingTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *ConstraintSettingTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// ConstraintSetting implements the Bazel constraint_setting rule.
+var ConstraintSetting = NewRule("constraint_setting",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &ConstraintSettingTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// ConstraintValueTarget is a target of the constraint_value rule (a value of a constraint setting).
+type ConstraintValueTarget struct {
+	TargetCommon
+
+	// ConstraintSetting is the value of constraint_setting, or nil if unset: the constraint
+	// setting this is a value of.
+	ConstraintSetting *core.Label `bazel:"constraint_setting!,nonconfigurable"`
+}
+
+var _ builtins_args.ArgsBinder = (*ConstraintValueTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*ConstraintValueTarget)(nil)
+var _ core.Target = (*ConstraintValueTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *ConstraintValueTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "constraint_setting!,nonconfigurable", ctx,
+		configurableTarget, &self.ConstraintSetting); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *ConstraintValueTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *ConstraintValueTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *ConstraintValueTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	if self.ConstraintSetting != nil {
+		fn("constraint_setting", *self.ConstraintSetting, nil)
+	} else {
+		visitConfigurable(configurableTarget, "constraint_setting", fn)
+	}
+}
+
+// GetConstraintSetting returns the value of constraint_setting, or its default if it is unset (or
+// given using select() and unresolved).
+func (self *ConstraintValueTarget) GetConstraintSetting() core.Label {
+	if self.ConstraintSetting != nil {
+		return *self.ConstraintSetting
+	}
+	return core.Label{}
+}
+
+func (self *ConstraintValueTarget) Kind() string {
+	return "constraint_value"
+}
+
+func (self *ConstraintValueTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *ConstraintValueTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// ConstraintValue implements the Bazel constraint_value rule.
+var ConstraintValue = NewRule("constraint_value",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &ConstraintValueTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+
+// PlatformTarget is a target of the platform rule (a platform (a collection of constraint values)).
+type PlatformTarget struct {
+	TargetCommon
+
+	// ConstraintValues is the value of constraint_values, or nil if unset: the platform's
+	// constraint values.
+	ConstraintValues *[]core.Label `bazel:"constraint_values,nonconfigurable"`
+
+	// Parents is the value of parents, or nil if unset: the platform (at most one) to inherit
+	// from.
+	Parents *[]core.Label `bazel:"parents,nonconfigurable"`
+
+	// RemoteExecutionProperties is the value of remote_execution_properties, or nil if unset:
+	// the properties for remote execution (deprecated).
+	RemoteExecutionProperties *string `bazel:"remote_execution_properties"`
+}
+
+var _ builtins_args.ArgsBinder = (*PlatformTarget)(nil)
+var _ builtins_args.ResolvedArgsTarget = (*PlatformTarget)(nil)
+var _ core.Target = (*PlatformTarget)(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *PlatformTarget) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+
+	if err := self.TargetCommon.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "constraint_values,nonconfigurable", ctx,
+		configurableTarget, &self.ConstraintValues); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "parents,nonconfigurable", ctx,
+		configurableTarget, &self.Parents); err != nil {
+		return err
+	}
+	if err := builtins_args.BindArg(kwargs, "remote_execution_properties", ctx,
+		configurableTarget, &self.RemoteExecutionProperties); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *PlatformTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	switch argName {
+	case "remote_execution_properties":
+		if value == nil {
+			self.RemoteExecutionProperties = nil
+		} else {
+			v := value.(string)
+			self.RemoteExecutionProperties = &v
+		}
+		return "remote_execution_properties"
+	}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *PlatformTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *PlatformTarget) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	self.TargetCommon.forEachGroupAttr(configurableTarget, fn)
+	if self.ConstraintValues != nil {
+		fn("constraint_values", *self.ConstraintValues, nil)
+	} else {
+		visitConfigurable(configurableTarget, "constraint_values", fn)
+	}
+	if self.Parents != nil {
+		fn("parents", *self.Parents, nil)
+	} else {
+		visitConfigurable(configurableTarget, "parents", fn)
+	}
+	if self.RemoteExecutionProperties != nil {
+		fn("remote_execution_properties", *self.RemoteExecutionProperties, nil)
+	} else {
+		visitConfigurable(configurableTarget, "remote_execution_properties", fn)
+	}
+}
+
+// GetConstraintValues returns the value of constraint_values, or its default if it is unset (or
+// given using select() and unresolved).
+func (self *PlatformTarget) GetConstraintValues() []core.Label {
+	if self.ConstraintValues != nil {
+		return *self.ConstraintValues
+	}
+	return nil
+}
+
+// GetParents returns the value of parents, or its default if it is unset (or given using select()
+// and unresolved).
+func (self *PlatformTarget) GetParents() []core.Label {
+	if self.Parents != nil {
+		return *self.Parents
+	}
+	return nil
+}
+
+// GetRemoteExecutionProperties returns the value of remote_execution_properties, or its default if
+// it is unset (or given using select() and unresolved).
+func (self *PlatformTarget) GetRemoteExecutionProperties() string {
+	if self.RemoteExecutionProperties != nil {
+		return *self.RemoteExecutionProperties
+	}
+	return ""
+}
+
+func (self *PlatformTarget) Kind() string {
+	return "platform"
+}
+
+func (self *PlatformTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *PlatformTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// Platform implements the Bazel platform rule.
+var Platform = NewRule("platform",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &PlatformTarget{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
@@ -0,0 +1,337 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.starlark.net/starlark"
+
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// genericAttrTypes are the types of (well-known) attributes of native rules that we don't model,
+// whose types can't be inferred from their values (since labels are given as strings).
+var genericAttrTypes = map[string]values.AttrType{
+	"actual":           values.AttrTypeLabel,
+	"exported_plugins": values.AttrTypeLabelList,
+	"exports":          values.AttrTypeLabelList,
+	"hdrs":             values.AttrTypeLabelList,
+	"plugins":          values.AttrTypeLabelList,
+	"resources":        values.AttrTypeLabelList,
+	"runtime_deps":     values.AttrTypeLabelList,
+	"srcs":             values.AttrTypeLabelList,
+	"tests":            values.AttrTypeLabelList,
+	"textual_hdrs":     values.AttrTypeLabelList,
+}
+
+// stringElems returns the elements of a starlark list or tuple as strings, if they're all strings.
+func stringElems(value starlark.Indexable) ([]string, bool) {
+	rv := make([]string, value.Len())
+	for i := range rv {
+		s, ok := value.Index(i).(starlark.String)
+		if !ok {
+			return nil, false
+		}
+		rv[i] = string(s)
+	}
+	return rv, true
+}
+
+// inferGenericValue converts a single (non-select()) value of an attribute of unknown type, based
+// on its starlark type: None, bools, ints, strings, Labels, lists (or tuples) of strings, ints, or
+// Labels, and dicts of strings to strings or lists of strings are converted to the corresponding
+// Go values (as for attributes of the corresponding types); other values are kept as (frozen)
+// starlark values.
+func inferGenericValue(argName string, value starlark.Value) (interface{}, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		i, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("argument %v invalid: integer %v out of range",
+				argName, v)
+		}
+		return i, nil
+	case starlark.String:
+		return string(v), nil
+	case *values.Label:
+		return v.Label(), nil
+	case *starlark.List, starlark.Tuple:
+		elems := v.(starlark.Indexable)
+		if rv, ok := stringElems(elems); ok {
+			return rv, nil
+		}
+		ints := make([]int64, elems.Len())
+		labels := make([]core.Label, elems.Len())
+		isInts, isLabels := true, true
+		for i := 0; i < elems.Len(); i++ {
+			if x, ok := elems.Index(i).(starlark.Int); !ok {
+				isInts = false
+			} else if ints[i], ok = x.Int64(); !ok {
+				isInts = false
+			}
+			if x, ok := elems.Index(i).(*values.Label); !ok {
+				isLabels = false
+			} else {
+				labels[i] = x.Label()
+			}
+		}
+		if isInts {
+			return ints, nil
+		}
+		if isLabels {
+			return labels, nil
+		}
+	case *starlark.Dict:
+		strs := make(map[string]string, v.Len())
+		strLists := make(map[string][]string, v.Len())
+		for _, item := range v.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				strs, strLists = nil, nil
+				break
+			}
+			if s, ok := item[1].(starlark.String); ok && strs != nil {
+				strs[string(k)] = string(s)
+			} else {
+				strs = nil
+			}
+			if l, ok := item[1].(starlark.Indexable); ok && strLists != nil {
+				if _, isString := l.(starlark.String); !isString {
+					if elems, ok := stringElems(l); ok {
+						strLists[string(k)] = elems
+						continue
+					}
+				}
+			}
+			strLists = nil
+		}
+		if strs != nil {
+			return strs, nil
+		}
+		if strLists != nil {
+			return strLists, nil
+		}
+	}
+	value.Freeze()
+	return value, nil
+}
+
+// GenericRuleTarget is a target of a native rule that we don't model (yet). Its common attributes
+// (those of TargetCommon and, for test rules, TargetCommonTest) are processed as usual; all its
+// other attributes are recorded (see Attr and AttrConfigurable), converted to Go values according
+// to genericAttrTypes or else as inferred from their starlark values (see inferGenericValue).
+type GenericRuleTarget struct {
+	TargetCommon
+	TargetCommonTest
+
+	kind string
+
+	// attrNames are the names of the other attributes (in the order given).
+	attrNames []string
+
+	// attrs are the (converted) values of the other attributes (nil if None), keyed by
+	// attribute name.
+	attrs map[string]interface{}
+
+	// attrConfigurables contains the unresolved values of the other attributes that were given
+	// using select(), keyed by attribute name.
+	attrConfigurables map[string]*core.Configurable
+}
+
+var _ builtins_args.DynamicArgsTarget = (*GenericRuleTarget)(nil)
+
+// isTestKind returns whether the given rule is a test rule (by Bazel's naming convention).
+func isTestKind(kind string) bool {
+	return strings.HasSuffix(kind, "_test")
+}
+
+// convertGenericArg converts a single (non-select()) value of an attribute of a GenericRuleTarget.
+func convertGenericArg(argName string, value starlark.Value, ctx core.Context) (interface{},
+	error) {
+
+	if attrType, ok := genericAttrTypes[argName]; ok {
+		return builtins_args.ConvertArg(argName, value, ctx, attrDestTypes[attrType])
+	}
+	return inferGenericValue(argName, value)
+}
+
+// setAttr sets the value of an attribute (other than a common attribute) from an argument.
+func (self *GenericRuleTarget) setAttr(ctx core.Context, argName string,
+	value starlark.Value) error {
+
+	self.attrNames = append(self.attrNames, argName)
+	sel, ok := value.(*values.Select)
+	if !ok {
+		v, err := convertGenericArg(argName, value, ctx)
+		if err != nil {
+			return err
+		}
+		self.attrs[argName] = v
+		return nil
+	}
+
+	configurable, err := builtins_args.ConvertConfigurableArgWith(argName, sel, ctx,
+		func(v starlark.Value) (interface{}, error) {
+			return convertGenericArg(argName, v, ctx)
+		})
+	if err != nil {
+		return err
+	}
+	if self.attrConfigurables == nil {
+		self.attrConfigurables = make(map[string]*core.Configurable)
+	}
+	self.attrConfigurables[argName] = configurable
+	return nil
+}
+
+// DidProcessArgs does nothing, since the arguments other than the common ones aren't checked.
+func (self *GenericRuleTarget) DidProcessArgs(ctx core.Context) error {
+	return nil
+}
+
+// Attr returns the value of the given attribute (other than a common attribute), which is nil if
+// None or if it was given using select() and is unresolved, and whether it was given.
+func (self *GenericRuleTarget) Attr(attrName string) (interface{}, bool) {
+	if value, ok := self.attrs[attrName]; ok {
+		return value, true
+	}
+	return nil, self.attrConfigurables[attrName] != nil
+}
+
+// AttrNames returns the names of the target's attributes other than its common attributes (in
+// the order given).
+func (self *GenericRuleTarget) AttrNames() []string {
+	return self.attrNames
+}
+
+// AttrConfigurable returns the unresolved value of the given attribute (other than a common
+// attribute) if it was given using select(), or nil otherwise.
+func (self *GenericRuleTarget) AttrConfigurable(attrName string) *core.Configurable {
+	return self.attrConfigurables[attrName]
+}
+
+func (self *GenericRuleTarget) Configurables() map[string]*core.Configurable {
+	if len(self.attrConfigurables) == 0 {
+		return self.TargetCommon.Configurables()
+	}
+	rv := make(map[string]*core.Configurable)
+	for argName, configurable := range self.TargetCommon.Configurables() {
+		rv[argName] = configurable
+	}
+	for argName, configurable := range self.attrConfigurables {
+		rv[argName] = configurable
+	}
+	return rv
+}
+
+func (self *GenericRuleTarget) SetDynamicArg(argName string, value interface{}) (bool, error) {
+	if self.attrConfigurables[argName] == nil {
+		return false, nil
+	}
+	self.attrs[argName] = value
+	delete(self.attrConfigurables, argName)
+	return true, nil
+}
+
+// SetResolvedArg sets the field for the given common argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget). Its other arguments are dynamic (see SetDynamicArg).
+func (self *GenericRuleTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	return self.TargetCommonTest.setGroupResolvedArg(argName, value)
+}
+
+func (self *GenericRuleTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	if err := self.TargetCommonTest.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+func (self *GenericRuleTarget) forEachExtraAttr(fn func(argName string, value interface{},
+	configurable *core.Configurable)) {
+
+	for _, attrName := range self.attrNames {
+		if value := self.attrs[attrName]; value != nil {
+			fn(attrName, value, nil)
+		} else if configurable := self.attrConfigurables[attrName]; configurable != nil {
+			fn(attrName, nil, configurable)
+		}
+	}
+}
+
+func (self *GenericRuleTarget) Kind() string {
+	return self.kind
+}
+
+func (self *GenericRuleTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *GenericRuleTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// instantiateGeneric instantiates a GenericRuleTarget of the given rule.
+func instantiateGeneric(ruleName string, ctx core.Context, args starlark.Tuple,
+	kwargs []starlark.Tuple) (core.Target, error) {
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("all arguments should be passed as keyword arguments")
+	}
+
+	target := &GenericRuleTarget{kind: ruleName, attrs: make(map[string]interface{})}
+
+	// Process the arguments other than the common ones, leaving those to ProcessArgs.
+	commonKwargs := []starlark.Tuple{}
+	for _, kwarg := range kwargs {
+		argName := string(kwarg[0].(starlark.String))
+		if isGroupAttr(reflect.TypeOf(TargetCommon{}), argName) || (isTestKind(ruleName) &&
+			isGroupAttr(reflect.TypeOf(TargetCommonTest{}), argName)) {
+			commonKwargs = append(commonKwargs, kwarg)
+			continue
+		}
+		if err := target.setAttr(ctx, argName, kwarg[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := builtins_args.ProcessArgs(nil, commonKwargs, ctx, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// GenericRule is used for native Bazel rules that we don't model (yet), so that their targets are
+// still part of the build (as GenericRuleTargets). Since such targets aren't converted, calls are
+// also recorded (see core.RecordNotImplementedCall).
+func GenericRule(ruleName string) *starlark.Builtin {
+	return starlark.NewBuiltin(ruleName, func(thread *starlark.Thread, _ *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		if err := core.RecordNotImplementedCall(thread, ruleName); err != nil {
+			return starlark.None, err
+		}
+		return callRule(thread, ruleName, args, kwargs,
+			func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (
+				core.Target, error) {
+
+				return instantiateGeneric(ruleName, ctx, args, kwargs)
+			})
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"fmt"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+var _ core.GeneratingTarget = (*GenruleTarget)(nil)
+
+func (self *GenruleTarget) DidProcessArgs(ctx core.Context) error {
+	if len(self.GetOuts()) == 0 {
+		return fmt.Errorf("argument outs invalid: must be non-empty")
+	}
+	if self.Executable != nil && *self.Executable && len(self.GetOuts()) != 1 {
+		return fmt.Errorf("argument outs invalid: must contain exactly one output if " +
+			"executable is set")
+	}
+
+	hasCmd := false
+	for _, argName := range []string{"cmd", "cmd_bash", "cmd_bat", "cmd_ps"} {
+		if self.Configurable(argName) != nil {
+			hasCmd = true
+		}
+	}
+	if self.Cmd != nil || self.CmdBash != nil || self.CmdBat != nil || self.CmdPs != nil {
+		hasCmd = true
+	}
+	if !hasCmd {
+		return fmt.Errorf("one of cmd, cmd_bash, cmd_bat, or cmd_ps must be given")
+	}
+	return nil
+}
+
+// OutputLabels returns the labels of the genrule's outs (nil if unset).
+func (self *GenruleTarget) OutputLabels() []core.Label {
+	return self.GetOuts()
+}
@@ -0,0 +1,401 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Command genrules generates the target structs for the native rules, and the code that binds,
+// sets the resolved values of, iterates over, and marshals their attributes, from the schemas in
+// builtins/rules/schema. It is run by "go generate" in builtins/rules.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"strings"
+	"text/template"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules/schema"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+)
+
+// maxLineLength is the maximum length of comment lines (with tabs counting as 8 columns).
+const maxLineLength = 100
+
+// goTypes maps attribute types to the (value) types of the corresponding fields.
+var goTypes = map[values.AttrType]string{
+	values.AttrTypeBool:                 "bool",
+	values.AttrTypeInt:                  "int64",
+	values.AttrTypeIntList:              "[]int64",
+	values.AttrTypeLabel:                "core.Label",
+	values.AttrTypeLabelKeyedStringDict: "map[core.Label]string",
+	values.AttrTypeLabelList:            "[]core.Label",
+	values.AttrTypeLicense:              "[]string",
+	values.AttrTypeOutput:               "core.Label",
+	values.AttrTypeOutputList:           "[]core.Label",
+	values.AttrTypeString:               "string",
+	values.AttrTypeStringDict:           "map[string]string",
+	values.AttrTypeStringList:           "[]string",
+	values.AttrTypeStringListDict:       "map[string][]string",
+}
+
+// camelCase converts a snake-cased name (e.g., "cc_library") to its camel-cased form (e.g.,
+// "CcLibrary").
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// goType returns the (value) type of the field for the given attribute.
+func goType(attr schema.Attr) string {
+	typ, ok := goTypes[attr.Type]
+	if !ok {
+		log.Fatalf("attribute %v has unsupported type %v", attr.Name, attr.Type)
+	}
+	return typ
+}
+
+// tag returns the "bazel" field tag for the given attribute (see builtins_args.ParseTag).
+func tag(attr schema.Attr) string {
+	rv := attr.Name
+	if attr.Mandatory {
+		rv += "!"
+	}
+	if attr.Nonconfigurable {
+		rv += ",nonconfigurable"
+	}
+	switch attr.Type {
+	case values.AttrTypeOutput, values.AttrTypeOutputList:
+		rv += ",output"
+	case values.AttrTypeLicense:
+		rv += ",license"
+	}
+	return rv
+}
+
+// defaultValue returns the default value of the given attribute, as a Go expression.
+func defaultValue(attr schema.Attr) string {
+	if attr.Default != "" {
+		return attr.Default
+	}
+	switch goType(attr) {
+	case "bool":
+		return "false"
+	case "int64":
+		return "0"
+	case "string":
+		return `""`
+	case "core.Label":
+		return "core.Label{}"
+	default:
+		return "nil"
+	}
+}
+
+// comment formats text as a comment (wrapped as needed) at the given indentation level.
+func comment(indent int, text string) string {
+	prefix := strings.Repeat("\t", indent) + "//"
+	lines := []string{}
+	line := prefix
+	for _, word := range strings.Fields(text) {
+		if line != prefix && len(line)+7*indent+1+len(word) > maxLineLength {
+			lines = append(lines, line)
+			line = prefix
+		}
+		line += " " + word
+	}
+	lines = append(lines, line)
+	return strings.Join(lines, "\n")
+}
+
+// fieldComment returns the comment for the field for the given attribute.
+func fieldComment(attr schema.Attr) string {
+	return comment(1, fmt.Sprintf("%v is the value of %v, or nil if unset: %v.",
+		camelCase(attr.Name), attr.Name, attr.Doc))
+}
+
+// getterComment returns the comment for the getter for the given attribute.
+func getterComment(attr schema.Attr) string {
+	return comment(0, fmt.Sprintf("Get%v returns the value of %v, or its default if it is "+
+		"unset (or given using select() and unresolved).", camelCase(attr.Name), attr.Name))
+}
+
+// configurableAttrs returns the given attributes that may be given using select().
+func configurableAttrs(attrs []schema.Attr) []schema.Attr {
+	rv := []schema.Attr{}
+	for _, attr := range attrs {
+		if !attr.Nonconfigurable {
+			rv = append(rv, attr)
+		}
+	}
+	return rv
+}
+
+var funcs = template.FuncMap{
+	"camelCase":         camelCase,
+	"configurableAttrs": configurableAttrs,
+	"goType":            goType,
+	"tag":               tag,
+	"defaultValue":      defaultValue,
+	"comment":           comment,
+	"fieldComment":      fieldComment,
+	"getterComment":     getterComment,
+	"printf":            fmt.Sprintf,
+}
+
+const fileTemplate = `// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Code generated by genrules from the schemas in builtins/rules/schema. DO NOT EDIT.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"go.starlark.net/starlark"
+
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+{{range .Groups}}{{$group := .}}
+{{comment 0 (printf "%v contains %v." .Name .Doc)}}
+type {{.Name}} struct {
+{{- range .Embeds}}
+	{{.}}
+{{end}}
+{{- range .Attrs}}
+{{fieldComment .}}
+	{{camelCase .Name}} *{{goType .}} ` + "`" + `bazel:"{{tag .}}"` + "`" + `
+{{end -}}
+}
+
+var _ builtins_args.ProcessArgsTarget = (*{{.Name}})(nil)
+
+// bindGroupArgs binds the group's arguments (see builtins_args.BindArg) and then calls
+// DidProcessArgs.
+func (self *{{.Name}}) bindGroupArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+{{range .Attrs}}
+	if err := builtins_args.BindArg(kwargs, "{{tag .}}", ctx,
+		configurableTarget, &self.{{camelCase .Name}}); err != nil {
+		return err
+	}
+{{- end}}
+	return self.DidProcessArgs(ctx)
+}
+
+// setGroupResolvedArg sets the field for the given argument to its resolved value if it is one of
+// the group's configurable arguments, returning the field's tag (see SetResolvedArg).
+func (self *{{.Name}}) setGroupResolvedArg(argName string, value interface{}) string {
+{{- if configurableAttrs .Attrs}}
+	switch argName {
+{{- range configurableAttrs .Attrs}}{{template "setResolvedArg" .}}{{end}}
+	}
+{{- end}}
+	return ""
+}
+
+// forEachGroupAttr calls fn for each of the group's attributes that is set (see forEachAttr).
+func (self *{{.Name}}) forEachGroupAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+{{range .Attrs}}
+	if self.{{camelCase .Name}} != nil {
+		fn("{{.Name}}", *self.{{camelCase .Name}}, nil)
+	} else {
+		visitConfigurable(configurableTarget, "{{.Name}}", fn)
+	}
+{{- end}}
+}
+{{range .Attrs}}
+{{getterComment .}}
+func (self *{{$group.Name}}) Get{{camelCase .Name}}() {{goType .}} {
+	if self.{{camelCase .Name}} != nil {
+		return *self.{{camelCase .Name}}
+	}
+	return {{defaultValue .}}
+}
+{{end}}{{end}}
+{{- range .Rules}}{{$target := printf "%vTarget" (camelCase .Name)}}{{$rule := .}}
+{{comment 0 (printf "%v is a target of the %v rule (%v)." $target .Name .Doc)}}
+type {{$target}} struct {
+{{- range .Groups}}
+	{{.}}
+{{- end}}
+{{range .Attrs}}
+{{fieldComment .}}
+	{{camelCase .Name}} *{{goType .}} ` + "`" + `bazel:"{{tag .}}"` + "`" + `
+{{end -}}
+}
+
+var _ builtins_args.ArgsBinder = (*{{$target}})(nil)
+var _ builtins_args.ResolvedArgsTarget = (*{{$target}})(nil)
+var _ core.Target = (*{{$target}})(nil)
+
+// BindArgs binds the target's arguments (see builtins_args.ArgsBinder).
+func (self *{{$target}}) BindArgs(kwargs map[string]starlark.Value, ctx core.Context,
+	configurableTarget builtins_args.ConfigurableArgsTarget) error {
+{{range .Groups}}
+	if err := self.{{.}}.bindGroupArgs(kwargs, ctx,
+		configurableTarget); err != nil {
+		return err
+	}
+{{- end}}
+{{- range .Attrs}}
+	if err := builtins_args.BindArg(kwargs, "{{tag .}}", ctx,
+		configurableTarget, &self.{{camelCase .Name}}); err != nil {
+		return err
+	}
+{{- end}}
+	return self.DidProcessArgs(ctx)
+}
+
+// SetResolvedArg sets the field for the given argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget).
+func (self *{{$target}}) SetResolvedArg(argName string, value interface{}) string {
+{{- range .Groups}}
+	if tag := self.{{.}}.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+{{- end}}
+{{- if configurableAttrs .Attrs}}
+	switch argName {
+{{- range configurableAttrs .Attrs}}{{template "setResolvedArg" .}}{{end}}
+	}
+{{- end}}
+	return ""
+}
+
+// DidResolveArgs calls DidProcessArgs for the target's argument groups and then for the target
+// itself (see builtins_args.ResolvedArgsTarget).
+func (self *{{$target}}) DidResolveArgs(ctx core.Context) error {
+{{- range .Groups}}
+	if err := self.{{.}}.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+{{- end}}
+	return self.DidProcessArgs(ctx)
+}
+
+// forEachNativeAttr calls fn for each of the target's attributes that is set (see forEachAttr).
+func (self *{{$target}}) forEachNativeAttr(configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+{{range .Groups}}
+	self.{{.}}.forEachGroupAttr(configurableTarget, fn)
+{{- end}}
+{{- range .Attrs}}
+	if self.{{camelCase .Name}} != nil {
+		fn("{{.Name}}", *self.{{camelCase .Name}}, nil)
+	} else {
+		visitConfigurable(configurableTarget, "{{.Name}}", fn)
+	}
+{{- end}}
+}
+{{range .Attrs}}
+{{getterComment .}}
+func (self *{{$target}}) Get{{camelCase .Name}}() {{goType .}} {
+	if self.{{camelCase .Name}} != nil {
+		return *self.{{camelCase .Name}}
+	}
+	return {{defaultValue .}}
+}
+{{end}}
+func (self *{{$target}}) Kind() string {
+	return "{{.Name}}"
+}
+
+func (self *{{$target}}) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *{{$target}}) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
+
+// {{camelCase .Name}} implements the Bazel {{.Name}} rule.
+var {{camelCase .Name}} = NewRule("{{.Name}}",
+	func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (core.Target,
+		error) {
+
+		target := &{{$target}}{}
+		if err := builtins_args.ProcessArgs(args, kwargs, ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+{{end}}
+{{- define "setResolvedArg"}}
+	case "{{.Name}}":
+		if value == nil {
+			self.{{camelCase .Name}} = nil
+		} else {
+			v := value.({{goType .}})
+			self.{{camelCase .Name}} = &v
+		}
+		return "{{tag .}}"
+{{- end}}`
+
+// checkSchemas checks that no rule has two attributes with the same name (including those of its
+// groups).
+func checkSchemas() error {
+	groups := map[string]*schema.Group{}
+	for _, group := range schema.Groups {
+		groups[group.Name] = group
+	}
+	for _, rule := range schema.Rules {
+		attrs := []schema.Attr{}
+		for _, groupName := range rule.Groups {
+			attrs = append(attrs, groups[groupName].Attrs...)
+		}
+		attrs = append(attrs, rule.Attrs...)
+		seen := map[string]bool{}
+		for _, attr := range attrs {
+			if seen[attr.Name] {
+				return fmt.Errorf("rule %v has duplicate attribute %v", rule.Name,
+					attr.Name)
+			}
+			seen[attr.Name] = true
+		}
+	}
+	return nil
+}
+
+// generate returns the (formatted) generated source.
+func generate() ([]byte, error) {
+	if err := checkSchemas(); err != nil {
+		return nil, err
+	}
+
+	tmpl := template.Must(template.New("file").Funcs(funcs).Parse(fileTemplate))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Groups []*schema.Group
+		Rules  []*schema.Rule
+	}{schema.Groups, schema.Rules}); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+func main() {
+	output := flag.String("o", "generated_rules.go", "output file")
+	flag.Parse()
+
+	src, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
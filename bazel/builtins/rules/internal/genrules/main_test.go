@@ -0,0 +1,24 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGeneratedRulesUpToDate(t *testing.T) {
+	expected, err := generate()
+	if err != nil {
+		t.Fatalf("generate() failed: %v", err)
+	}
+	actual, err := ioutil.ReadFile("../../generated_rules.go")
+	if err != nil {
+		t.Fatalf("failed to read generated_rules.go: %v", err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("generated_rules.go is out of date; run \"go generate\" in builtins/rules")
+	}
+}
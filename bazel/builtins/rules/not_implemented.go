@@ -9,11 +9,20 @@ import (
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-// NotImplemented is used for Bazel rules that we haven't implemented (yet).
+// NotImplemented is used for Bazel rules that we haven't implemented (yet). Calls are recorded (see
+// core.RecordNotImplementedCall).
 func NotImplemented(ruleName string) *starlark.Builtin {
-	return newRule(ruleName,
-		func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) error {
+	return starlark.NewBuiltin(ruleName, func(thread *starlark.Thread, _ *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
-			return nil
-		})
+		if err := core.RecordNotImplementedCall(thread, ruleName); err != nil {
+			return starlark.None, err
+		}
+		return callRule(thread, ruleName, args, kwargs,
+			func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) (
+				core.Target, error) {
+
+				return nil, nil
+			})
+	})
 }
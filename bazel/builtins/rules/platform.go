@@ -0,0 +1,17 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"fmt"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func (self *PlatformTarget) DidProcessArgs(ctx core.Context) error {
+	if self.Parents != nil && len(*self.Parents) > 1 {
+		return fmt.Errorf("parents attribute must have a single value")
+	}
+	return nil
+}
@@ -5,6 +5,9 @@
 // files).
 package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
 
+// The target structs for the native rules are generated from their schemas (in schema).
+//go:generate go run ./internal/genrules -o generated_rules.go
+
 import (
 	"fmt"
 
@@ -13,26 +16,131 @@ import (
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-// newRule creates a new rule(-like) starlark.Builtin.
-func newRule(ruleName string, impl func(ctx core.Context, args starlark.Tuple,
-	kwargs []starlark.Tuple) error) *starlark.Builtin {
+// provenanceTarget is implemented by targets whose provenance can be set.
+type provenanceTarget interface {
+	SetProvenance(provenance *core.Provenance)
+}
 
-	return starlark.NewBuiltin(ruleName, func(thread *starlark.Thread, _ *starlark.Builtin,
-		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+// packageDefaultsTarget is implemented by targets to which package defaults (from package())
+// apply.
+type packageDefaultsTarget interface {
+	ApplyPackageDefaults(metadata *core.PackageMetadata)
+}
+
+// macroNameArg returns the value of the name parameter of the function (macro) at the given depth
+// of the thread's call stack, if it has one (and it's a string).
+func macroNameArg(thread *starlark.Thread, depth int) (string, bool) {
+	frame := thread.DebugFrame(depth)
+	fn, ok := frame.Callable().(*starlark.Function)
+	if !ok {
+		return "", false
+	}
+	for i := 0; i < fn.NumParams(); i++ {
+		if paramName, _ := fn.Param(i); paramName == "name" {
+			name, ok := frame.Local(i).(starlark.String)
+			return string(name), ok
+		}
+	}
+	return "", false
+}
+
+// getProvenance determines the provenance of a target (with the given name) being instantiated
+// by a rule called on the given thread.
+func getProvenance(thread *starlark.Thread, targetName core.TargetName) *core.Provenance {
+	// Drop the frame for the rule itself.
+	callStack := thread.CallStack()
+	callStack = callStack[:len(callStack)-1]
+
+	rv := &core.Provenance{CallStack: callStack}
+	if len(callStack) > 1 {
+		rv.GeneratorFunction = callStack[1].Name
+		rv.GeneratorLocation = callStack[0].Pos.String()
+		// The macro called from the BUILD file is at depth len(callStack) - 1 (depth 0
+		// being the rule itself).
+		if name, ok := macroNameArg(thread, len(callStack)-1); ok {
+			rv.GeneratorName = name
+		} else {
+			rv.GeneratorName = string(targetName)
+		}
+	}
+	return rv
+}
 
-		ctx := core.GetContext(thread)
+// describeRuleCall describes a call to a rule for error messages, giving the rule and (if known)
+// the label of the target being instantiated, preceded by the location of the call in the BUILD
+// file if it was made from a macro (e.g., "//foo:BUILD:3:11: in cc_library rule //foo:bar"). The
+// location of a call made directly from the BUILD file is omitted, since it is already given by
+// the innermost frame of the backtrace.
+func describeRuleCall(thread *starlark.Thread, ctx core.Context, ruleName string,
+	kwargs []starlark.Tuple) string {
 
-		if ctx.FileType() != core.FileTypeBuild {
-			return starlark.None, fmt.Errorf(
-				"%v: %v: rule can only be called from a BUILD[.bazel] file",
-				ctx.Label(), ruleName)
+	prefix := ctx.Label().String() + ": "
+	if callStack := thread.CallStack(); len(callStack) == 2 {
+		prefix = ""
+	} else if len(callStack) > 2 {
+		prefix = callStack[0].Pos.String() + ": "
+	}
+	for _, kwarg := range kwargs {
+		if string(kwarg[0].(starlark.String)) != "name" {
+			continue
 		}
+		if name, ok := kwarg[1].(starlark.String); ok {
+			label := core.Label{
+				Workspace: ctx.Label().Workspace,
+				Package:   ctx.Label().Package,
+				Target:    core.TargetName(name),
+			}
+			if label.IsValid() {
+				return fmt.Sprintf("%vin %v rule %v", prefix, ruleName, label)
+			}
+		}
+	}
+	return fmt.Sprintf("%vin %v rule", prefix, ruleName)
+}
+
+// callRule calls a rule(-like) implementation on the given thread, and adds the target that it
+// instantiates (if any) to the build (with its provenance and package defaults).
+func callRule(thread *starlark.Thread, ruleName string, args starlark.Tuple,
+	kwargs []starlark.Tuple, impl func(ctx core.Context, args starlark.Tuple,
+		kwargs []starlark.Tuple) (core.Target, error)) (starlark.Value, error) {
 
-		err := impl(ctx, args, kwargs)
-		if err != nil {
-			return starlark.None, fmt.Errorf("%v: %v: %v", ctx.Label(), ruleName, err)
+	ctx := core.GetContext(thread)
+
+	if ctx.FileType() != core.FileTypeBuild {
+		return starlark.None, fmt.Errorf(
+			"%v: %v: rule can only be called from a BUILD[.bazel] file",
+			ctx.Label(), ruleName)
+	}
+
+	target, err := impl(ctx, args, kwargs)
+	if err == nil && target != nil {
+		if t, ok := target.(provenanceTarget); ok {
+			t.SetProvenance(getProvenance(thread, target.Label().Target))
+		}
+		label := ctx.Label()
+		metadata := ctx.BuildTargets()[label.Workspace][label.Package].Metadata
+		if t, ok := target.(packageDefaultsTarget); ok && metadata != nil {
+			t.ApplyPackageDefaults(metadata)
 		}
+		err = ctx.BuildTargets().Add(target)
+	}
+	if err != nil {
+		return starlark.None, fmt.Errorf("%v: %v",
+			describeRuleCall(thread, ctx, ruleName, kwargs), err)
+	}
+
+	return starlark.None, nil
+}
+
+// NewRule creates a new rule(-like) starlark.Builtin. impl should return the target that it
+// instantiates (if any), which is then added to the build (with its provenance). It may be used to
+// implement native rules outside this package (see builtins.Registry).
+func NewRule(ruleName string, impl func(ctx core.Context, args starlark.Tuple,
+	kwargs []starlark.Tuple) (core.Target, error)) *starlark.Builtin {
+
+	return starlark.NewBuiltin(ruleName, func(thread *starlark.Thread, _ *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
-		return starlark.None, nil
+		return callRule(thread, ruleName, args, kwargs, impl)
 	})
 }
@@ -0,0 +1,334 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package schema contains the declarative schemas of the native rules (and of the groups of
+// attributes common to several rules). The target structs for the rules, and the code that binds,
+// iterates over, and marshals their attributes, are generated from these (see
+// builtins/rules/internal/genrules); after changing them, run "go generate" in builtins/rules.
+package schema // import "src.tricot.io/public/bazel2x/bazel/builtins/rules/schema"
+
+import (
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+)
+
+// Attr is the schema of an attribute of a native rule (or of a group of attributes).
+type Attr struct {
+	// Name is the attribute's name (e.g., "strip_include_prefix"). The corresponding field's
+	// name is its camel-cased form (e.g., "StripIncludePrefix").
+	Name string
+
+	// Type is the attribute's type, which determines the field's type (e.g., label_list
+	// attributes have fields of type *[]core.Label). Output (and output_list) attributes must
+	// refer to files in the current package and license attributes must contain license types.
+	Type values.AttrType
+
+	// Default is the attribute's default value, as a Go expression of the attribute's (value)
+	// type (e.g., "true" or "-1"), or empty if the default is the zero value (nil for lists and
+	// dicts) or is determined elsewhere (e.g., by package()).
+	Default string
+
+	// Mandatory indicates that the attribute must be given.
+	Mandatory bool
+
+	// Nonconfigurable indicates that the attribute's value may not be given using select().
+	Nonconfigurable bool
+
+	// Doc is the attribute's documentation (a sentence fragment, e.g., "the source files").
+	Doc string
+}
+
+// Group is the schema of a group of attributes that is common to several rules (whose target
+// structs embed the group's struct).
+type Group struct {
+	// Name is the name of the group's struct (e.g., "TargetCommon").
+	Name string
+
+	// Doc is the group's documentation (a sentence fragment, e.g., "the attributes common to
+	// all rules").
+	Doc string
+
+	// Embeds are the names of (handwritten) structs that the group's struct embeds, e.g., for
+	// state that doesn't correspond to attributes.
+	Embeds []string
+
+	Attrs []Attr
+}
+
+// Rule is the schema of a native rule.
+type Rule struct {
+	// Name is the rule's name (e.g., "cc_library"). The name of the target struct is its
+	// camel-cased form suffixed with "Target" (e.g., "CcLibraryTarget"), and the name of the
+	// rule itself (a starlark.Builtin) is its camel-cased form (e.g., "CcLibrary").
+	Name string
+
+	// Doc is the rule's documentation (a sentence fragment, e.g., "a C++ library").
+	Doc string
+
+	// Groups are the names of the groups of attributes that the rule has (in order).
+	Groups []string
+
+	Attrs []Attr
+}
+
+// Groups are the groups of attributes that are common to several rules.
+var Groups = []*Group{
+	{
+		Name:   "TargetCommon",
+		Doc:    "the attributes common to all rules",
+		Embeds: []string{"targetCommonState"},
+		Attrs: []Attr{
+			{Name: "name", Type: values.AttrTypeString, Mandatory: true,
+				Nonconfigurable: true, Doc: "the target's name"},
+			{Name: "data", Type: values.AttrTypeLabelList,
+				Doc: "the files needed at runtime"},
+			{Name: "visibility", Type: values.AttrTypeLabelList, Nonconfigurable: true,
+				Doc: "the visibility of the target"},
+			{Name: "toolchains", Type: values.AttrTypeLabelList,
+				Doc: "the targets whose Make variables the target may access"},
+			{Name: "deps", Type: values.AttrTypeLabelList,
+				Doc: "the dependencies of the target"},
+			{Name: "deprecation", Type: values.AttrTypeString,
+				Doc: "the deprecation warning for the target"},
+			{Name: "tags", Type: values.AttrTypeStringList, Nonconfigurable: true,
+				Doc: "the target's tags"},
+			{Name: "testonly", Type: values.AttrTypeBool, Nonconfigurable: true,
+				Doc: "whether only test targets may depend on the target"},
+			{Name: "features", Type: values.AttrTypeStringList,
+				Doc: "the features enabled (or, if prefixed by \"-\", disabled)"},
+			{Name: "licenses", Type: values.AttrTypeLicense, Nonconfigurable: true,
+				Doc: "the license types of the target"},
+			{Name: "compatible_with", Type: values.AttrTypeLabelList,
+				Nonconfigurable: true, Doc: "the environments the target can be " +
+					"built for"},
+			{Name: "distribs", Type: values.AttrTypeStringList, Nonconfigurable: true,
+				Doc: "the distribution methods of the target"},
+			{Name: "exec_compatible_with", Type: values.AttrTypeLabelList,
+				Nonconfigurable: true, Doc: "the constraints required of the " +
+					"execution platform"},
+			{Name: "restricted_to", Type: values.AttrTypeLabelList,
+				Nonconfigurable: true, Doc: "the only environments the target " +
+					"can be built for"},
+			{Name: "applicable_licenses", Type: values.AttrTypeLabelList,
+				Nonconfigurable: true, Doc: "the licenses that apply to the " +
+					"target"},
+			{Name: "exec_properties", Type: values.AttrTypeStringDict,
+				Doc: "the execution properties (added to the platform's)"},
+		},
+	},
+	{
+		Name: "TargetCommonTest",
+		Doc:  "the attributes common to all test rules",
+		Attrs: []Attr{
+			{Name: "args", Type: values.AttrTypeStringList,
+				Doc: "the command line arguments for the test"},
+			{Name: "size", Type: values.AttrTypeString, Default: `"medium"`,
+				Doc: "the test's size (small, medium, large, or enormous)"},
+			{Name: "timeout", Type: values.AttrTypeString,
+				Doc: "the test's timeout (short, moderate, long, or eternal)"},
+			{Name: "flaky", Type: values.AttrTypeBool,
+				Doc: "whether the test is flaky"},
+			{Name: "local", Type: values.AttrTypeBool,
+				Doc: "whether the test must be run locally (unsandboxed)"},
+			{Name: "shard_count", Type: values.AttrTypeInt,
+				Doc: "the number of shards to use to run the test"},
+		},
+	},
+	{
+		Name: "TargetCommonBinary",
+		Doc:  "the attributes common to all binary rules",
+		Attrs: []Attr{
+			{Name: "args", Type: values.AttrTypeStringList,
+				Doc: "the command line arguments for the binary (when run)"},
+			{Name: "output_licenses", Type: values.AttrTypeLicense,
+				Doc: "the licenses of the binary's outputs"},
+		},
+	},
+}
+
+// ccCommonAttrs are the attributes common to cc_binary and cc_test.
+var ccCommonAttrs = []Attr{
+	{Name: "srcs", Type: values.AttrTypeLabelList,
+		Doc: "the source (and private header) files"},
+	{Name: "additional_linker_inputs", Type: values.AttrTypeLabelList,
+		Doc: "the additional files to pass to the linker"},
+	{Name: "copts", Type: values.AttrTypeStringList, Doc: "the C++ compiler options"},
+	{Name: "defines", Type: values.AttrTypeStringList,
+		Doc: "the defines (also for dependents)"},
+	{Name: "includes", Type: values.AttrTypeStringList,
+		Doc: "the include directories (relative to the package)"},
+	{Name: "linkopts", Type: values.AttrTypeStringList, Doc: "the linker options"},
+}
+
+// ccCommonLinkAttrs returns the attributes (after linkstatic) common to cc_binary and cc_test,
+// which differ in the default for stamp.
+func ccCommonLinkAttrs(stampDefault string) []Attr {
+	return []Attr{
+		{Name: "malloc", Type: values.AttrTypeLabel,
+			Doc: "the malloc implementation to use"},
+		{Name: "nocopts", Type: values.AttrTypeStringList,
+			Doc: "the (regular expressions for) C++ compiler options to remove"},
+		{Name: "stamp", Type: values.AttrTypeInt, Default: stampDefault,
+			Doc: "whether to stamp build information into the binary (1, 0, or -1 " +
+				"to only do so if --stamp is given)"},
+		{Name: "win_def_file", Type: values.AttrTypeLabel,
+			Doc: "the Windows DEF file to pass to the linker"},
+	}
+}
+
+// concatAttrs concatenates lists of attributes.
+func concatAttrs(attrLists ...[]Attr) []Attr {
+	rv := []Attr{}
+	for _, attrs := range attrLists {
+		rv = append(rv, attrs...)
+	}
+	return rv
+}
+
+// Rules are the native rules.
+var Rules = []*Rule{
+	// C / C++ Rules
+	{
+		Name:   "cc_binary",
+		Doc:    "a C++ binary",
+		Groups: []string{"TargetCommon", "TargetCommonBinary"},
+		Attrs: concatAttrs(ccCommonAttrs, []Attr{
+			{Name: "linkshared", Type: values.AttrTypeBool,
+				Doc: "whether to create a shared library"},
+			{Name: "linkstatic", Type: values.AttrTypeBool, Default: "true",
+				Doc: "whether to link statically"},
+		}, ccCommonLinkAttrs("-1")),
+	},
+	{
+		Name:   "cc_library",
+		Doc:    "a C++ library",
+		Groups: []string{"TargetCommon"},
+		Attrs: []Attr{
+			{Name: "srcs", Type: values.AttrTypeLabelList,
+				Doc: "the source (and private header) files"},
+			{Name: "hdrs", Type: values.AttrTypeLabelList,
+				Doc: "the public header files"},
+			{Name: "alwayslink", Type: values.AttrTypeBool,
+				Doc: "whether to always link all the library's code"},
+			{Name: "copts", Type: values.AttrTypeStringList,
+				Doc: "the C++ compiler options"},
+			{Name: "defines", Type: values.AttrTypeStringList,
+				Doc: "the defines (also for dependents)"},
+			{Name: "include_prefix", Type: values.AttrTypeString,
+				Doc: "the prefix to add to the paths of the headers"},
+			{Name: "includes", Type: values.AttrTypeStringList,
+				Doc: "the include directories (relative to the package)"},
+			{Name: "linkopts", Type: values.AttrTypeStringList,
+				Doc: "the linker options (also for dependents)"},
+			{Name: "linkstatic", Type: values.AttrTypeBool,
+				Doc: "whether to only build the static library"},
+			{Name: "nocopts", Type: values.AttrTypeStringList,
+				Doc: "the (regexps for) C++ compiler options to remove"},
+			{Name: "strip_include_prefix", Type: values.AttrTypeString,
+				Doc: "the prefix to strip from the paths of the headers"},
+			{Name: "textual_hdrs", Type: values.AttrTypeLabelList,
+				Doc: "the header files that can't be compiled on their own"},
+			{Name: "win_def_file", Type: values.AttrTypeLabel,
+				Doc: "the Windows DEF file to pass to the linker"},
+		},
+	},
+	{
+		Name:   "cc_test",
+		Doc:    "a C++ test",
+		Groups: []string{"TargetCommon", "TargetCommonTest"},
+		Attrs: concatAttrs(ccCommonAttrs, []Attr{
+			{Name: "linkstatic", Type: values.AttrTypeBool,
+				Doc: "whether to link statically"},
+		}, ccCommonLinkAttrs("0"), []Attr{
+			{Name: "env", Type: values.AttrTypeStringDict,
+				Doc: "the environment variables to set when the test is run"},
+			{Name: "env_inherit", Type: values.AttrTypeStringList,
+				Doc: "the environment variables to inherit when the test is run"},
+		}),
+	},
+
+	// General Rules
+	{
+		Name:   "genrule",
+		Doc:    "a general rule that generates files using a shell command",
+		Groups: []string{"TargetCommon"},
+		Attrs: []Attr{
+			{Name: "srcs", Type: values.AttrTypeLabelList, Doc: "the input files"},
+			{Name: "outs", Type: values.AttrTypeOutputList, Mandatory: true,
+				Nonconfigurable: true, Doc: "the output files"},
+			{Name: "cmd", Type: values.AttrTypeString, Doc: "the command to run"},
+			{Name: "cmd_bash", Type: values.AttrTypeString,
+				Doc: "the command to run using Bash (overriding cmd)"},
+			{Name: "cmd_bat", Type: values.AttrTypeString,
+				Doc: "the command to run using cmd.exe (overriding cmd)"},
+			{Name: "cmd_ps", Type: values.AttrTypeString,
+				Doc: "the command to run using PowerShell (overriding cmd)"},
+			{Name: "tools", Type: values.AttrTypeLabelList,
+				Doc: "the tools used by the command (built for the host)"},
+			{Name: "exec_tools", Type: values.AttrTypeLabelList,
+				Doc: "the tools used by the command (built for execution)"},
+			{Name: "message", Type: values.AttrTypeString,
+				Doc: "the progress message to print"},
+			{Name: "output_licenses", Type: values.AttrTypeLicense,
+				Doc: "the licenses of the outputs"},
+			{Name: "output_to_bindir", Type: values.AttrTypeBool, Nonconfigurable: true,
+				Doc: "whether to put the outputs in bin (instead of genfiles)"},
+			{Name: "local", Type: values.AttrTypeBool,
+				Doc: "whether the command must be run locally (unsandboxed)"},
+			{Name: "executable", Type: values.AttrTypeBool, Nonconfigurable: true,
+				Doc: "whether the (single) output is executable"},
+			{Name: "stamp", Type: values.AttrTypeBool,
+				Doc: "whether the command may use build information"},
+		},
+	},
+
+	// Platform Rules
+	{
+		Name:   "config_setting",
+		Doc:    "a configuration condition (for select())",
+		Groups: []string{"TargetCommon"},
+		Attrs: []Attr{
+			{Name: "values", Type: values.AttrTypeStringDict, Nonconfigurable: true,
+				Doc: "the required flag values"},
+			{Name: "define_values", Type: values.AttrTypeStringDict,
+				Nonconfigurable: true, Doc: "the required --define values"},
+			{Name: "flag_values", Type: values.AttrTypeLabelKeyedStringDict,
+				Nonconfigurable: true, Doc: "the required custom flag values"},
+			{Name: "constraint_values", Type: values.AttrTypeLabelList,
+				Nonconfigurable: true, Doc: "the constraint values the target " +
+					"platform must have"},
+		},
+	},
+	{
+		Name:   "constraint_setting",
+		Doc:    "a constraint setting (a dimension along which platforms may differ)",
+		Groups: []string{"TargetCommon"},
+		Attrs: []Attr{
+			{Name: "default_constraint_value", Type: values.AttrTypeLabel,
+				Nonconfigurable: true, Doc: "the value for platforms that don't " +
+					"specify one"},
+		},
+	},
+	{
+		Name:   "constraint_value",
+		Doc:    "a value of a constraint setting",
+		Groups: []string{"TargetCommon"},
+		Attrs: []Attr{
+			{Name: "constraint_setting", Type: values.AttrTypeLabel, Mandatory: true,
+				Nonconfigurable: true, Doc: "the constraint setting this is a " +
+					"value of"},
+		},
+	},
+	{
+		Name:   "platform",
+		Doc:    "a platform (a collection of constraint values)",
+		Groups: []string{"TargetCommon"},
+		Attrs: []Attr{
+			{Name: "constraint_values", Type: values.AttrTypeLabelList,
+				Nonconfigurable: true, Doc: "the platform's constraint values"},
+			{Name: "parents", Type: values.AttrTypeLabelList, Nonconfigurable: true,
+				Doc: "the platform (at most one) to inherit from"},
+			{Name: "remote_execution_properties", Type: values.AttrTypeString,
+				Doc: "the properties for remote execution (deprecated)"},
+		},
+	},
+}
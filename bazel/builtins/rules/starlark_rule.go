@@ -0,0 +1,498 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"go.starlark.net/starlark"
+
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// attrDestTypes maps attribute types to the (pointer) types used to convert their values (see
+// builtins_args.ConvertArg). Values of attributes of other types are kept as (frozen) starlark
+// values.
+var attrDestTypes = map[values.AttrType]reflect.Type{
+	values.AttrTypeBool:                 reflect.TypeOf((*bool)(nil)),
+	values.AttrTypeInt:                  reflect.TypeOf((*int64)(nil)),
+	values.AttrTypeIntList:              reflect.TypeOf((*[]int64)(nil)),
+	values.AttrTypeLabel:                reflect.TypeOf((*core.Label)(nil)),
+	values.AttrTypeLabelKeyedStringDict: reflect.TypeOf((*map[core.Label]string)(nil)),
+	values.AttrTypeLabelList:            reflect.TypeOf((*[]core.Label)(nil)),
+	values.AttrTypeLicense:              reflect.TypeOf((*[]string)(nil)),
+	values.AttrTypeOutput:               reflect.TypeOf((*core.Label)(nil)),
+	values.AttrTypeOutputList:           reflect.TypeOf((*[]core.Label)(nil)),
+	values.AttrTypeString:               reflect.TypeOf((*string)(nil)),
+	values.AttrTypeStringDict:           reflect.TypeOf((*map[string]string)(nil)),
+	values.AttrTypeStringList:           reflect.TypeOf((*[]string)(nil)),
+	values.AttrTypeStringListDict:       reflect.TypeOf((*map[string][]string)(nil)),
+}
+
+// nonconfigurableAttrTypes are the attribute types whose values may not be given using select().
+var nonconfigurableAttrTypes = map[values.AttrType]bool{
+	values.AttrTypeLicense:    true,
+	values.AttrTypeOutput:     true,
+	values.AttrTypeOutputList: true,
+}
+
+// overridableCommonAttrs are the attributes (of TargetCommon) that user-defined rules may declare
+// themselves, since they aren't actually common to all Bazel rules (so targets of user-defined
+// rules only have them if declared).
+var overridableCommonAttrs = map[string]bool{"data": true, "deps": true}
+
+// isGroupAttr returns whether the given attribute name is that of an attribute (i.e., a tagged
+// field) of the given group type (e.g., TargetCommon).
+func isGroupAttr(typ reflect.Type, name string) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if tag, ok := typ.Field(i).Tag.Lookup("bazel"); ok {
+			if argName, _, _ := builtins_args.ParseTag(tag); argName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isCommonAttr returns whether the given attribute name is that of an attribute of TargetCommon.
+func isCommonAttr(name string) bool {
+	return isGroupAttr(reflect.TypeOf(TargetCommon{}), name)
+}
+
+// convertAttrValue converts a (non-select()) value of the given attribute.
+func convertAttrValue(attrName string, attr *values.Attr, value starlark.Value,
+	ctx core.Context) (interface{}, error) {
+
+	destType, ok := attrDestTypes[attr.AttrType]
+	if !ok {
+		if value == starlark.None {
+			return nil, nil
+		}
+		value.Freeze()
+		return value, nil
+	}
+	v, err := builtins_args.ConvertArg(attrName, value, ctx, destType)
+	if err != nil {
+		return nil, err
+	}
+	switch attr.AttrType {
+	case values.AttrTypeOutput, values.AttrTypeOutputList:
+		err = builtins_args.CheckOutputs(attrName, v, ctx)
+	case values.AttrTypeLicense:
+		err = builtins_args.CheckLicenses(attrName, v, ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// starlarkRuleIdCounter is used to assign (unique) ids to user-defined rules, which are used for
+// hashing.
+var starlarkRuleIdCounter uint32
+
+// StarlarkRule is a user-defined rule (as created by rule()), which is callable from BUILD files
+// to instantiate StarlarkRuleTargets.
+type StarlarkRule struct {
+	name string
+
+	implementation starlark.Callable
+
+	// attrNames are the names of the declared attributes (in the order declared).
+	attrNames []string
+	attrs     map[string]*values.Attr
+
+	// defaults are the (converted) default values of the declared attributes, which are
+	// relative to the .bzl file that defined the rule.
+	defaults map[string]interface{}
+
+	doc        string
+	test       bool
+	executable bool
+
+	id uint32
+}
+
+var _ starlark.Callable = (*StarlarkRule)(nil)
+var _ values.Exportable = (*StarlarkRule)(nil)
+
+// NewStarlarkRule creates a new (unexported) StarlarkRule, with the given declared attributes.
+// ctx should be the context of the .bzl file defining the rule.
+func NewStarlarkRule(ctx core.Context, implementation starlark.Callable, attrNames []string,
+	attrs []*values.Attr, doc string, test bool, executable bool) (*StarlarkRule, error) {
+
+	rv := &StarlarkRule{
+		implementation: implementation,
+		attrs:          make(map[string]*values.Attr, len(attrs)),
+		defaults:       make(map[string]interface{}, len(attrs)),
+		doc:            doc,
+		test:           test,
+		executable:     executable,
+		id:             atomic.AddUint32(&starlarkRuleIdCounter, 1),
+	}
+	for i, attrName := range attrNames {
+		if isCommonAttr(attrName) && !overridableCommonAttrs[attrName] {
+			return nil, fmt.Errorf("there is already a built-in attribute %v, which "+
+				"cannot be overridden", attrName)
+		}
+		if _, ok := rv.attrs[attrName]; ok {
+			return nil, fmt.Errorf("duplicate attribute %v", attrName)
+		}
+		attr := attrs[i]
+		rv.attrNames = append(rv.attrNames, attrName)
+		rv.attrs[attrName] = attr
+
+		defaultValue := attr.DefaultValue()
+		if _, ok := defaultValue.(starlark.Callable); ok {
+			// TODO(vtl): Support computed defaults.
+			rv.defaults[attrName] = nil
+			continue
+		}
+		// Only explicit defaults need be allowed values.
+		if attr.Default != nil {
+			if err := attr.CheckValue(defaultValue); err != nil {
+				return nil, fmt.Errorf("attribute %v: invalid default value: %v",
+					attrName, err)
+			}
+		}
+		value, err := convertAttrValue(attrName, attr, defaultValue, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %v: invalid default value: %v", attrName,
+				err)
+		}
+		rv.defaults[attrName] = value
+	}
+	return rv, nil
+}
+
+// Implementation returns the rule's implementation function.
+func (self *StarlarkRule) Implementation() starlark.Callable {
+	return self.implementation
+}
+
+// AttrNames returns the names of the rule's declared attributes (in the order declared).
+func (self *StarlarkRule) AttrNames() []string {
+	return self.attrNames
+}
+
+// Attr returns the schema of the given declared attribute (or nil if there is no such attribute).
+func (self *StarlarkRule) Attr(attrName string) *values.Attr {
+	return self.attrs[attrName]
+}
+
+// Doc returns the rule's documentation.
+func (self *StarlarkRule) Doc() string {
+	return self.doc
+}
+
+// IsTest returns whether the rule is a test rule.
+func (self *StarlarkRule) IsTest() bool {
+	return self.test
+}
+
+// IsExecutable returns whether the rule creates executables (test rules always do).
+func (self *StarlarkRule) IsExecutable() bool {
+	return self.executable || self.test
+}
+
+func (self *StarlarkRule) IsExported() bool {
+	return self.name != ""
+}
+
+func (self *StarlarkRule) Export(name string) {
+	self.name = name
+}
+
+func (self *StarlarkRule) String() string {
+	if !self.IsExported() {
+		return "<rule>"
+	}
+	return fmt.Sprintf("<rule %v>", self.name)
+}
+
+func (self *StarlarkRule) Type() string {
+	return "rule"
+}
+
+// Freeze does nothing, since rules are immutable (other than being exported).
+func (self *StarlarkRule) Freeze() {}
+
+func (self *StarlarkRule) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *StarlarkRule) Hash() (uint32, error) {
+	return self.id, nil
+}
+
+func (self *StarlarkRule) Name() string {
+	if !self.IsExported() {
+		return "<unexported rule>"
+	}
+	return self.name
+}
+
+func (self *StarlarkRule) CallInternal(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	return callRule(thread, self.Name(), args, kwargs, self.instantiate)
+}
+
+// instantiate instantiates a target of the rule.
+func (self *StarlarkRule) instantiate(ctx core.Context, args starlark.Tuple,
+	kwargs []starlark.Tuple) (core.Target, error) {
+
+	if !self.IsExported() {
+		return nil, fmt.Errorf("invalid rule class hasn't been exported by a .bzl file")
+	}
+	if self.test != strings.HasSuffix(self.name, "_test") {
+		if self.test {
+			return nil, fmt.Errorf("invalid rule class name %v: test rule class names "+
+				"must end with '_test'", self.name)
+		}
+		return nil, fmt.Errorf("invalid rule class name %v: only test rule class names "+
+			"may end with '_test'", self.name)
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("all arguments should be passed as keyword arguments")
+	}
+
+	target := &StarlarkRuleTarget{rule: self, attrs: make(map[string]interface{})}
+
+	// Process the declared attributes, leaving the other arguments (for TargetCommon, etc.)
+	// to ProcessArgs.
+	otherKwargs := []starlark.Tuple{}
+	for _, kwarg := range kwargs {
+		argName := string(kwarg[0].(starlark.String))
+		attr, ok := self.attrs[argName]
+		if !ok {
+			// Only the attributes implicitly common to all rules are accepted without
+			// being declared (which excludes data and deps).
+			if overridableCommonAttrs[argName] {
+				return nil, fmt.Errorf("unknown keyword argument %v", argName)
+			}
+			otherKwargs = append(otherKwargs, kwarg)
+			continue
+		}
+		if strings.HasPrefix(argName, "_") {
+			return nil, fmt.Errorf("argument %v invalid: private attributes may not "+
+				"be set", argName)
+		}
+		if err := target.setAttr(ctx, argName, attr, kwarg[1]); err != nil {
+			return nil, err
+		}
+	}
+	for _, attrName := range self.attrNames {
+		if _, ok := target.attrs[attrName]; ok || target.AttrConfigurable(attrName) != nil {
+			continue
+		}
+		if self.attrs[attrName].Mandatory {
+			return nil, fmt.Errorf("target argument %v required", attrName)
+		}
+		target.attrs[attrName] = self.defaults[attrName]
+	}
+
+	if err := builtins_args.ProcessArgs(nil, otherKwargs, ctx, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// StarlarkRuleTarget is a target of a user-defined rule (i.e., a StarlarkRule). Its declared
+// attributes are available via Attr (and AttrConfigurable, if given using select()).
+type StarlarkRuleTarget struct {
+	TargetCommon
+	TargetCommonTest
+
+	rule *StarlarkRule
+
+	// attrs are the (converted) values of the declared attributes (nil if None), keyed by
+	// attribute name.
+	attrs map[string]interface{}
+
+	// attrConfigurables contains the unresolved values of the declared attributes that were
+	// given using select(), keyed by attribute name.
+	attrConfigurables map[string]*core.Configurable
+}
+
+var _ builtins_args.DynamicArgsTarget = (*StarlarkRuleTarget)(nil)
+var _ core.GeneratingTarget = (*StarlarkRuleTarget)(nil)
+
+// setAttr sets the value of a declared attribute (from an argument).
+func (self *StarlarkRuleTarget) setAttr(ctx core.Context, attrName string, attr *values.Attr,
+	value starlark.Value) error {
+
+	sel, ok := value.(*values.Select)
+	if !ok {
+		if err := attr.CheckValue(value); err != nil {
+			return fmt.Errorf("argument %v invalid: %v", attrName, err)
+		}
+		v, err := convertAttrValue(attrName, attr, value, ctx)
+		if err != nil {
+			return err
+		}
+		self.attrs[attrName] = v
+		return nil
+	}
+
+	destType, ok := attrDestTypes[attr.AttrType]
+	if !ok || nonconfigurableAttrTypes[attr.AttrType] || attr.Nonconfigurable {
+		return fmt.Errorf("argument %v invalid: not configurable", attrName)
+	}
+	// The value is checked against the attribute's schema (see values.Attr.CheckValue) as a
+	// whole, once it is resolved (see SetDynamicArg), since its parts needn't be valid by
+	// themselves (e.g., [] + select(...) for a non-empty label_list).
+	configurable, err := builtins_args.ConvertConfigurableArg(attrName, sel, ctx, destType)
+	if err != nil {
+		return err
+	}
+	if self.attrConfigurables == nil {
+		self.attrConfigurables = make(map[string]*core.Configurable)
+	}
+	self.attrConfigurables[attrName] = configurable
+	return nil
+}
+
+func (self *StarlarkRuleTarget) DidProcessArgs(ctx core.Context) error {
+	// The test (and executable) arguments are only accepted by test (resp. executable) rules.
+	testArgs := []struct {
+		argName string
+		isSet   bool
+	}{
+		{"args", self.Args != nil},
+		{"size", self.Size != nil},
+		{"timeout", self.Timeout != nil},
+		{"flaky", self.Flaky != nil},
+		{"local", self.Local != nil},
+		{"shard_count", self.ShardCount != nil},
+	}
+	for _, testArg := range testArgs {
+		if self.rule.IsTest() || (testArg.argName == "args" && self.rule.IsExecutable()) {
+			continue
+		}
+		if testArg.isSet || self.Configurable(testArg.argName) != nil {
+			return fmt.Errorf("unknown keyword argument %v", testArg.argName)
+		}
+	}
+	return nil
+}
+
+// Rule returns the rule of which the target is an instance.
+func (self *StarlarkRuleTarget) Rule() *StarlarkRule {
+	return self.rule
+}
+
+// Attr returns the value of the given declared attribute (nil if None or if it was given using
+// select() and is unresolved), and whether there is such an attribute.
+func (self *StarlarkRuleTarget) Attr(attrName string) (interface{}, bool) {
+	if self.rule.Attr(attrName) == nil {
+		return nil, false
+	}
+	return self.attrs[attrName], true
+}
+
+// AttrConfigurable returns the unresolved value of the given declared attribute if it was given
+// using select(), or nil otherwise.
+func (self *StarlarkRuleTarget) AttrConfigurable(attrName string) *core.Configurable {
+	return self.attrConfigurables[attrName]
+}
+
+// OutputLabels returns the labels of the files given by the target's output and output_list
+// attributes (in the order declared).
+func (self *StarlarkRuleTarget) OutputLabels() []core.Label {
+	rv := []core.Label{}
+	for _, attrName := range self.rule.AttrNames() {
+		switch v := self.attrs[attrName].(type) {
+		case core.Label:
+			if self.rule.Attr(attrName).AttrType == values.AttrTypeOutput {
+				rv = append(rv, v)
+			}
+		case []core.Label:
+			if self.rule.Attr(attrName).AttrType == values.AttrTypeOutputList {
+				rv = append(rv, v...)
+			}
+		}
+	}
+	return rv
+}
+
+func (self *StarlarkRuleTarget) Configurables() map[string]*core.Configurable {
+	if len(self.attrConfigurables) == 0 {
+		return self.TargetCommon.Configurables()
+	}
+	rv := make(map[string]*core.Configurable)
+	for argName, configurable := range self.TargetCommon.Configurables() {
+		rv[argName] = configurable
+	}
+	for argName, configurable := range self.attrConfigurables {
+		rv[argName] = configurable
+	}
+	return rv
+}
+
+// SetDynamicArg sets the resolved value of a declared attribute given using select(), checking
+// the whole (concatenated) value against the attribute's schema (e.g., allow_empty and values).
+func (self *StarlarkRuleTarget) SetDynamicArg(argName string, value interface{}) (bool, error) {
+	attr := self.rule.Attr(argName)
+	if attr == nil {
+		return false, nil
+	}
+	if value != nil {
+		if err := attr.CheckValue(attrValueToStarlark(self.Label(), value)); err != nil {
+			return true, fmt.Errorf("argument %v invalid: %v", argName, err)
+		}
+	}
+	self.attrs[argName] = value
+	delete(self.attrConfigurables, argName)
+	return true, nil
+}
+
+// SetResolvedArg sets the field for the given common argument to its resolved value (see
+// builtins_args.ResolvedArgsTarget). Its other arguments are dynamic (see SetDynamicArg).
+func (self *StarlarkRuleTarget) SetResolvedArg(argName string, value interface{}) string {
+	if tag := self.TargetCommon.setGroupResolvedArg(argName, value); tag != "" {
+		return tag
+	}
+	return self.TargetCommonTest.setGroupResolvedArg(argName, value)
+}
+
+func (self *StarlarkRuleTarget) DidResolveArgs(ctx core.Context) error {
+	if err := self.TargetCommon.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	if err := self.TargetCommonTest.DidProcessArgs(ctx); err != nil {
+		return err
+	}
+	return self.DidProcessArgs(ctx)
+}
+
+func (self *StarlarkRuleTarget) forEachExtraAttr(fn func(argName string, value interface{},
+	configurable *core.Configurable)) {
+
+	for _, attrName := range self.rule.AttrNames() {
+		if value := self.attrs[attrName]; value != nil {
+			fn(attrName, value, nil)
+		} else if configurable := self.attrConfigurables[attrName]; configurable != nil {
+			fn(attrName, nil, configurable)
+		}
+	}
+}
+
+func (self *StarlarkRuleTarget) Kind() string {
+	return self.rule.Name()
+}
+
+func (self *StarlarkRuleTarget) String() string {
+	return targetToString(self)
+}
+
+// MarshalJSON marshals the target as JSON (see marshalTargetJSON).
+func (self *StarlarkRuleTarget) MarshalJSON() ([]byte, error) {
+	return marshalTargetJSON(self)
+}
@@ -0,0 +1,281 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"reflect"
+	"sort"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// configurableTarget is implemented by targets that may have configurable attributes given using
+// select().
+type configurableTarget interface {
+	Configurable(argName string) *core.Configurable
+}
+
+// extraAttrsTarget is implemented by targets that have attributes that don't correspond to
+// (tagged) fields (e.g., targets of user-defined rules).
+type extraAttrsTarget interface {
+	forEachExtraAttr(fn func(argName string, value interface{},
+		configurable *core.Configurable))
+}
+
+// nativeAttrsTarget is implemented by targets of native rules, whose attributes are iterated over
+// by (generated) code instead of using reflection.
+type nativeAttrsTarget interface {
+	forEachNativeAttr(configurableTarget configurableTarget,
+		fn func(argName string, value interface{}, configurable *core.Configurable))
+}
+
+// visitConfigurable calls fn for the given attribute with its configurable value, if it was given
+// using select() (and is unresolved); configurableTarget may be nil.
+func visitConfigurable(configurableTarget configurableTarget, argName string,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	if configurableTarget == nil {
+		return
+	}
+	if configurable := configurableTarget.Configurable(argName); configurable != nil {
+		fn(argName, nil, configurable)
+	}
+}
+
+func forEachAttrHelper(targetVp reflect.Value, configurableTarget configurableTarget,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	v := targetVp.Elem()
+	if v.Kind() != reflect.Struct {
+		panic(v)
+	}
+	typ := v.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		typf := typ.Field(i)
+		vf := v.Field(i)
+		if tag, ok := typf.Tag.Lookup("bazel"); ok {
+			argName, _, _ := args.ParseTag(tag)
+
+			if !vf.IsNil() {
+				fn(argName, vf.Elem().Interface(), nil)
+			} else if configurableTarget != nil {
+				configurable := configurableTarget.Configurable(argName)
+				if configurable != nil {
+					fn(argName, nil, configurable)
+				}
+			}
+		} else if vf.Kind() == reflect.Struct {
+			forEachAttrHelper(vf.Addr(), configurableTarget, fn)
+		}
+	}
+}
+
+// forEachAttr calls fn for each attribute of the target that is set (in the order declared),
+// either with its value or, if it was given using select() (and is unresolved), with its
+// configurable value.
+func forEachAttr(target core.Target,
+	fn func(argName string, value interface{}, configurable *core.Configurable)) {
+
+	targetVp := reflect.ValueOf(target)
+	if targetVp.Kind() != reflect.Ptr {
+		panic(targetVp)
+	}
+
+	configurableTarget, _ := target.(configurableTarget)
+	if nativeTarget, ok := target.(nativeAttrsTarget); ok {
+		nativeTarget.forEachNativeAttr(configurableTarget, fn)
+	} else {
+		forEachAttrHelper(targetVp, configurableTarget, fn)
+	}
+	if extraAttrsTarget, ok := target.(extraAttrsTarget); ok {
+		extraAttrsTarget.forEachExtraAttr(fn)
+	}
+}
+
+// labelToStarlark converts a label to a starlark string, relative to the given package (i.e.,
+// of the form ":foo" if it is in the package).
+func labelToStarlark(pkg core.Label, label core.Label) starlark.Value {
+	if label.Workspace == pkg.Workspace && label.Package == pkg.Package {
+		return starlark.String(":" + string(label.Target))
+	}
+	return starlark.String(label.String())
+}
+
+// attrValueToStarlark converts the value of an attribute to a starlark value, with labels
+// converted to strings (relative to the package of pkg).
+func attrValueToStarlark(pkg core.Label, value interface{}) starlark.Value {
+	switch v := value.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(v)
+	case int64:
+		return starlark.MakeInt64(v)
+	case string:
+		return starlark.String(v)
+	case core.Label:
+		return labelToStarlark(pkg, v)
+	case []int64:
+		elems := make([]starlark.Value, len(v))
+		for i := range v {
+			elems[i] = starlark.MakeInt64(v[i])
+		}
+		return starlark.NewList(elems)
+	case []string:
+		elems := make([]starlark.Value, len(v))
+		for i := range v {
+			elems[i] = starlark.String(v[i])
+		}
+		return starlark.NewList(elems)
+	case []core.Label:
+		elems := make([]starlark.Value, len(v))
+		for i := range v {
+			elems[i] = labelToStarlark(pkg, v[i])
+		}
+		return starlark.NewList(elems)
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		rv := starlark.NewDict(len(v))
+		for _, k := range keys {
+			rv.SetKey(starlark.String(k), starlark.String(v[k]))
+		}
+		return rv
+	case map[core.Label]string:
+		keys := make([]core.Label, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		rv := starlark.NewDict(len(v))
+		for _, k := range keys {
+			rv.SetKey(labelToStarlark(pkg, k), starlark.String(v[k]))
+		}
+		return rv
+	case map[string][]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		rv := starlark.NewDict(len(v))
+		for _, k := range keys {
+			rv.SetKey(starlark.String(k), attrValueToStarlark(pkg, v[k]))
+		}
+		return rv
+	case starlark.Value:
+		// Values that are kept as (frozen) starlark values.
+		return v
+	default:
+		panic(v)
+	}
+}
+
+// configurableToStarlark converts a configurable value to a starlark (select()) value.
+func configurableToStarlark(pkg core.Label, configurable *core.Configurable) starlark.Value {
+	parts := make([]values.SelectPart, len(configurable.Parts))
+	for i, part := range configurable.Parts {
+		if !part.IsSelect() {
+			parts[i] = values.SelectPart{Value: attrValueToStarlark(pkg, part.Value)}
+			continue
+		}
+
+		conditions := starlark.NewDict(len(part.Branches))
+		for _, branch := range part.Branches {
+			conditions.SetKey(starlark.String(branch.Condition.String()),
+				attrValueToStarlark(pkg, branch.Value))
+		}
+		conditions.Freeze()
+		parts[i] = values.SelectPart{Selector: &values.Selector{
+			Conditions:   conditions,
+			NoMatchError: part.NoMatchError,
+		}}
+	}
+	return &values.Select{Parts: parts}
+}
+
+// TargetAttrs returns the attributes of a target (for native.existing_rules()) as a frozen dict,
+// containing its name, kind, generator_* attributes (if instantiated by a macro), and all its set
+// attributes.
+func TargetAttrs(target core.Target) *starlark.Dict {
+	label := target.Label()
+	rv := starlark.NewDict(0)
+	rv.SetKey(starlark.String("name"), starlark.String(label.Target))
+	rv.SetKey(starlark.String("kind"), starlark.String(target.Kind()))
+	if provenance := target.Provenance(); provenance != nil && provenance.IsGenerated() {
+		rv.SetKey(starlark.String("generator_name"),
+			starlark.String(provenance.GeneratorName))
+		rv.SetKey(starlark.String("generator_function"),
+			starlark.String(provenance.GeneratorFunction))
+		rv.SetKey(starlark.String("generator_location"),
+			starlark.String(provenance.GeneratorLocation))
+	}
+	forEachAttr(target, func(argName string, value interface{},
+		configurable *core.Configurable) {
+
+		if configurable != nil {
+			rv.SetKey(starlark.String(argName), configurableToStarlark(label,
+				configurable))
+		} else {
+			rv.SetKey(starlark.String(argName), attrValueToStarlark(label, value))
+		}
+	})
+	rv.Freeze()
+	return rv
+}
+
+// appendLabels appends the labels in an attribute value to labels.
+func appendLabels(labels []core.Label, value interface{}) []core.Label {
+	switch v := value.(type) {
+	case core.Label:
+		return append(labels, v)
+	case []core.Label:
+		return append(labels, v...)
+	case map[core.Label]string:
+		keys := make([]core.Label, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		return append(labels, keys...)
+	default:
+		return labels
+	}
+}
+
+// TargetLabels returns the labels referred to by the attributes of a target (including in all
+// the branches of unresolved select()s, but not including the select() conditions themselves).
+// The labels of visibility are excluded, since they refer to packages rather than targets.
+func TargetLabels(target core.Target) []core.Label {
+	rv := []core.Label{}
+	forEachAttr(target, func(argName string, value interface{},
+		configurable *core.Configurable) {
+
+		if argName == "visibility" {
+			return
+		}
+		if configurable == nil {
+			rv = appendLabels(rv, value)
+			return
+		}
+		for _, part := range configurable.Parts {
+			if !part.IsSelect() {
+				rv = appendLabels(rv, part.Value)
+				continue
+			}
+			for _, branch := range part.Branches {
+				rv = appendLabels(rv, branch.Value)
+			}
+		}
+	})
+	return rv
+}
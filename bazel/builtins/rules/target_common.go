@@ -10,26 +10,18 @@ import (
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-type TargetCommon struct {
-	Name               *string       `bazel:"name!"`
-	Data               *[]core.Label `bazel:"data"`
-	Visibility         *[]core.Label `bazel:"visibility"`
-	Toolchains         *[]core.Label `bazel:"toolchains"`
-	Deps               *[]core.Label `bazel:"deps"`
-	Deprecation        *string       `bazel:"deprecation"`
-	Tags               *[]string     `bazel:"tags"`
-	Testonly           *bool         `bazel:"testonly"`
-	Features           *[]string     `bazel:"features"`
-	Licenses           *[]string     `bazel:"licenses"`
-	CompatibleWith     *[]core.Label `bazel:"compatible_with"`
-	Distribs           *[]string     `bazel:"distribs"`
-	ExecCompatibleWith *[]core.Label `bazel:"exec_compatible_with"`
-	RestrictedTo       *[]core.Label `bazel:"restricted_to"`
-
+// targetCommonState is the state of a TargetCommon that doesn't correspond to attributes.
+type targetCommonState struct {
 	label core.Label
+
+	// configurables contains the unresolved values of the attributes that were given using
+	// select() (whose fields are left unset), keyed by attribute name.
+	configurables map[string]*core.Configurable
+
+	provenance *core.Provenance
 }
 
-var _ args.ProcessArgsTarget = (*TargetCommon)(nil)
+var _ args.ConfigurableArgsTarget = (*TargetCommon)(nil)
 
 func (self *TargetCommon) DidProcessArgs(ctx core.Context) error {
 	self.label = core.Label{
@@ -44,6 +36,73 @@ func (self *TargetCommon) DidProcessArgs(ctx core.Context) error {
 	return nil
 }
 
+// ApplyPackageDefaults applies the package's defaults (from package()) to attributes that weren't
+// given explicitly, and prepends the package's features to the target's. The values are copied,
+// so the targets of a package don't share them (with each other or with the package's metadata).
+func (self *TargetCommon) ApplyPackageDefaults(metadata *core.PackageMetadata) {
+	if self.Visibility == nil && metadata.DefaultVisibility != nil {
+		visibility := append([]core.Label{}, *metadata.DefaultVisibility...)
+		self.Visibility = &visibility
+	}
+	if self.Testonly == nil && metadata.DefaultTestonly != nil {
+		testonly := *metadata.DefaultTestonly
+		self.Testonly = &testonly
+	}
+	if self.Deprecation == nil && self.Configurable("deprecation") == nil &&
+		metadata.DefaultDeprecation != nil {
+		deprecation := *metadata.DefaultDeprecation
+		self.Deprecation = &deprecation
+	}
+	if self.ApplicableLicenses == nil && metadata.DefaultApplicableLicenses != nil {
+		applicableLicenses := append([]core.Label{}, *metadata.DefaultApplicableLicenses...)
+		self.ApplicableLicenses = &applicableLicenses
+	}
+	if metadata.Features != nil {
+		if configurable := self.Configurable("features"); configurable != nil {
+			configurable.Parts = append([]core.ConfigurablePart{
+				{Value: append([]string{}, *metadata.Features...)}},
+				configurable.Parts...)
+		} else {
+			features := append([]string{}, *metadata.Features...)
+			if self.Features != nil {
+				features = append(features, *self.Features...)
+			}
+			self.Features = &features
+		}
+	}
+}
+
 func (self *TargetCommon) Label() core.Label {
 	return self.label
 }
+
+func (self *TargetCommon) Provenance() *core.Provenance {
+	return self.provenance
+}
+
+func (self *TargetCommon) SetProvenance(provenance *core.Provenance) {
+	self.provenance = provenance
+}
+
+// SetConfigurable sets the unresolved value of the given attribute (or, if value is nil, marks it
+// as resolved).
+func (self *TargetCommon) SetConfigurable(argName string, value *core.Configurable) {
+	if value == nil {
+		delete(self.configurables, argName)
+		return
+	}
+	if self.configurables == nil {
+		self.configurables = make(map[string]*core.Configurable)
+	}
+	self.configurables[argName] = value
+}
+
+func (self *TargetCommon) Configurables() map[string]*core.Configurable {
+	return self.configurables
+}
+
+// Configurable returns the unresolved value of the given attribute if it was given using select(),
+// or nil otherwise.
+func (self *TargetCommon) Configurable(argName string) *core.Configurable {
+	return self.configurables[argName]
+}
@@ -6,22 +6,22 @@
 package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
 
 import (
-	"src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"fmt"
+
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-type TargetCommonTest struct {
-	Args       *[]string `bazel:"args"`
-	Size       *string   `bazel:"size"`
-	Timeout    *string   `bazel:"timeout"`
-	Flaky      *bool     `bazel:"flaky"`
-	Local      *bool     `bazel:"local"`
-	ShardCount *int64    `bazel:"shard_count"`
-}
-
-var _ args.ProcessArgsTarget = (*TargetCommonTest)(nil)
-
 func (self *TargetCommonTest) DidProcessArgs(ctx core.Context) error {
-	// TODO(vtl): Check fields.
+	if err := checkOneOf("size", self.Size, "small", "medium", "large",
+		"enormous"); err != nil {
+		return err
+	}
+	if err := checkOneOf("timeout", self.Timeout, "short", "moderate", "long",
+		"eternal"); err != nil {
+		return err
+	}
+	if self.ShardCount != nil && *self.ShardCount < 0 {
+		return fmt.Errorf("argument shard_count invalid: must not be negative")
+	}
 	return nil
 }
@@ -0,0 +1,87 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
+
+import (
+	"encoding/json"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// attrValueToJSON converts the value of an attribute to a value that encoding/json can marshal,
+// with labels converted to strings.
+func attrValueToJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, int64, string, []int64, []string, map[string]string,
+		map[string][]string:
+		return v
+	case core.Label:
+		return v.String()
+	case []core.Label:
+		rv := make([]string, len(v))
+		for i := range v {
+			rv[i] = v[i].String()
+		}
+		return rv
+	case map[core.Label]string:
+		rv := make(map[string]string, len(v))
+		for k := range v {
+			rv[k.String()] = v[k]
+		}
+		return rv
+	case starlark.Value:
+		// Values that are kept as (frozen) starlark values.
+		return v.String()
+	default:
+		panic(v)
+	}
+}
+
+// configurablePartJSON is the JSON form of a part of a configurable value: either a plain value or
+// a select().
+type configurablePartJSON struct {
+	Value        interface{}            `json:"value,omitempty"`
+	Select       map[string]interface{} `json:"select,omitempty"`
+	NoMatchError string                 `json:"no_match_error,omitempty"`
+}
+
+// configurableToJSON converts a configurable value to a value that encoding/json can marshal (of
+// the form {"configurable": [<part>, ...]}).
+func configurableToJSON(configurable *core.Configurable) interface{} {
+	parts := make([]configurablePartJSON, len(configurable.Parts))
+	for i, part := range configurable.Parts {
+		if !part.IsSelect() {
+			parts[i].Value = attrValueToJSON(part.Value)
+			continue
+		}
+		parts[i].Select = make(map[string]interface{}, len(part.Branches))
+		for _, branch := range part.Branches {
+			parts[i].Select[branch.Condition.String()] = attrValueToJSON(branch.Value)
+		}
+		parts[i].NoMatchError = part.NoMatchError
+	}
+	return map[string]interface{}{"configurable": parts}
+}
+
+// marshalTargetJSON marshals a target as JSON, as an object with its kind, label, and set
+// attributes.
+func marshalTargetJSON(target core.Target) ([]byte, error) {
+	attrs := make(map[string]interface{})
+	forEachAttr(target, func(argName string, value interface{},
+		configurable *core.Configurable) {
+
+		if configurable != nil {
+			attrs[argName] = configurableToJSON(configurable)
+		} else {
+			attrs[argName] = attrValueToJSON(value)
+		}
+	})
+	return json.Marshal(struct {
+		Kind  string                 `json:"kind"`
+		Label string                 `json:"label"`
+		Attrs map[string]interface{} `json:"attrs"`
+	}{target.Kind(), target.Label().String(), attrs})
+}
@@ -5,16 +5,19 @@ package rules // import "src.tricot.io/public/bazel2x/bazel/builtins/rules"
 
 import (
 	"fmt"
-	"reflect"
+	"sort"
 	"strings"
 
-	"src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"go.starlark.net/starlark"
+
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-func getAttr(attrName string, src reflect.Value) string {
+func attrValueToString(value interface{}) string {
 	var attrValue string
-	switch v := src.Interface().(type) {
+	switch v := value.(type) {
+	case nil:
+		attrValue = "None"
 	case bool:
 		if v {
 			attrValue = "True"
@@ -27,6 +30,15 @@ func getAttr(attrName string, src reflect.Value) string {
 		attrValue = fmt.Sprintf("%q", v)
 	case core.Label:
 		attrValue = fmt.Sprintf("%q", v.String())
+	case []int64:
+		attrValue = "["
+		for i := range v {
+			if i > 0 {
+				attrValue += ", "
+			}
+			attrValue += fmt.Sprintf("%v", v[i])
+		}
+		attrValue += "]"
 	case []string:
 		attrValue = "["
 		for i := range v {
@@ -45,43 +57,86 @@ func getAttr(attrName string, src reflect.Value) string {
 			attrValue += fmt.Sprintf("%q", v[i].String())
 		}
 		attrValue += "]"
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrValue = "{"
+		for i, k := range keys {
+			if i > 0 {
+				attrValue += ", "
+			}
+			attrValue += fmt.Sprintf("%q: %q", k, v[k])
+		}
+		attrValue += "}"
+	case map[core.Label]string:
+		keys := make([]string, 0, len(v))
+		values := make(map[string]string, len(v))
+		for k := range v {
+			keys = append(keys, k.String())
+			values[k.String()] = v[k]
+		}
+		sort.Strings(keys)
+		attrValue = "{"
+		for i, k := range keys {
+			if i > 0 {
+				attrValue += ", "
+			}
+			attrValue += fmt.Sprintf("%q: %q", k, values[k])
+		}
+		attrValue += "}"
+	case map[string][]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrValue = "{"
+		for i, k := range keys {
+			if i > 0 {
+				attrValue += ", "
+			}
+			attrValue += fmt.Sprintf("%q: %v", k, attrValueToString(v[k]))
+		}
+		attrValue += "}"
+	case starlark.Value:
+		attrValue = v.String()
 	default:
 		panic(v)
 	}
-	return attrName + " = " + attrValue
+	return attrValue
 }
 
-func getAttrs(targetVp reflect.Value, attrs *[]string) {
-	v := targetVp.Elem()
-	if v.Kind() != reflect.Struct {
-		panic(v)
-	}
-	typ := v.Type()
-
-	for i := 0; i < typ.NumField(); i++ {
-		typf := typ.Field(i)
-		vf := v.Field(i)
-		if argName, ok := typf.Tag.Lookup("bazel"); ok {
-			if argName[len(argName)-1] == '!' {
-				argName = argName[:len(argName)-1]
-			}
+func configurableToString(configurable *core.Configurable) string {
+	parts := make([]string, len(configurable.Parts))
+	for i, part := range configurable.Parts {
+		if !part.IsSelect() {
+			parts[i] = attrValueToString(part.Value)
+			continue
+		}
 
-			if !vf.IsNil() {
-				*attrs = append(*attrs, getAttr(argName, vf.Elem()))
-			}
-		} else if vf.Kind() == reflect.Struct {
-			getAttrs(vf.Addr(), attrs)
+		branches := make([]string, len(part.Branches))
+		for j, branch := range part.Branches {
+			branches[j] = fmt.Sprintf("%q: %v", branch.Condition.String(),
+				attrValueToString(branch.Value))
 		}
+		parts[i] = "select({" + strings.Join(branches, ", ") + "})"
 	}
+	return strings.Join(parts, " + ")
 }
 
-func targetToString(ruleName string, target args.ProcessArgsTarget) string {
-	targetVp := reflect.ValueOf(target)
-	if targetVp.Kind() != reflect.Ptr {
-		panic(targetVp)
-	}
-
+func targetToString(target core.Target) string {
 	attrs := []string{}
-	getAttrs(targetVp, &attrs)
-	return ruleName + "(" + strings.Join(attrs, ", ") + ")"
+	forEachAttr(target, func(argName string, value interface{},
+		configurable *core.Configurable) {
+
+		if configurable != nil {
+			attrs = append(attrs, argName+" = "+configurableToString(configurable))
+		} else {
+			attrs = append(attrs, argName+" = "+attrValueToString(value))
+		}
+	})
+	return target.Kind() + "(" + strings.Join(attrs, ", ") + ")"
 }
@@ -0,0 +1,126 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"go.starlark.net/starlark"
+)
+
+// aspectIdCounter is used to assign (unique) ids to aspects, which are used for hashing.
+var aspectIdCounter uint32
+
+// Aspect is an aspect (as created by aspect()). Aspects aren't applied (yet), but their
+// definitions may be introspected.
+type Aspect struct {
+	name string
+
+	implementation starlark.Callable
+
+	// attrAspects are the names of the attributes along which the aspect propagates.
+	attrAspects []string
+
+	// attrNames are the names of the declared attributes (in the order declared).
+	attrNames []string
+	attrs     map[string]*Attr
+
+	doc string
+
+	id uint32
+}
+
+var _ Exportable = (*Aspect)(nil)
+
+// NewAspect creates a new (unexported) Aspect, with the given declared attributes.
+func NewAspect(implementation starlark.Callable, attrAspects []string, attrNames []string,
+	attrs []*Attr, doc string) (*Aspect, error) {
+
+	rv := &Aspect{
+		implementation: implementation,
+		attrAspects:    attrAspects,
+		attrs:          make(map[string]*Attr, len(attrs)),
+		doc:            doc,
+		id:             atomic.AddUint32(&aspectIdCounter, 1),
+	}
+	for i, attrName := range attrNames {
+		if _, ok := rv.attrs[attrName]; ok {
+			return nil, fmt.Errorf("duplicate attribute %v", attrName)
+		}
+		attr := attrs[i]
+		// Public attributes (i.e., parameters) are taken from the rules to which the aspect
+		// is applied, so only simple types are supported.
+		if attrName[0] != '_' && attr.AttrType != AttrTypeBool &&
+			attr.AttrType != AttrTypeInt && attr.AttrType != AttrTypeString {
+			return nil, fmt.Errorf("attribute %v invalid: aspect parameters must "+
+				"be of type bool, int or string", attrName)
+		}
+		rv.attrNames = append(rv.attrNames, attrName)
+		rv.attrs[attrName] = attr
+	}
+	return rv, nil
+}
+
+// Implementation returns the aspect's implementation function.
+func (self *Aspect) Implementation() starlark.Callable {
+	return self.implementation
+}
+
+// AttrAspects returns the names of the attributes along which the aspect propagates.
+func (self *Aspect) AttrAspects() []string {
+	return self.attrAspects
+}
+
+// AttrNames returns the names of the aspect's declared attributes (in the order declared).
+func (self *Aspect) AttrNames() []string {
+	return self.attrNames
+}
+
+// Attr returns the schema of the given declared attribute (or nil if there is no such attribute).
+func (self *Aspect) Attr(attrName string) *Attr {
+	return self.attrs[attrName]
+}
+
+// Doc returns the aspect's documentation.
+func (self *Aspect) Doc() string {
+	return self.doc
+}
+
+func (self *Aspect) IsExported() bool {
+	return self.name != ""
+}
+
+func (self *Aspect) Export(name string) {
+	self.name = name
+}
+
+func (self *Aspect) Name() string {
+	if !self.IsExported() {
+		return "<unexported aspect>"
+	}
+	return self.name
+}
+
+func (self *Aspect) String() string {
+	if !self.IsExported() {
+		return "<aspect>"
+	}
+	return fmt.Sprintf("<aspect %v>", self.name)
+}
+
+func (self *Aspect) Type() string {
+	return "Aspect"
+}
+
+// Freeze does nothing, since aspects are immutable (other than being exported).
+func (self *Aspect) Freeze() {}
+
+func (self *Aspect) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Aspect) Hash() (uint32, error) {
+	return self.id, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// AttrType is the type of an attribute of a (user-defined) rule, as given by the attr module
+// function used to declare it (e.g., "label_list" for attr.label_list()).
+type AttrType string
+
+const (
+	AttrTypeBool                 AttrType = "bool"
+	AttrTypeInt                  AttrType = "int"
+	AttrTypeIntList              AttrType = "int_list"
+	AttrTypeLabel                AttrType = "label"
+	AttrTypeLabelKeyedStringDict AttrType = "label_keyed_string_dict"
+	AttrTypeLabelList            AttrType = "label_list"
+	AttrTypeLicense              AttrType = "license"
+	AttrTypeOutput               AttrType = "output"
+	AttrTypeOutputList           AttrType = "output_list"
+	AttrTypeString               AttrType = "string"
+	AttrTypeStringDict           AttrType = "string_dict"
+	AttrTypeStringList           AttrType = "string_list"
+	AttrTypeStringListDict       AttrType = "string_list_dict"
+)
+
+// Attr is an attribute schema (as returned by, e.g., attr.string()), which describes an attribute
+// of a user-defined rule.
+type Attr struct {
+	AttrType AttrType
+
+	// Mandatory indicates that the attribute must be given.
+	Mandatory bool
+
+	// Default is the default value, or nil if there is no explicit default (in which case the
+	// default for the type is used).
+	Default starlark.Value
+
+	// Values are the allowed values (for string and int attributes), or nil if any value is
+	// allowed.
+	Values []starlark.Value
+
+	// Doc is the attribute's documentation.
+	Doc string
+
+	// Nonconfigurable indicates that the attribute's value may not be given using select().
+	Nonconfigurable bool
+
+	// AllowEmpty indicates that the attribute's value may be empty (for list and dict
+	// attributes).
+	AllowEmpty bool
+
+	// AllowFiles indicates that (label) attributes may refer to files, in which case
+	// AllowedFileTypes are the allowed file extensions (e.g., ".cc"), or nil if any file is
+	// allowed.
+	AllowFiles       bool
+	AllowedFileTypes []string
+
+	// SingleFile indicates that a label attribute must refer to a single file (as for
+	// allow_single_file).
+	SingleFile bool
+
+	// Executable indicates that a label attribute must refer to an executable.
+	Executable bool
+
+	// Providers are the providers that targets referred to must provide: a list of
+	// alternatives, each of which is a list of (required) providers (either *Providers or, for
+	// legacy providers, strings). It is nil if there are no requirements.
+	Providers [][]starlark.Value
+
+	// AllowRules are the kinds of rules that targets referred to may be instances of, or nil if
+	// there is no restriction.
+	AllowRules []string
+
+	// Cfg is the configuration of (label) attributes: "target", "exec", "host", or a
+	// transition. It is nil if unspecified.
+	Cfg starlark.Value
+
+	// Aspects are the aspects to apply to targets referred to.
+	Aspects []*Aspect
+}
+
+var _ starlark.Value = (*Attr)(nil)
+
+// DefaultValue returns the default value of the attribute: its explicit default if given, or
+// otherwise the default for its type.
+func (self *Attr) DefaultValue() starlark.Value {
+	if self.Default != nil {
+		return self.Default
+	}
+	switch self.AttrType {
+	case AttrTypeBool:
+		return starlark.False
+	case AttrTypeInt:
+		return starlark.MakeInt(0)
+	case AttrTypeString:
+		return starlark.String("")
+	case AttrTypeIntList, AttrTypeLabelList, AttrTypeOutputList, AttrTypeStringList,
+		AttrTypeLicense:
+		return starlark.NewList(nil)
+	case AttrTypeLabelKeyedStringDict, AttrTypeStringDict, AttrTypeStringListDict:
+		return starlark.NewDict(0)
+	default:
+		return starlark.None
+	}
+}
+
+// CheckValue checks that the given (non-select()) value is one of the allowed values (if
+// restricted), and that it isn't empty (if not allowed).
+func (self *Attr) CheckValue(value starlark.Value) error {
+	if l, ok := value.(starlark.Sequence); ok && !self.AllowEmpty && l.Len() == 0 {
+		return fmt.Errorf("attribute must be non empty")
+	}
+	if self.Values == nil {
+		return nil
+	}
+	for _, allowed := range self.Values {
+		if eq, err := starlark.Equal(value, allowed); err == nil && eq {
+			return nil
+		}
+	}
+	return fmt.Errorf("has to be one of %v instead of %v", starlark.NewList(self.Values),
+		value)
+}
+
+func (self *Attr) String() string {
+	return fmt.Sprintf("<attr.%v>", self.AttrType)
+}
+
+func (self *Attr) Type() string {
+	return "Attribute"
+}
+
+// Freeze does nothing, since attribute schemas are immutable (and their values are frozen).
+func (self *Attr) Freeze() {}
+
+func (self *Attr) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Attr) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", self.Type())
+}
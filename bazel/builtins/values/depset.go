@@ -0,0 +1,236 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.starlark.net/starlark"
+)
+
+// DepsetOrder is the traversal order of a depset.
+type DepsetOrder string
+
+const (
+	// DepsetOrderDefault is the default ("stable") order, which is the same as postorder
+	// (though compatible with all orders).
+	DepsetOrderDefault DepsetOrder = "default"
+
+	// DepsetOrderPostorder is the (left-to-right) postorder: transitive depsets before direct
+	// elements.
+	DepsetOrderPostorder DepsetOrder = "postorder"
+
+	// DepsetOrderPreorder is the (left-to-right) preorder: direct elements before transitive
+	// depsets.
+	DepsetOrderPreorder DepsetOrder = "preorder"
+
+	// DepsetOrderTopological is a topological order: each depset's direct elements come before
+	// its transitive depsets' (and left-to-right order is not guaranteed).
+	DepsetOrderTopological DepsetOrder = "topological"
+)
+
+// IsValid returns whether the given DepsetOrder is valid.
+func (o DepsetOrder) IsValid() bool {
+	switch o {
+	case DepsetOrderDefault, DepsetOrderPostorder, DepsetOrderPreorder, DepsetOrderTopological:
+		return true
+	default:
+		return false
+	}
+}
+
+// isCompatibleWith returns whether a depset of order o may have a transitive depset of order other.
+func (o DepsetOrder) isCompatibleWith(other DepsetOrder) bool {
+	return o == other || o == DepsetOrderDefault || other == DepsetOrderDefault
+}
+
+// depsetIdCounter is used to assign (unique) ids to depsets, which are used for hashing.
+var depsetIdCounter uint32
+
+// Depset is an immutable set (of hashable, frozen values, all of the same type), which may be
+// efficiently built up from other depsets.
+type Depset struct {
+	order      DepsetOrder
+	elemType   string
+	direct     []starlark.Value
+	transitive []*Depset
+
+	id uint32
+}
+
+var _ starlark.HasAttrs = (*Depset)(nil)
+
+// NewDepset creates a new Depset. It checks that direct elements are hashable and that all the
+// elements have the same type, and that the orders of the transitive depsets are compatible.
+func NewDepset(order DepsetOrder, direct []starlark.Value, transitive []*Depset) (*Depset,
+	error) {
+
+	if !order.IsValid() {
+		return nil, fmt.Errorf("invalid order: %v", order)
+	}
+
+	rv := &Depset{order: order, id: atomic.AddUint32(&depsetIdCounter, 1)}
+
+	for _, elem := range direct {
+		if _, err := elem.Hash(); err != nil {
+			return nil, fmt.Errorf("depset elements must not be mutable values")
+		}
+		if rv.elemType == "" {
+			rv.elemType = elem.Type()
+		} else if elem.Type() != rv.elemType {
+			return nil, fmt.Errorf("cannot add an item of type '%v' to a depset of "+
+				"'%v'", elem.Type(), rv.elemType)
+		}
+		elem.Freeze()
+		rv.direct = append(rv.direct, elem)
+	}
+
+	for _, t := range transitive {
+		if !order.isCompatibleWith(t.order) {
+			return nil, fmt.Errorf("order mismatch: %v != %v", t.order, order)
+		}
+		if t.IsEmpty() {
+			continue
+		}
+		if rv.elemType == "" {
+			rv.elemType = t.elemType
+		} else if t.elemType != rv.elemType {
+			return nil, fmt.Errorf("cannot add depset of type '%v' to a depset of '%v'",
+				t.elemType, rv.elemType)
+		}
+		rv.transitive = append(rv.transitive, t)
+	}
+
+	return rv, nil
+}
+
+// Order returns the depset's order.
+func (self *Depset) Order() DepsetOrder {
+	return self.order
+}
+
+// IsEmpty returns whether the depset is empty.
+func (self *Depset) IsEmpty() bool {
+	return len(self.direct) == 0 && len(self.transitive) == 0
+}
+
+// ToList returns the elements of the depset, without duplicates, in its order.
+func (self *Depset) ToList() []starlark.Value {
+	rv := []starlark.Value{}
+	seenElems := &starlark.Dict{}
+	seenDepsets := map[*Depset]struct{}{}
+
+	add := func(elem starlark.Value) {
+		if _, found, _ := seenElems.Get(elem); !found {
+			seenElems.SetKey(elem, starlark.None)
+			rv = append(rv, elem)
+		}
+	}
+
+	var visit func(d *Depset)
+	switch self.order {
+	case DepsetOrderDefault, DepsetOrderPostorder:
+		visit = func(d *Depset) {
+			if _, seen := seenDepsets[d]; seen {
+				return
+			}
+			seenDepsets[d] = struct{}{}
+			for _, t := range d.transitive {
+				visit(t)
+			}
+			for _, elem := range d.direct {
+				add(elem)
+			}
+		}
+		visit(self)
+	case DepsetOrderPreorder:
+		visit = func(d *Depset) {
+			if _, seen := seenDepsets[d]; seen {
+				return
+			}
+			seenDepsets[d] = struct{}{}
+			for _, elem := range d.direct {
+				add(elem)
+			}
+			for _, t := range d.transitive {
+				visit(t)
+			}
+		}
+		visit(self)
+	case DepsetOrderTopological:
+		// This is the reverse of a postorder traversal in which transitive depsets and
+		// direct elements are visited right-to-left.
+		visit = func(d *Depset) {
+			if _, seen := seenDepsets[d]; seen {
+				return
+			}
+			seenDepsets[d] = struct{}{}
+			for i := len(d.transitive) - 1; i >= 0; i-- {
+				visit(d.transitive[i])
+			}
+			for i := len(d.direct) - 1; i >= 0; i-- {
+				add(d.direct[i])
+			}
+		}
+		visit(self)
+		for i, j := 0, len(rv)-1; i < j; i, j = i+1, j-1 {
+			rv[i], rv[j] = rv[j], rv[i]
+		}
+	default:
+		panic(self.order)
+	}
+	return rv
+}
+
+func (self *Depset) String() string {
+	elems := self.ToList()
+	elemStrings := make([]string, len(elems))
+	for i, elem := range elems {
+		elemStrings[i] = elem.String()
+	}
+	if self.order == DepsetOrderDefault {
+		return fmt.Sprintf("depset([%v])", strings.Join(elemStrings, ", "))
+	}
+	return fmt.Sprintf("depset([%v], order = %q)", strings.Join(elemStrings, ", "),
+		string(self.order))
+}
+
+func (self *Depset) Type() string {
+	return "depset"
+}
+
+// Freeze does nothing, since depsets are immutable (and their elements are frozen).
+func (self *Depset) Freeze() {}
+
+func (self *Depset) Truth() starlark.Bool {
+	return starlark.Bool(!self.IsEmpty())
+}
+
+func (self *Depset) Hash() (uint32, error) {
+	return self.id, nil
+}
+
+func (self *Depset) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "to_list":
+		return starlark.NewBuiltin("to_list", func(thread *starlark.Thread,
+			_ *starlark.Builtin, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			if err := starlark.UnpackPositionalArgs("to_list", args, kwargs,
+				0); err != nil {
+				return nil, err
+			}
+			return starlark.NewList(self.ToList()), nil
+		}).BindReceiver(self), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *Depset) AttrNames() []string {
+	return []string{"to_list"}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values_test
+
+import (
+	"testing"
+
+	"go.starlark.net/starlark"
+
+	. "src.tricot.io/public/bazel2x/bazel/builtins/values"
+)
+
+func stringValues(s ...string) []starlark.Value {
+	rv := make([]starlark.Value, len(s))
+	for i, x := range s {
+		rv[i] = starlark.String(x)
+	}
+	return rv
+}
+
+func mustNewDepset(t *testing.T, order DepsetOrder, direct []starlark.Value,
+	transitive ...*Depset) *Depset {
+
+	d, err := NewDepset(order, direct, transitive)
+	if err != nil {
+		t.Fatal("NewDepset failed: ", err)
+	}
+	return d
+}
+
+func TestDepset_ToList(t *testing.T) {
+	testCases := []struct {
+		order DepsetOrder
+		out   string
+	}{
+		{DepsetOrderDefault, `["d", "e", "b", "c", "a"]`},
+		{DepsetOrderPostorder, `["d", "e", "b", "c", "a"]`},
+		{DepsetOrderPreorder, `["a", "b", "d", "e", "c"]`},
+		{DepsetOrderTopological, `["a", "b", "c", "d", "e"]`},
+	}
+	for _, testCase := range testCases {
+		// Diamond: a -> {b, c}; b -> de; c -> de.
+		de := mustNewDepset(t, testCase.order, stringValues("d", "e"))
+		b := mustNewDepset(t, testCase.order, stringValues("b"), de)
+		c := mustNewDepset(t, testCase.order, stringValues("c", "e"), de)
+		a := mustNewDepset(t, testCase.order, stringValues("a"), b, c)
+		if out := starlark.NewList(a.ToList()).String(); out != testCase.out {
+			t.Error(testCase.order, " should have resulted in ", testCase.out,
+				", but resulted in ", out)
+		}
+	}
+}
+
+func TestNewDepset_Errors(t *testing.T) {
+	if _, err := NewDepset("bogus", nil, nil); err == nil {
+		t.Error("invalid order should have resulted in error")
+	}
+	if _, err := NewDepset(DepsetOrderDefault,
+		[]starlark.Value{starlark.NewList(nil)}, nil); err == nil {
+		t.Error("mutable element should have resulted in error")
+	}
+	if _, err := NewDepset(DepsetOrderDefault,
+		[]starlark.Value{starlark.String("a"), starlark.MakeInt(1)}, nil); err == nil {
+		t.Error("mixed element types should have resulted in error")
+	}
+	preorder := mustNewDepset(t, DepsetOrderPreorder, stringValues("a"))
+	if _, err := NewDepset(DepsetOrderPostorder, nil,
+		[]*Depset{preorder}); err == nil {
+		t.Error("incompatible orders should have resulted in error")
+	}
+	if _, err := NewDepset(DepsetOrderDefault, nil, []*Depset{preorder}); err != nil {
+		t.Error("default order should be compatible with preorder: ", err)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// BinDir is the (emulated) path of the root of generated files, relative to the execution root.
+const BinDir = "bazel-out/bin"
+
+// File is a Bazel File value: a source file or a generated file (declared by a rule's
+// implementation function), identified by its label.
+type File struct {
+	label       core.Label
+	isSource    bool
+	isDirectory bool
+}
+
+var _ starlark.HasAttrs = (*File)(nil)
+var _ starlark.Comparable = (*File)(nil)
+
+// NewSourceFile creates a new File for the source file with the given label.
+func NewSourceFile(label core.Label) *File {
+	return &File{label: label, isSource: true}
+}
+
+// NewGeneratedFile creates a new File for the generated file (or directory) with the given label.
+func NewGeneratedFile(label core.Label, isDirectory bool) *File {
+	return &File{label: label, isDirectory: isDirectory}
+}
+
+// Label returns the file's label.
+func (self *File) Label() core.Label {
+	return self.label
+}
+
+// IsSource returns whether the file is a source file.
+func (self *File) IsSource() bool {
+	return self.isSource
+}
+
+// IsDirectory returns whether the file is a (generated) directory.
+func (self *File) IsDirectory() bool {
+	return self.isDirectory
+}
+
+// Root returns the path of the file's root, relative to the execution root ("" for source files
+// in the main workspace).
+func (self *File) Root() string {
+	if self.isSource {
+		return ""
+	}
+	return BinDir
+}
+
+// ShortPath returns the path of the file relative to its root (for files in external workspaces,
+// this is of the form "../<workspace>/<path>").
+func (self *File) ShortPath() string {
+	p := path.Join(string(self.label.Package), string(self.label.Target))
+	if self.label.IsExternal() {
+		return path.Join("..", string(self.label.Workspace), p)
+	}
+	return p
+}
+
+// Path returns the path of the file, relative to the execution root.
+func (self *File) Path() string {
+	p := path.Join(string(self.label.Package), string(self.label.Target))
+	if self.label.IsExternal() {
+		p = path.Join("external", string(self.label.Workspace), p)
+	}
+	return path.Join(self.Root(), p)
+}
+
+func (self *File) String() string {
+	if self.isSource {
+		return fmt.Sprintf("<source file %v>", self.ShortPath())
+	}
+	return fmt.Sprintf("<generated file %v>", self.ShortPath())
+}
+
+func (self *File) Type() string {
+	return "File"
+}
+
+// Freeze does nothing, since files are immutable.
+func (self *File) Freeze() {}
+
+func (self *File) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *File) Hash() (uint32, error) {
+	return starlark.String(self.Path()).Hash()
+}
+
+func (self *File) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	other, ok := y.(*File)
+	if !ok {
+		return compareDifferentTypes(op, self, y)
+	}
+	switch op {
+	case syntax.EQL:
+		return *self == *other, nil
+	case syntax.NEQ:
+		return *self != *other, nil
+	default:
+		return false, fmt.Errorf("%v %v %v not implemented", self.Type(), op, other.Type())
+	}
+}
+
+func (self *File) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "basename":
+		return starlark.String(path.Base(string(self.label.Target))), nil
+	case "dirname":
+		return starlark.String(path.Dir(self.Path())), nil
+	case "extension":
+		ext := path.Ext(string(self.label.Target))
+		return starlark.String(strings.TrimPrefix(ext, ".")), nil
+	case "is_directory":
+		return starlark.Bool(self.isDirectory), nil
+	case "is_source":
+		return starlark.Bool(self.isSource), nil
+	case "owner":
+		return NewLabel(self.label), nil
+	case "path":
+		return starlark.String(self.Path()), nil
+	case "root":
+		return starlarkstruct.FromStringDict(starlark.String("root"), starlark.StringDict{
+			"path": starlark.String(self.Root()),
+		}), nil
+	case "short_path":
+		return starlark.String(self.ShortPath()), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *File) AttrNames() []string {
+	return []string{"basename", "dirname", "extension", "is_directory", "is_source", "owner",
+		"path", "root", "short_path"}
+}
@@ -0,0 +1,37 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values_test
+
+import (
+	"testing"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	. "src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func TestFile_CompareWithSameNamedType(t *testing.T) {
+	file := NewSourceFile(core.Label{Package: "x", Target: "y.txt"})
+	// The instance's type is "File", like that of file.
+	instance := newExportedProviderInstance(t, "File")
+
+	if eq, err := starlark.Equal(file, instance); err != nil || eq {
+		t.Errorf("file == instance gave %v, %v; expected false", eq, err)
+	}
+	if ne, err := starlark.Compare(syntax.NEQ, file, instance); err != nil || !ne {
+		t.Errorf("file != instance gave %v, %v; expected true", ne, err)
+	}
+	if _, err := starlark.Compare(syntax.LT, file, instance); err == nil {
+		t.Errorf("file < instance unexpectedly succeeded")
+	}
+	if eq, err := starlark.Equal(file, NewSourceFile(file.Label())); err != nil || !eq {
+		t.Errorf("file == file gave %v, %v; expected true", eq, err)
+	}
+	generated := NewGeneratedFile(file.Label(), false)
+	if eq, err := starlark.Equal(file, generated); err != nil || eq {
+		t.Errorf("file == generated gave %v, %v; expected false", eq, err)
+	}
+}
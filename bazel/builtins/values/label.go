@@ -0,0 +1,135 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Label is a Bazel Label value (wrapping a core.Label).
+type Label struct {
+	label core.Label
+}
+
+var _ starlark.HasAttrs = (*Label)(nil)
+var _ starlark.Comparable = (*Label)(nil)
+
+// NewLabel creates a new Label for the given core.Label.
+func NewLabel(label core.Label) *Label {
+	return &Label{label}
+}
+
+// ParseRelativeLabel parses a label string relative to the given label's package (so that, e.g.,
+// "foo" and ":foo" are targets in that package, and "//foo" is in that label's workspace).
+func ParseRelativeLabel(relativeTo core.Label, s string) (core.Label, error) {
+	if !strings.HasPrefix(s, "@") && !strings.HasPrefix(s, "//") && !strings.HasPrefix(s, ":") {
+		s = ":" + s
+	}
+	return core.ParseLabel(relativeTo.Workspace, relativeTo.Package, s)
+}
+
+// Label returns the underlying core.Label.
+func (self *Label) Label() core.Label {
+	return self.label
+}
+
+func (self *Label) String() string {
+	return self.label.String()
+}
+
+func (self *Label) Type() string {
+	return "Label"
+}
+
+// Freeze does nothing, since labels are immutable.
+func (self *Label) Freeze() {}
+
+func (self *Label) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Label) Hash() (uint32, error) {
+	return starlark.String(self.label.String()).Hash()
+}
+
+func (self *Label) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	other, ok := y.(*Label)
+	if !ok {
+		return compareDifferentTypes(op, self, y)
+	}
+	switch op {
+	case syntax.EQL:
+		return self.label == other.label, nil
+	case syntax.NEQ:
+		return self.label != other.label, nil
+	default:
+		return false, fmt.Errorf("%v %v %v not implemented", self.Type(), op, other.Type())
+	}
+}
+
+func (self *Label) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "name":
+		return starlark.String(self.label.Target), nil
+	case "package":
+		return starlark.String(self.label.Package), nil
+	case "relative":
+		return starlark.NewBuiltin("relative", func(thread *starlark.Thread,
+			_ *starlark.Builtin, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			relName := ""
+			if err := starlark.UnpackPositionalArgs("relative", args, kwargs, 1,
+				&relName); err != nil {
+				return nil, err
+			}
+			label, err := ParseRelativeLabel(self.label, relName)
+			if err != nil {
+				return nil, fmt.Errorf("relative: %v", err)
+			}
+			return NewLabel(label), nil
+		}).BindReceiver(self), nil
+	case "same_package_label":
+		return starlark.NewBuiltin("same_package_label", func(thread *starlark.Thread,
+			_ *starlark.Builtin, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			targetName := ""
+			if err := starlark.UnpackArgs("same_package_label", args, kwargs,
+				"target_name", &targetName); err != nil {
+				return nil, err
+			}
+			label := core.Label{
+				Workspace: self.label.Workspace,
+				Package:   self.label.Package,
+				Target:    core.TargetName(targetName),
+			}
+			if !label.IsValid() {
+				return nil, fmt.Errorf("same_package_label: invalid target name %q",
+					targetName)
+			}
+			return NewLabel(label), nil
+		}).BindReceiver(self), nil
+	case "repo_name", "workspace_name":
+		return starlark.String(self.label.Workspace), nil
+	case "workspace_root":
+		if !self.label.IsExternal() {
+			return starlark.String(""), nil
+		}
+		return starlark.String("external/" + string(self.label.Workspace)), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (self *Label) AttrNames() []string {
+	return []string{"name", "package", "relative", "repo_name", "same_package_label",
+		"workspace_name", "workspace_root"}
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values_test
+
+import (
+	"testing"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	. "src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func TestParseRelativeLabel(t *testing.T) {
+	relativeTo := core.Label{Workspace: "ws", Package: "pkg", Target: "defs.bzl"}
+	testCases := []struct {
+		in  string
+		out string
+	}{
+		{"foo", "@ws//pkg:foo"},
+		{":foo", "@ws//pkg:foo"},
+		{"//other:bar", "@ws//other:bar"},
+		{"@x//other", "@x//other:other"},
+	}
+	for _, testCase := range testCases {
+		actual, err := ParseRelativeLabel(relativeTo, testCase.in)
+		if err != nil {
+			t.Errorf("ParseRelativeLabel(%q) failed: %v", testCase.in, err)
+		} else if actual.String() != testCase.out {
+			t.Errorf("ParseRelativeLabel(%q) = %v; expected %v", testCase.in, actual,
+				testCase.out)
+		}
+	}
+}
+
+func TestLabel_Methods(t *testing.T) {
+	label := NewLabel(core.Label{Package: "pkg", Target: "foo"})
+	for _, testCase := range []struct {
+		method string
+		arg    string
+		out    string
+	}{
+		{"relative", "bar", "//pkg:bar"},
+		{"relative", "//other:baz", "//other:baz"},
+		{"same_package_label", "qux", "//pkg:qux"},
+	} {
+		method, err := label.Attr(testCase.method)
+		if err != nil {
+			t.Fatal("Attr failed: ", err)
+		}
+		rv, err := starlark.Call(&starlark.Thread{}, method,
+			starlark.Tuple{starlark.String(testCase.arg)}, nil)
+		if err != nil {
+			t.Errorf("%v(%q) failed: %v", testCase.method, testCase.arg, err)
+		} else if rv.String() != testCase.out {
+			t.Errorf("%v(%q) = %v; expected %v", testCase.method, testCase.arg, rv,
+				testCase.out)
+		}
+	}
+}
+
+func TestLabel_CompareWithSameNamedType(t *testing.T) {
+	label := NewLabel(core.Label{Package: "x", Target: "y"})
+	// The instance's type is "Label", like that of label.
+	instance := newExportedProviderInstance(t, "Label")
+
+	if eq, err := starlark.Equal(label, instance); err != nil || eq {
+		t.Errorf("label == instance gave %v, %v; expected false", eq, err)
+	}
+	if ne, err := starlark.Compare(syntax.NEQ, label, instance); err != nil || !ne {
+		t.Errorf("label != instance gave %v, %v; expected true", ne, err)
+	}
+	if _, err := starlark.Compare(syntax.LT, label, instance); err == nil {
+		t.Errorf("label < instance unexpectedly succeeded")
+	}
+	if eq, err := starlark.Equal(label, NewLabel(label.Label())); err != nil || !eq {
+		t.Errorf("label == label gave %v, %v; expected true", eq, err)
+	}
+}
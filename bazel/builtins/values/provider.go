@@ -0,0 +1,143 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Exportable is implemented by values that get their names when they are exported from a .bzl
+// file (i.e., assigned to a global), such as providers.
+type Exportable interface {
+	starlark.Value
+
+	// IsExported returns whether the value has been exported.
+	IsExported() bool
+
+	// Export exports the value under the given name.
+	Export(name string)
+}
+
+// providerIdCounter is used to assign (unique) ids to providers, which are used for hashing.
+var providerIdCounter uint32
+
+// Provider is a provider (as created by provider()): a callable that constructs instances, which
+// are Structs.
+type Provider struct {
+	name string
+	doc  string
+
+	// fields are the allowed fields (in the order declared), or nil if any field is allowed.
+	fields []string
+
+	id uint32
+}
+
+var _ starlark.Callable = (*Provider)(nil)
+var _ Exportable = (*Provider)(nil)
+
+// NewProvider creates a new (unexported) Provider. If fields is nil, instances may have any
+// fields.
+func NewProvider(doc string, fields []string) *Provider {
+	return &Provider{doc: doc, fields: fields, id: atomic.AddUint32(&providerIdCounter, 1)}
+}
+
+// Doc returns the provider's documentation.
+func (self *Provider) Doc() string {
+	return self.doc
+}
+
+// Fields returns the provider's declared fields (or nil if any field is allowed).
+func (self *Provider) Fields() []string {
+	return self.fields
+}
+
+func (self *Provider) IsExported() bool {
+	return self.name != ""
+}
+
+func (self *Provider) Export(name string) {
+	self.name = name
+}
+
+func (self *Provider) String() string {
+	if !self.IsExported() {
+		return "<provider>"
+	}
+	return fmt.Sprintf("<provider %v>", self.name)
+}
+
+func (self *Provider) Type() string {
+	return "Provider"
+}
+
+// Freeze does nothing, since providers are immutable (other than being exported).
+func (self *Provider) Freeze() {}
+
+func (self *Provider) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Provider) Hash() (uint32, error) {
+	return self.id, nil
+}
+
+func (self *Provider) Name() string {
+	if !self.IsExported() {
+		return "<unexported provider>"
+	}
+	return self.name
+}
+
+func (self *Provider) CallInternal(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	ctx := core.GetContext(thread)
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%v: %v: unexpected positional arguments", ctx.Label(),
+			self.Name())
+	}
+	if self.fields != nil {
+		for _, kwarg := range kwargs {
+			field := string(kwarg[0].(starlark.String))
+			allowed := false
+			for _, f := range self.fields {
+				if f == field {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil, fmt.Errorf("%v: %v: unexpected keyword argument %v",
+					ctx.Label(), self.Name(), field)
+			}
+		}
+	}
+	rv, err := NewStruct(self, kwargs)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v: %v", ctx.Label(), self.Name(), err)
+	}
+	return rv, nil
+}
+
+// newBuiltinProvider creates a new (already exported) built-in provider.
+func newBuiltinProvider(name string, doc string, fields []string) *Provider {
+	rv := NewProvider(doc, fields)
+	rv.Export(name)
+	return rv
+}
+
+// DefaultInfo is the built-in provider giving the default outputs (files) of a target.
+var DefaultInfo = newBuiltinProvider("DefaultInfo",
+	"A provider that gives general information about a target's direct and transitive files.",
+	[]string{"files", "runfiles", "data_runfiles", "default_runfiles", "executable"})
+
+// OutputGroupInfo is the built-in provider giving named groups of outputs of a target.
+var OutputGroupInfo = newBuiltinProvider("OutputGroupInfo",
+	"A provider that indicates what output groups a rule has.", nil)
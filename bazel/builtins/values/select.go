@@ -0,0 +1,145 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Selector is a single select() (i.e., a dictionary of conditions to values).
+type Selector struct {
+	// Conditions maps conditions (labels, as strings) to values. It is frozen.
+	Conditions *starlark.Dict
+
+	// NoMatchError is the custom error message to report if no condition matches.
+	NoMatchError string
+}
+
+// valueType returns the type of the values of the select() (which should all be the same, if
+// there are any values other than None).
+func (self *Selector) valueType() string {
+	for _, item := range self.Conditions.Items() {
+		if item[1] != starlark.None {
+			return item[1].Type()
+		}
+	}
+	return ""
+}
+
+func (self *Selector) String() string {
+	if self.NoMatchError == "" {
+		return fmt.Sprintf("select(%v)", self.Conditions)
+	}
+	return fmt.Sprintf("select(%v, no_match_error = %q)", self.Conditions, self.NoMatchError)
+}
+
+// SelectPart is a part of a Select: either a Selector or a plain value.
+type SelectPart struct {
+	// Selector is the select(), or nil if this is a plain value.
+	Selector *Selector
+
+	// Value is the plain value (only valid if Selector is nil).
+	Value starlark.Value
+}
+
+func (self SelectPart) valueType() string {
+	if self.Selector != nil {
+		return self.Selector.valueType()
+	}
+	return self.Value.Type()
+}
+
+func (self SelectPart) String() string {
+	if self.Selector != nil {
+		return self.Selector.String()
+	}
+	return self.Value.String()
+}
+
+// Select is the value of a select() or of a concatenation (using + or |) of select()s and other
+// values, e.g., select({...}) + [...].
+type Select struct {
+	Parts []SelectPart
+}
+
+var _ starlark.HasBinary = (*Select)(nil)
+
+// NewSelect creates a Select consisting of the single select() given by conditions (which is
+// frozen) and noMatchError.
+func NewSelect(conditions *starlark.Dict, noMatchError string) *Select {
+	conditions.Freeze()
+	return &Select{[]SelectPart{{Selector: &Selector{conditions, noMatchError}}}}
+}
+
+func (self *Select) String() string {
+	parts := make([]string, len(self.Parts))
+	for i, part := range self.Parts {
+		parts[i] = part.String()
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (self *Select) Type() string {
+	return "select"
+}
+
+func (self *Select) Freeze() {
+	for _, part := range self.Parts {
+		if part.Selector == nil {
+			part.Value.Freeze()
+		}
+	}
+}
+
+func (self *Select) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *Select) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: select")
+}
+
+// valueType returns the type of the values in the Select (or "" if unknown).
+func (self *Select) valueType() string {
+	for _, part := range self.Parts {
+		if t := part.valueType(); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+func (self *Select) Binary(op syntax.Token, y starlark.Value, side starlark.Side) (starlark.Value,
+	error) {
+
+	if op != syntax.PLUS && op != syntax.PIPE {
+		return nil, nil
+	}
+
+	var yParts []SelectPart
+	yType := y.Type()
+	if ySelect, ok := y.(*Select); ok {
+		yParts = ySelect.Parts
+		yType = ySelect.valueType()
+	} else {
+		yParts = []SelectPart{{Value: y}}
+	}
+
+	if xType := self.valueType(); xType != "" && yType != "" && xType != yType {
+		return nil, fmt.Errorf("cannot combine incompatible types (select of %v, %v)",
+			xType, yType)
+	}
+
+	parts := make([]SelectPart, 0, len(self.Parts)+len(yParts))
+	if side == starlark.Left {
+		parts = append(append(parts, self.Parts...), yParts...)
+	} else {
+		parts = append(append(parts, yParts...), self.Parts...)
+	}
+	return &Select{parts}, nil
+}
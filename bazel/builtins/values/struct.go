@@ -0,0 +1,305 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// structMethodNames are the names of the (built-in) methods of structs, which may not be used as
+// field names.
+var structMethodNames = []string{"to_json", "to_proto"}
+
+// Struct is a Bazel struct (or an instance of a provider), i.e., a starlarkstruct.Struct with the
+// to_json and to_proto methods.
+type Struct struct {
+	*starlarkstruct.Struct
+}
+
+var _ starlark.HasAttrs = (*Struct)(nil)
+var _ starlark.HasBinary = (*Struct)(nil)
+var _ starlark.Comparable = (*Struct)(nil)
+
+// NewStruct creates a new Struct with the given fields. The constructor should be
+// starlarkstruct.Default for plain structs, or the *Provider for provider instances.
+func NewStruct(constructor starlark.Value, kwargs []starlark.Tuple) (*Struct, error) {
+	for _, kwarg := range kwargs {
+		for _, methodName := range structMethodNames {
+			if string(kwarg[0].(starlark.String)) == methodName {
+				return nil, fmt.Errorf("cannot override built-in struct "+
+					"function '%v'", methodName)
+			}
+		}
+	}
+	return &Struct{starlarkstruct.FromKeywords(constructor, kwargs)}, nil
+}
+
+// Provider returns the provider of which the struct is an instance, or nil if it is a plain
+// struct.
+func (self *Struct) Provider() *Provider {
+	provider, _ := self.Constructor().(*Provider)
+	return provider
+}
+
+func (self *Struct) String() string {
+	fields := []string{}
+	for _, name := range self.fieldNames() {
+		fieldValue, _ := self.Struct.Attr(name)
+		fields = append(fields, fmt.Sprintf("%v = %v", name, fieldValue))
+	}
+	return fmt.Sprintf("%v(%v)", self.Type(), strings.Join(fields, ", "))
+}
+
+func (self *Struct) Type() string {
+	if provider := self.Provider(); provider != nil && provider.IsExported() {
+		return provider.Name()
+	}
+	return "struct"
+}
+
+func (self *Struct) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "to_json":
+		return starlark.NewBuiltin("to_json", func(thread *starlark.Thread,
+			_ *starlark.Builtin, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			if err := starlark.UnpackPositionalArgs("to_json", args, kwargs,
+				0); err != nil {
+				return nil, err
+			}
+			buf := &bytes.Buffer{}
+			if err := writeJSON(buf, self); err != nil {
+				return nil, err
+			}
+			return starlark.String(buf.String()), nil
+		}).BindReceiver(self), nil
+	case "to_proto":
+		return starlark.NewBuiltin("to_proto", func(thread *starlark.Thread,
+			_ *starlark.Builtin, args starlark.Tuple,
+			kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			if err := starlark.UnpackPositionalArgs("to_proto", args, kwargs,
+				0); err != nil {
+				return nil, err
+			}
+			buf := &bytes.Buffer{}
+			if err := writeProtoFields(buf, self, 0); err != nil {
+				return nil, err
+			}
+			return starlark.String(buf.String()), nil
+		}).BindReceiver(self), nil
+	default:
+		return self.Struct.Attr(name)
+	}
+}
+
+func (self *Struct) AttrNames() []string {
+	return append(self.Struct.AttrNames(), structMethodNames...)
+}
+
+func (self *Struct) Binary(op syntax.Token, y starlark.Value,
+	side starlark.Side) (starlark.Value, error) {
+
+	other, ok := y.(*Struct)
+	if !ok {
+		return nil, nil
+	}
+	rv, err := self.Struct.Binary(op, other.Struct, side)
+	if rv == nil || err != nil {
+		return rv, err
+	}
+	return &Struct{rv.(*starlarkstruct.Struct)}, nil
+}
+
+// compareDifferentTypes compares values whose types have the same name but which are different
+// (e.g., a Label and an instance of a provider exported as "Label"): they are never equal, and
+// can't be ordered.
+func compareDifferentTypes(op syntax.Token, x starlark.Value, y starlark.Value) (bool, error) {
+	switch op {
+	case syntax.EQL:
+		return false, nil
+	case syntax.NEQ:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%v %v %v not implemented", x.Type(), op, y.Type())
+	}
+}
+
+func (self *Struct) CompareSameType(op syntax.Token, y starlark.Value,
+	depth int) (bool, error) {
+
+	other, ok := y.(*Struct)
+	if !ok {
+		return compareDifferentTypes(op, self, y)
+	}
+	return self.Struct.CompareSameType(op, other.Struct, depth)
+}
+
+// fieldNames returns the (sorted) names of the fields of the struct (excluding methods).
+func (self *Struct) fieldNames() []string {
+	return self.Struct.AttrNames()
+}
+
+// writeJSONString writes s as a JSON string.
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	// Remove the newline added by Encode.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// writeJSON writes the JSON encoding of value (for struct.to_json()).
+func writeJSON(buf *bytes.Buffer, value starlark.Value) error {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		buf.WriteString("null")
+	case starlark.Bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case starlark.Int:
+		buf.WriteString(v.String())
+	case starlark.Float:
+		// Floats are written as JSON numbers (which excludes NaN and infinities).
+		b, err := json.Marshal(float64(v))
+		if err != nil {
+			return fmt.Errorf("invalid float value %v for to_json", v)
+		}
+		buf.Write(b)
+	case starlark.String:
+		return writeJSONString(buf, string(v))
+	case *Struct:
+		buf.WriteString("{")
+		for i, name := range v.fieldNames() {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSONString(buf, name); err != nil {
+				return err
+			}
+			buf.WriteString(":")
+			fieldValue, _ := v.Struct.Attr(name)
+			if err := writeJSON(buf, fieldValue); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case *starlark.Dict:
+		buf.WriteString("{")
+		for i, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return fmt.Errorf("keys must be strings, not %v", item[0].Type())
+			}
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSONString(buf, string(key)); err != nil {
+				return err
+			}
+			buf.WriteString(":")
+			if err := writeJSON(buf, item[1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case starlark.Indexable: // List or Tuple.
+		buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		return fmt.Errorf("invalid value of type %v for to_json", value.Type())
+	}
+	return nil
+}
+
+// writeProtoFields writes the fields of a struct in text protocol buffer format (for
+// struct.to_proto()).
+func writeProtoFields(buf *bytes.Buffer, s *Struct, indent int) error {
+	for _, name := range s.fieldNames() {
+		fieldValue, _ := s.Struct.Attr(name)
+		if err := writeProtoField(buf, name, fieldValue, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProtoField writes a single field in text protocol buffer format. Lists result in repeated
+// fields, and dicts in repeated messages with key and value fields.
+func writeProtoField(buf *bytes.Buffer, name string, value starlark.Value, indent int) error {
+	prefix := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case starlark.NoneType:
+		// Unset fields are omitted.
+	case starlark.Bool:
+		if v {
+			fmt.Fprintf(buf, "%v%v: true\n", prefix, name)
+		} else {
+			fmt.Fprintf(buf, "%v%v: false\n", prefix, name)
+		}
+	case starlark.Int:
+		fmt.Fprintf(buf, "%v%v: %v\n", prefix, name, v)
+	case starlark.String:
+		fmt.Fprintf(buf, "%v%v: ", prefix, name)
+		if err := writeJSONString(buf, string(v)); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+	case *Struct:
+		fmt.Fprintf(buf, "%v%v {\n", prefix, name)
+		if err := writeProtoFields(buf, v, indent+1); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%v}\n", prefix)
+	case *starlark.Dict:
+		for _, item := range v.Items() {
+			fmt.Fprintf(buf, "%v%v {\n", prefix, name)
+			if err := writeProtoField(buf, "key", item[0], indent+1); err != nil {
+				return err
+			}
+			if err := writeProtoField(buf, "value", item[1], indent+1); err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%v}\n", prefix)
+		}
+	case starlark.Indexable: // List or Tuple.
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			if _, isList := elem.(starlark.Indexable); isList {
+				if _, isString := elem.(starlark.String); !isString {
+					return fmt.Errorf("invalid value for field %v: nested "+
+						"lists are not allowed", name)
+				}
+			}
+			if err := writeProtoField(buf, name, elem, indent); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid value of type %v for field %v", value.Type(), name)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package values_test
+
+import (
+	"testing"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	. "src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func mustNewStruct(t *testing.T, kwargs ...starlark.Tuple) *Struct {
+	s, err := NewStruct(starlarkstruct.Default, kwargs)
+	if err != nil {
+		t.Fatal("NewStruct failed: ", err)
+	}
+	return s
+}
+
+func callMethod(t *testing.T, value starlark.HasAttrs, name string) string {
+	method, err := value.Attr(name)
+	if err != nil {
+		t.Fatal("Attr failed: ", err)
+	}
+	rv, err := starlark.Call(&starlark.Thread{}, method, nil, nil)
+	if err != nil {
+		t.Fatal(name, " failed: ", err)
+	}
+	return string(rv.(starlark.String))
+}
+
+func TestStruct_ToJSONAndToProto(t *testing.T) {
+	s := mustNewStruct(t,
+		starlark.Tuple{starlark.String("name"), starlark.String("a\"b")},
+		starlark.Tuple{starlark.String("copts"),
+			starlark.NewList(stringValues("-O2", "-g"))},
+		starlark.Tuple{starlark.String("inner"), mustNewStruct(t,
+			starlark.Tuple{starlark.String("on"), starlark.True})},
+		starlark.Tuple{starlark.String("n"), starlark.MakeInt(3)},
+	)
+
+	jsonOut := `{"copts":["-O2","-g"],"inner":{"on":true},"n":3,"name":"a\"b"}`
+	if out := callMethod(t, s, "to_json"); out != jsonOut {
+		t.Error("to_json should have resulted in ", jsonOut, ", but resulted in ", out)
+	}
+
+	protoOut := "copts: \"-O2\"\ncopts: \"-g\"\ninner {\n  on: true\n}\nn: 3\n" +
+		"name: \"a\\\"b\"\n"
+	if out := callMethod(t, s, "to_proto"); out != protoOut {
+		t.Error("to_proto should have resulted in ", protoOut, ", but resulted in ", out)
+	}
+}
+
+func TestStruct_ToJSONFloat(t *testing.T) {
+	s := mustNewStruct(t,
+		starlark.Tuple{starlark.String("x"), starlark.Float(1.5)},
+		starlark.Tuple{starlark.String("y"), starlark.NewList([]starlark.Value{
+			starlark.Float(-0.25), starlark.Float(1e21)})},
+	)
+
+	jsonOut := `{"x":1.5,"y":[-0.25,1e+21]}`
+	if out := callMethod(t, s, "to_json"); out != jsonOut {
+		t.Error("to_json should have resulted in ", jsonOut, ", but resulted in ", out)
+	}
+}
+
+// newExportedProviderInstance creates an instance (with a = 1) of a provider exported with the
+// given name.
+func newExportedProviderInstance(t *testing.T, name string) starlark.Value {
+	provider := NewProvider("", nil)
+	provider.Export(name)
+	rv, err := NewStruct(provider,
+		[]starlark.Tuple{{starlark.String("a"), starlark.MakeInt(1)}})
+	if err != nil {
+		t.Fatal("NewStruct failed: ", err)
+	}
+	return rv
+}
+
+func TestStruct_CompareWithSameNamedType(t *testing.T) {
+	// The instance's type is "Label", like that of label.
+	instance := newExportedProviderInstance(t, "Label")
+	label := NewLabel(core.Label{Package: "x", Target: "y"})
+	if instance.Type() != label.Type() {
+		t.Fatalf("instance type is %v, not %v", instance.Type(), label.Type())
+	}
+
+	if eq, err := starlark.Equal(instance, label); err != nil || eq {
+		t.Errorf("instance == label gave %v, %v; expected false", eq, err)
+	}
+	if ne, err := starlark.Compare(syntax.NEQ, instance, label); err != nil || !ne {
+		t.Errorf("instance != label gave %v, %v; expected true", ne, err)
+	}
+	if _, err := starlark.Compare(syntax.LT, instance, label); err == nil {
+		t.Errorf("instance < label unexpectedly succeeded")
+	}
+	if eq, err := starlark.Equal(instance, instance); err != nil || !eq {
+		t.Errorf("instance == instance gave %v, %v; expected true", eq, err)
+	}
+
+	// Likewise for a plain struct and a starlarkstruct.Struct (both of type "struct").
+	plain := mustNewStruct(t)
+	other := starlarkstruct.FromStringDict(starlarkstruct.Default, nil)
+	if eq, err := starlark.Equal(plain, other); err != nil || eq {
+		t.Errorf("plain == other gave %v, %v; expected false", eq, err)
+	}
+}
+
+func TestNewStruct_Errors(t *testing.T) {
+	if _, err := NewStruct(starlarkstruct.Default, []starlark.Tuple{
+		{starlark.String("to_json"), starlark.True}}); err == nil {
+		t.Error("field to_json should have resulted in error")
+	}
+}
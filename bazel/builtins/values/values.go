@@ -0,0 +1,5 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package values contains implementations of Bazel-specific starlark values (i.e., types).
+package values // import "src.tricot.io/public/bazel2x/bazel/builtins/values"
@@ -9,11 +9,18 @@ import (
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-// NotImplemented is used for Bazel workspace rules that we haven't implemented (yet).
+// NotImplemented is used for Bazel workspace rules that we haven't implemented (yet). Calls are
+// recorded (see core.RecordNotImplementedCall).
 func NotImplemented(ruleName string) *starlark.Builtin {
-	return newWorkspaceRule(ruleName, func(ctx core.Context, args starlark.Tuple,
-		kwargs []starlark.Tuple) error {
+	return starlark.NewBuiltin(ruleName, func(thread *starlark.Thread, _ *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
-		return nil
+		if err := core.RecordNotImplementedCall(thread, ruleName); err != nil {
+			return starlark.None, err
+		}
+		return callWorkspaceRule(thread, ruleName, args, kwargs,
+			func(ctx core.Context, args starlark.Tuple, kwargs []starlark.Tuple) error {
+				return nil
+			})
 	})
 }
@@ -0,0 +1,198 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package workspace_rules // import "src.tricot.io/public/bazel2x/bazel/builtins/workspace_rules"
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.starlark.net/starlark"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// starlarkRepositoryRuleIdCounter is used to assign (unique) ids to user-defined repository
+// rules, which are used for hashing.
+var starlarkRepositoryRuleIdCounter uint32
+
+// StarlarkRepositoryRule is a user-defined repository rule (as created by repository_rule()),
+// which is callable from WORKSPACE files. Calls are checked against its declared attributes, but
+// repositories aren't actually fetched (yet).
+type StarlarkRepositoryRule struct {
+	name string
+
+	implementation starlark.Callable
+
+	// attrNames are the names of the declared attributes (in the order declared).
+	attrNames []string
+	attrs     map[string]*values.Attr
+
+	local     bool
+	environ   []string
+	configure bool
+	doc       string
+
+	id uint32
+}
+
+var _ starlark.Callable = (*StarlarkRepositoryRule)(nil)
+var _ values.Exportable = (*StarlarkRepositoryRule)(nil)
+
+// NewStarlarkRepositoryRule creates a new (unexported) StarlarkRepositoryRule, with the given
+// declared attributes.
+func NewStarlarkRepositoryRule(implementation starlark.Callable, attrNames []string,
+	attrs []*values.Attr, local bool, environ []string, configure bool,
+	doc string) (*StarlarkRepositoryRule, error) {
+
+	rv := &StarlarkRepositoryRule{
+		implementation: implementation,
+		attrs:          make(map[string]*values.Attr, len(attrs)),
+		local:          local,
+		environ:        environ,
+		configure:      configure,
+		doc:            doc,
+		id:             atomic.AddUint32(&starlarkRepositoryRuleIdCounter, 1),
+	}
+	for i, attrName := range attrNames {
+		if attrName == "name" {
+			return nil, fmt.Errorf("there is already a built-in attribute name, " +
+				"which cannot be overridden")
+		}
+		if _, ok := rv.attrs[attrName]; ok {
+			return nil, fmt.Errorf("duplicate attribute %v", attrName)
+		}
+		rv.attrNames = append(rv.attrNames, attrName)
+		rv.attrs[attrName] = attrs[i]
+	}
+	return rv, nil
+}
+
+// Implementation returns the repository rule's implementation function.
+func (self *StarlarkRepositoryRule) Implementation() starlark.Callable {
+	return self.implementation
+}
+
+// AttrNames returns the names of the repository rule's declared attributes (in the order
+// declared).
+func (self *StarlarkRepositoryRule) AttrNames() []string {
+	return self.attrNames
+}
+
+// Attr returns the schema of the given declared attribute (or nil if there is no such attribute).
+func (self *StarlarkRepositoryRule) Attr(attrName string) *values.Attr {
+	return self.attrs[attrName]
+}
+
+// IsLocal returns whether the repository rule is local (i.e., always refetched).
+func (self *StarlarkRepositoryRule) IsLocal() bool {
+	return self.local
+}
+
+// Environ returns the environment variables on which the repository rule depends.
+func (self *StarlarkRepositoryRule) Environ() []string {
+	return self.environ
+}
+
+// IsConfigure returns whether the repository rule inspects the system for configuration.
+func (self *StarlarkRepositoryRule) IsConfigure() bool {
+	return self.configure
+}
+
+// Doc returns the repository rule's documentation.
+func (self *StarlarkRepositoryRule) Doc() string {
+	return self.doc
+}
+
+func (self *StarlarkRepositoryRule) IsExported() bool {
+	return self.name != ""
+}
+
+func (self *StarlarkRepositoryRule) Export(name string) {
+	self.name = name
+}
+
+func (self *StarlarkRepositoryRule) String() string {
+	if !self.IsExported() {
+		return "<repository_rule>"
+	}
+	return fmt.Sprintf("<repository_rule %v>", self.name)
+}
+
+func (self *StarlarkRepositoryRule) Type() string {
+	return "repository_rule"
+}
+
+// Freeze does nothing, since repository rules are immutable (other than being exported).
+func (self *StarlarkRepositoryRule) Freeze() {}
+
+func (self *StarlarkRepositoryRule) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (self *StarlarkRepositoryRule) Hash() (uint32, error) {
+	return self.id, nil
+}
+
+func (self *StarlarkRepositoryRule) Name() string {
+	if !self.IsExported() {
+		return "<unexported repository_rule>"
+	}
+	return self.name
+}
+
+func (self *StarlarkRepositoryRule) CallInternal(thread *starlark.Thread, args starlark.Tuple,
+	kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	return callWorkspaceRule(thread, self.Name(), args, kwargs, self.checkArgs)
+}
+
+// checkArgs checks the arguments of a call to the repository rule against its declared
+// attributes.
+//
+// TODO(vtl): Actually fetch the repository (i.e., run the implementation function).
+func (self *StarlarkRepositoryRule) checkArgs(ctx core.Context, args starlark.Tuple,
+	kwargs []starlark.Tuple) error {
+
+	if !self.IsExported() {
+		return fmt.Errorf("invalid repository rule hasn't been exported by a .bzl file")
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("all arguments should be passed as keyword arguments")
+	}
+
+	given := map[string]bool{}
+	for _, kwarg := range kwargs {
+		argName := string(kwarg[0].(starlark.String))
+		given[argName] = true
+		if argName == "name" {
+			name, ok := kwarg[1].(starlark.String)
+			if !ok || !core.WorkspaceName(name).IsValid() {
+				return fmt.Errorf("argument name invalid: %v is not a valid "+
+					"workspace name", kwarg[1])
+			}
+			continue
+		}
+		if argName == "repo_mapping" {
+			continue
+		}
+		attr, ok := self.attrs[argName]
+		if !ok || strings.HasPrefix(argName, "_") {
+			return fmt.Errorf("unknown keyword argument %v", argName)
+		}
+		if err := attr.CheckValue(kwarg[1]); err != nil {
+			return fmt.Errorf("argument %v invalid: %v", argName, err)
+		}
+	}
+	if !given["name"] {
+		return fmt.Errorf("target argument name required")
+	}
+	for _, attrName := range self.attrNames {
+		if self.attrs[attrName].Mandatory && !given[attrName] {
+			return fmt.Errorf("target argument %v required", attrName)
+		}
+	}
+	return nil
+}
@@ -13,26 +13,35 @@ import (
 	"src.tricot.io/public/bazel2x/bazel/core"
 )
 
-// TODO(vtl): Mostly copy-pasta of rules.newRule.
+// callWorkspaceRule calls a workspace rule implementation on the given thread.
+//
+// TODO(vtl): Mostly copy-pasta of rules.callRule.
+func callWorkspaceRule(thread *starlark.Thread, ruleName string, args starlark.Tuple,
+	kwargs []starlark.Tuple, impl func(ctx core.Context, args starlark.Tuple,
+		kwargs []starlark.Tuple) error) (starlark.Value, error) {
+
+	ctx := core.GetContext(thread)
+
+	if ctx.FileType() != core.FileTypeWorkspace {
+		return starlark.None, fmt.Errorf(
+			"%v: %v: workspace rule can only be called from a WORKSPACE file",
+			ctx.Label(), ruleName)
+	}
+
+	err := impl(ctx, args, kwargs)
+	if err != nil {
+		return starlark.None, fmt.Errorf("%v: %v: %v", ctx.Label(), ruleName, err)
+	}
+
+	return starlark.None, nil
+}
+
 func newWorkspaceRule(ruleName string, impl func(ctx core.Context, args starlark.Tuple,
 	kwargs []starlark.Tuple) error) *starlark.Builtin {
 
 	return starlark.NewBuiltin(ruleName, func(thread *starlark.Thread, _ *starlark.Builtin,
 		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
-		ctx := core.GetContext(thread)
-
-		if ctx.FileType() != core.FileTypeWorkspace {
-			return starlark.None, fmt.Errorf(
-				"%v: %v: workspace rule can only be called from a WORKSPACE file",
-				ctx.Label(), ruleName)
-		}
-
-		err := impl(ctx, args, kwargs)
-		if err != nil {
-			return starlark.None, fmt.Errorf("%v: %v: %v", ctx.Label(), ruleName, err)
-		}
-
-		return starlark.None, nil
+		return callWorkspaceRule(thread, ruleName, args, kwargs, impl)
 	})
 }
@@ -0,0 +1,369 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel // import "src.tricot.io/public/bazel2x/bazel"
+
+import (
+	"fmt"
+	"strings"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// Configuration describes a build configuration (roughly, the command-line options given to
+// Bazel), under which select()s are resolved.
+type Configuration struct {
+	// Platform is the label of the target platform (like --platforms), e.g.,
+	// "//build:linux_arm". Its constraint values (including those inherited from its parents)
+	// are part of the configuration. If empty, there is no target platform.
+	Platform string `json:"platform"`
+
+	// ConstraintValues are labels of additional constraint values of the target platform (which
+	// take precedence over those of Platform).
+	ConstraintValues []string `json:"constraintValues"`
+
+	// Values are the values of native options (as matched by the values attribute of
+	// config_setting), e.g., "cpu" or "compilation_mode". If not specified, "compilation_mode"
+	// is "fastbuild".
+	Values map[string]string `json:"values"`
+
+	// Defines are the --define values.
+	Defines map[string]string `json:"defines"`
+
+	// FlagValues are the values of custom (build setting) flags, keyed by label.
+	FlagValues map[string]string `json:"flagValues"`
+
+	// MakeVariables are the values of additional "Make" variables, or of predefined ones
+	// (e.g., toolchain variables like "CC") to override their defaults, for expansion in
+	// attributes (see MakeVariableExpander).
+	MakeVariables map[string]string `json:"makeVariables"`
+}
+
+// configurationMatcher implements core.ConditionMatcher for a given configuration.
+type configurationMatcher struct {
+	build         *Build
+	configuration *Configuration
+
+	// constraintValues maps constraint settings to constraint values (as determined by the
+	// target platform).
+	constraintValues map[core.Label]core.Label
+}
+
+var _ core.ConditionMatcher = (*configurationMatcher)(nil)
+
+// getTarget gets the target with the given label, which must exist.
+func (self *Build) getTarget(label core.Label) (core.Target, error) {
+	if packageTargets, ok := self.BuildTargets[label.Workspace][label.Package]; ok {
+		if target, ok := packageTargets.TargetsByName[label.Target]; ok {
+			return target, nil
+		}
+	}
+	return nil, fmt.Errorf("no such target %v", label)
+}
+
+func (self *Build) parseConfigurationLabel(s string) (core.Label, error) {
+	label, err := core.ParseLabel(core.MainWorkspaceName, "", s)
+	if err != nil {
+		return core.Label{}, fmt.Errorf("invalid configuration: %v", err)
+	}
+	return label, nil
+}
+
+// platformsWorkspaceName is the name of the workspace of Bazel's standard constraint settings and
+// values, whose @platforms//os and @platforms//cpu constraint values we handle natively (without
+// the workspace being loaded).
+const platformsWorkspaceName core.WorkspaceName = "platforms"
+
+// platformsConstraintSetting returns the constraint setting of the given label, if it is a
+// constraint value in @platforms//os or @platforms//cpu (e.g., @platforms//os:os for
+// @platforms//os:linux) that isn't otherwise defined.
+func (self *Build) platformsConstraintSetting(label core.Label) (core.Label, bool) {
+	if label.Workspace != platformsWorkspaceName ||
+		(label.Package != "os" && label.Package != "cpu") ||
+		string(label.Target) == string(label.Package) {
+		return core.Label{}, false
+	}
+	if _, err := self.getTarget(label); err == nil {
+		return core.Label{}, false
+	}
+	constraintSetting := core.Label{
+		Workspace: platformsWorkspaceName,
+		Package:   label.Package,
+		Target:    core.TargetName(label.Package),
+	}
+	return constraintSetting, true
+}
+
+// getConstraintSetting gets the constraint setting of the given constraint value.
+func (self *Build) getConstraintSetting(constraintValue core.Label) (core.Label, error) {
+	if constraintSetting, ok := self.platformsConstraintSetting(constraintValue); ok {
+		return constraintSetting, nil
+	}
+	target, err := self.getTarget(constraintValue)
+	if err != nil {
+		return core.Label{}, err
+	}
+	constraintValueTarget, ok := target.(*rules.ConstraintValueTarget)
+	if !ok {
+		return core.Label{}, fmt.Errorf("%v is not a constraint_value", constraintValue)
+	}
+	return *constraintValueTarget.ConstraintSetting, nil
+}
+
+// addPlatformConstraintValues adds the constraint values of the given platform (and its parents) to
+// constraintValues (unless already set, since child platforms take precedence). visited contains
+// the platforms already visited (to detect cycles).
+func (self *Build) addPlatformConstraintValues(platform core.Label,
+	constraintValues map[core.Label]core.Label, visited map[core.Label]bool) error {
+
+	if visited[platform] {
+		return fmt.Errorf("cycle in platform parents involving %v", platform)
+	}
+	visited[platform] = true
+
+	target, err := self.getTarget(platform)
+	if err != nil {
+		return err
+	}
+	platformTarget, ok := target.(*rules.PlatformTarget)
+	if !ok {
+		return fmt.Errorf("%v is not a platform", platform)
+	}
+
+	if platformTarget.ConstraintValues != nil {
+		for _, constraintValue := range *platformTarget.ConstraintValues {
+			constraintSetting, err := self.getConstraintSetting(constraintValue)
+			if err != nil {
+				return err
+			}
+			if _, alreadySet := constraintValues[constraintSetting]; !alreadySet {
+				constraintValues[constraintSetting] = constraintValue
+			}
+		}
+	}
+	if platformTarget.Parents != nil {
+		for _, parent := range *platformTarget.Parents {
+			err := self.addPlatformConstraintValues(parent, constraintValues, visited)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newConfigurationMatcher creates a configurationMatcher for the given configuration. It should
+// only be called once all the relevant BUILD files have been executed.
+func (self *Build) newConfigurationMatcher(
+	configuration *Configuration) (*configurationMatcher, error) {
+
+	constraintValues := map[core.Label]core.Label{}
+	for _, s := range configuration.ConstraintValues {
+		constraintValue, err := self.parseConfigurationLabel(s)
+		if err != nil {
+			return nil, err
+		}
+		constraintSetting, err := self.getConstraintSetting(constraintValue)
+		if err != nil {
+			return nil, err
+		}
+		constraintValues[constraintSetting] = constraintValue
+	}
+	if configuration.Platform != "" {
+		platform, err := self.parseConfigurationLabel(configuration.Platform)
+		if err != nil {
+			return nil, err
+		}
+		if err := self.addPlatformConstraintValues(platform, constraintValues,
+			map[core.Label]bool{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &configurationMatcher{
+		build:            self,
+		configuration:    configuration,
+		constraintValues: constraintValues,
+	}, nil
+}
+
+// value returns the value of the given native option.
+func (self *configurationMatcher) value(option string) (string, bool) {
+	if value, ok := self.configuration.Values[option]; ok {
+		return value, true
+	}
+	if option == "compilation_mode" {
+		return "fastbuild", true
+	}
+	return "", false
+}
+
+// hasConstraintValue returns whether the target platform has the given constraint value (possibly
+// as the default value of its constraint setting).
+func (self *configurationMatcher) hasConstraintValue(constraintValue core.Label) (bool, error) {
+	constraintSetting, err := self.build.getConstraintSetting(constraintValue)
+	if err != nil {
+		return false, err
+	}
+	if actual, ok := self.constraintValues[constraintSetting]; ok {
+		return actual == constraintValue, nil
+	}
+	if _, ok := self.build.platformsConstraintSetting(constraintValue); ok {
+		// The @platforms constraint settings have no default values.
+		return false, nil
+	}
+
+	target, err := self.build.getTarget(constraintSetting)
+	if err != nil {
+		return false, err
+	}
+	constraintSettingTarget, ok := target.(*rules.ConstraintSettingTarget)
+	if !ok {
+		return false, fmt.Errorf("%v is not a constraint_setting", constraintSetting)
+	}
+	defaultConstraintValue := constraintSettingTarget.DefaultConstraintValue
+	return defaultConstraintValue != nil && *defaultConstraintValue == constraintValue, nil
+}
+
+func notAConditionError(condition core.Label) error {
+	return fmt.Errorf("%v is not a config_setting or constraint_value", condition)
+}
+
+// settings returns the settings required by a condition (as strings), for the purposes of
+// determining specialization.
+func (self *configurationMatcher) settings(condition core.Label) (map[string]struct{}, error) {
+	rv := map[string]struct{}{}
+	if _, ok := self.build.platformsConstraintSetting(condition); ok {
+		rv["constraint:"+condition.String()] = struct{}{}
+		return rv, nil
+	}
+	target, err := self.build.getTarget(condition)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case *rules.ConfigSettingTarget:
+		if t.Values != nil {
+			for k, v := range *t.Values {
+				rv["values:"+k+"="+v] = struct{}{}
+			}
+		}
+		if t.DefineValues != nil {
+			for k, v := range *t.DefineValues {
+				rv["define:"+k+"="+v] = struct{}{}
+			}
+		}
+		if t.FlagValues != nil {
+			for k, v := range *t.FlagValues {
+				rv["flag:"+k.String()+"="+v] = struct{}{}
+			}
+		}
+		if t.ConstraintValues != nil {
+			for _, constraintValue := range *t.ConstraintValues {
+				rv["constraint:"+constraintValue.String()] = struct{}{}
+			}
+		}
+	case *rules.ConstraintValueTarget:
+		rv["constraint:"+condition.String()] = struct{}{}
+	default:
+		return nil, notAConditionError(condition)
+	}
+	return rv, nil
+}
+
+func (self *configurationMatcher) matchesConfigSetting(
+	configSetting *rules.ConfigSettingTarget) (bool, error) {
+
+	if configSetting.Values != nil {
+		for option, expected := range *configSetting.Values {
+			var actual string
+			var ok bool
+			if option == "define" {
+				// values = {"define": "foo=bar"} is the same as define_values =
+				// {"foo": "bar"}.
+				name, value := splitDefine(expected)
+				actual, ok = self.configuration.Defines[name]
+				expected = value
+			} else {
+				actual, ok = self.value(option)
+			}
+			if !ok || actual != expected {
+				return false, nil
+			}
+		}
+	}
+	if configSetting.DefineValues != nil {
+		for name, expected := range *configSetting.DefineValues {
+			actual, ok := self.configuration.Defines[name]
+			if !ok || actual != expected {
+				return false, nil
+			}
+		}
+	}
+	if configSetting.FlagValues != nil {
+		for flag, expected := range *configSetting.FlagValues {
+			// TODO(vtl): Unset flags should have their build_setting_default values.
+			actual, ok := self.configuration.FlagValues[flag.String()]
+			if !ok || actual != expected {
+				return false, nil
+			}
+		}
+	}
+	if configSetting.ConstraintValues != nil {
+		for _, constraintValue := range *configSetting.ConstraintValues {
+			has, err := self.hasConstraintValue(constraintValue)
+			if err != nil || !has {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func (self *configurationMatcher) Matches(condition core.Label) (bool, error) {
+	if _, ok := self.build.platformsConstraintSetting(condition); ok {
+		return self.hasConstraintValue(condition)
+	}
+	target, err := self.build.getTarget(condition)
+	if err != nil {
+		return false, err
+	}
+	switch t := target.(type) {
+	case *rules.ConfigSettingTarget:
+		return self.matchesConfigSetting(t)
+	case *rules.ConstraintValueTarget:
+		return self.hasConstraintValue(condition)
+	default:
+		return false, notAConditionError(condition)
+	}
+}
+
+func (self *configurationMatcher) Specializes(a core.Label, b core.Label) (bool, error) {
+	aSettings, err := self.settings(a)
+	if err != nil {
+		return false, err
+	}
+	bSettings, err := self.settings(b)
+	if err != nil {
+		return false, err
+	}
+	if len(aSettings) <= len(bSettings) {
+		return false, nil
+	}
+	for setting := range bSettings {
+		if _, ok := aSettings[setting]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// splitDefine splits a --define value of the form "name=value".
+func splitDefine(define string) (string, string) {
+	if i := strings.Index(define, "="); i >= 0 {
+		return define[:i], define[i+1:]
+	}
+	return define, ""
+}
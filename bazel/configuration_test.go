@@ -0,0 +1,312 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "src.tricot.io/public/bazel2x/bazel"
+	builtins_args "src.tricot.io/public/bazel2x/bazel/builtins/args"
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// configurationTestBuild is the BUILD file (for //conf) for the configuration tests.
+const configurationTestBuild = `
+config_setting(name = "opt", values = {"compilation_mode": "opt"})
+config_setting(name = "fastbuild", values = {"compilation_mode": "fastbuild"})
+config_setting(name = "opt_arm", values = {"compilation_mode": "opt", "cpu": "arm"})
+config_setting(name = "foo_define", define_values = {"foo": "1"})
+config_setting(name = "foo_values_define", values = {"define": "foo=1"})
+config_setting(name = "flag_on", flag_values = {":flag": "on"})
+filegroup(name = "flag")  # A stand-in for a build setting.
+
+constraint_setting(name = "os")
+constraint_value(name = "linux", constraint_setting = ":os")
+constraint_value(name = "mac", constraint_setting = ":os")
+constraint_setting(name = "gpu", default_constraint_value = ":no_gpu")
+constraint_value(name = "no_gpu", constraint_setting = ":gpu")
+constraint_value(name = "has_gpu", constraint_setting = ":gpu")
+config_setting(name = "linux_gpu", constraint_values = [":linux", ":has_gpu"])
+
+platform(name = "base", constraint_values = [":linux", ":has_gpu"])
+platform(name = "child", parents = [":base"], constraint_values = [":mac"])
+platform(name = "cycle_a", parents = [":cycle_b"])
+platform(name = "cycle_b", parents = [":cycle_a"])
+
+platform(name = "ext", constraint_values = ["@platforms//os:linux", "@platforms//cpu:x86_64"])
+config_setting(
+    name = "ext_linux_x86_64",
+    constraint_values = ["@platforms//os:linux", "@platforms//cpu:x86_64"],
+)
+`
+
+func TestBuild_ResolveConfigurables(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configuration Configuration
+		// sel is the dict given to select() for the copts of a cc_library.
+		sel string
+
+		// copts are the expected resolved copts, if err is empty, and otherwise err is a
+		// substring of the expected error.
+		copts []string
+		err   string
+	}{
+		{
+			name:  "default",
+			sel:   `{":opt": ["opt"], "//conditions:default": ["d"]}`,
+			copts: []string{"d"},
+		},
+		{
+			name: "values",
+			configuration: Configuration{
+				Values: map[string]string{"compilation_mode": "opt"},
+			},
+			sel:   `{":opt": ["opt"], "//conditions:default": ["d"]}`,
+			copts: []string{"opt"},
+		},
+		{
+			name:  "values with default compilation_mode",
+			sel:   `{":opt": ["opt"], ":fastbuild": ["fastbuild"]}`,
+			copts: []string{"fastbuild"},
+		},
+		{
+			name:          "define_values",
+			configuration: Configuration{Defines: map[string]string{"foo": "1"}},
+			sel:           `{":foo_define": ["foo"], "//conditions:default": ["d"]}`,
+			copts:         []string{"foo"},
+		},
+		{
+			name:          "define_values not matching",
+			configuration: Configuration{Defines: map[string]string{"foo": "2"}},
+			sel:           `{":foo_define": ["foo"], "//conditions:default": ["d"]}`,
+			copts:         []string{"d"},
+		},
+		{
+			name:          "values define",
+			configuration: Configuration{Defines: map[string]string{"foo": "1"}},
+			sel:           `{":foo_values_define": ["foo"]}`,
+			copts:         []string{"foo"},
+		},
+		{
+			name: "flag_values",
+			configuration: Configuration{
+				FlagValues: map[string]string{"//conf:flag": "on"},
+			},
+			sel:   `{":flag_on": ["on"], "//conditions:default": ["d"]}`,
+			copts: []string{"on"},
+		},
+		{
+			name: "specialization",
+			configuration: Configuration{
+				Values: map[string]string{"compilation_mode": "opt", "cpu": "arm"},
+			},
+			sel:   `{":opt": ["opt"], ":opt_arm": ["opt_arm"]}`,
+			copts: []string{"opt_arm"},
+		},
+		{
+			name: "specialization not matching",
+			configuration: Configuration{
+				Values: map[string]string{"compilation_mode": "opt"},
+			},
+			sel:   `{":opt": ["opt"], ":opt_arm": ["opt_arm"]}`,
+			copts: []string{"opt"},
+		},
+		{
+			name:          "platform",
+			configuration: Configuration{Platform: "//conf:base"},
+			sel:           `{":linux": ["linux"], ":mac": ["mac"]}`,
+			copts:         []string{"linux"},
+		},
+		{
+			name:          "platform parent overridden",
+			configuration: Configuration{Platform: "//conf:child"},
+			sel:           `{":linux": ["linux"], ":mac": ["mac"]}`,
+			copts:         []string{"mac"},
+		},
+		{
+			name:          "platform parent inherited",
+			configuration: Configuration{Platform: "//conf:child"},
+			sel:           `{":has_gpu": ["gpu"], ":no_gpu": ["no_gpu"]}`,
+			copts:         []string{"gpu"},
+		},
+		{
+			name:  "default constraint value",
+			sel:   `{":has_gpu": ["gpu"], ":no_gpu": ["no_gpu"]}`,
+			copts: []string{"no_gpu"},
+		},
+		{
+			name: "constraint values",
+			configuration: Configuration{
+				Platform:         "//conf:base",
+				ConstraintValues: []string{"//conf:mac"},
+			},
+			sel:   `{":linux": ["linux"], ":mac": ["mac"]}`,
+			copts: []string{"mac"},
+		},
+		{
+			name:          "config_setting constraint values",
+			configuration: Configuration{Platform: "//conf:base"},
+			sel:           `{":linux": ["linux"], ":linux_gpu": ["linux_gpu"]}`,
+			copts:         []string{"linux_gpu"},
+		},
+		{
+			name:          "@platforms constraint values",
+			configuration: Configuration{Platform: "//conf:ext"},
+			sel:           `{"@platforms//os:linux": ["l"], "@platforms//os:osx": []}`,
+			copts:         []string{"l"},
+		},
+		{
+			name:          "@platforms constraint values specialization",
+			configuration: Configuration{Platform: "//conf:ext"},
+			sel:           `{"@platforms//os:linux": [], ":ext_linux_x86_64": ["x"]}`,
+			copts:         []string{"x"},
+		},
+		{
+			name:  "@platforms constraint values without platform",
+			sel:   `{"@platforms//cpu:arm": ["arm"], "//conditions:default": ["d"]}`,
+			copts: []string{"d"},
+		},
+		{
+			name: "no match",
+			sel:  `{":opt": ["opt"]}`,
+			err:  "configurable attribute doesn't match this configuration",
+		},
+		{
+			name:          "ambiguous",
+			configuration: Configuration{Defines: map[string]string{"foo": "1"}},
+			sel:           `{":fastbuild": ["fastbuild"], ":foo_define": ["foo"]}`,
+			err: "illegal ambiguous match on configurable attribute: " +
+				"//conf:fastbuild and //conf:foo_define both match",
+		},
+		{
+			name:          "platform cycle",
+			configuration: Configuration{Platform: "//conf:cycle_a"},
+			sel:           `{"//conditions:default": ["d"]}`,
+			err:           "cycle in platform parents involving //conf:cycle_a",
+		},
+		{
+			name: "not a condition",
+			sel:  `{":base": ["base"]}`,
+			err:  "//conf:base is not a config_setting or constraint_value",
+		},
+		{
+			name: "no such condition",
+			sel:  `{"@other//os:linux": ["linux"]}`,
+			err:  "no such target @other//os:linux",
+		},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//conf:BUILD": configurationTestBuild + "cc_library(name = \"lib\", " +
+				"copts = select(" + testCase.sel + "))\n",
+		}
+		build, err := execFakeBuild(files, "//conf:BUILD")
+		if err != nil {
+			t.Errorf("%v: executing failed: %v", testCase.name, err)
+			continue
+		}
+		configuration := testCase.configuration
+		build.Configuration = &configuration
+		err = build.Analyze()
+		if testCase.err != "" {
+			if err == nil {
+				t.Errorf("%v: Analyze() unexpectedly succeeded", testCase.name)
+			} else if !strings.Contains(err.Error(), testCase.err) {
+				t.Errorf("%v: Analyze() failed with %v; expected error "+
+					"containing %q", testCase.name, err, testCase.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Analyze() failed: %v", testCase.name, err)
+			continue
+		}
+		lib := build.BuildTargets[""]["conf"].TargetsByName["lib"].(*rules.CcLibraryTarget)
+		if copts := lib.GetCopts(); !reflect.DeepEqual(copts, testCase.copts) {
+			t.Errorf("%v: copts = %q; expected %q", testCase.name, copts,
+				testCase.copts)
+		}
+	}
+}
+
+func TestBuild_ResolveConfigurablesOfAllTargets(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE":    "",
+		"//conf:defs.bzl": "r = rule(implementation = lambda ctx: [])\n",
+		"//conf:BUILD": `load(":defs.bzl", "r")` + configurationTestBuild + `
+genrule(name = "g", outs = ["g.out"], cmd = select({":opt": "opt", "//conditions:default": "d"}))
+sh_test(
+    name = "t",
+    srcs = ["t.sh"],
+    size = select({":opt": "large", "//conditions:default": "small"}),
+)
+r(name = "s", features = select({":opt": ["opt"], "//conditions:default": ["d"]}))
+`,
+		"//conf:t.sh": "",
+	}
+	build, err := execFakeBuild(files, "//conf:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	build.Configuration = &Configuration{
+		Values: map[string]string{"compilation_mode": "opt"},
+	}
+	if err := build.Analyze(); err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+
+	targets := build.BuildTargets[""]["conf"].TargetsByName
+	if cmd := targets["g"].(*rules.GenruleTarget).GetCmd(); cmd != "opt" {
+		t.Errorf("genrule cmd = %q; expected \"opt\"", cmd)
+	}
+	if size := targets["t"].(*rules.GenericRuleTarget).GetSize(); size != "large" {
+		t.Errorf("sh_test size = %q; expected \"large\"", size)
+	}
+	features := targets["s"].(*rules.StarlarkRuleTarget).GetFeatures()
+	if !reflect.DeepEqual(features, []string{"opt"}) {
+		t.Errorf("features = %q; expected [\"opt\"]", features)
+	}
+	for _, name := range []core.TargetName{"g", "t", "s"} {
+		target := targets[name].(builtins_args.ConfigurableArgsTarget)
+		if configurables := target.Configurables(); len(configurables) != 0 {
+			t.Errorf("%v has unresolved values %v", name, configurables)
+		}
+	}
+}
+
+func TestBuild_SelectDuplicateKeys(t *testing.T) {
+	testCases := []struct {
+		sel string
+		err string
+	}{
+		{`{":opt": ["a"], "//conf:opt": ["b"]}`,
+			`select() keys ":opt" and "//conf:opt" both refer to //conf:opt`},
+		{`{"opt": ["a"], "@//conf:opt": ["b"]}`,
+			`select() keys "opt" and "@//conf:opt" both refer to //conf:opt`},
+		{`{":opt": ["a"], "//conf:fastbuild": ["b"]}`, ""},
+	}
+	for _, testCase := range testCases {
+		files := map[string]string{
+			"//:WORKSPACE": "",
+			"//conf:BUILD": configurationTestBuild + "cc_library(name = \"lib\", " +
+				"copts = select(" + testCase.sel + "))\n",
+		}
+		_, err := execFakeBuild(files, "//conf:BUILD")
+		if testCase.err == "" {
+			if err != nil {
+				t.Errorf("%v: executing failed: %v", testCase.sel, err)
+			}
+		} else if err == nil {
+			t.Errorf("%v: executing unexpectedly succeeded", testCase.sel)
+		} else if !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%v: executing failed with %v; expected error containing %q",
+				testCase.sel, err, testCase.err)
+		}
+	}
+}
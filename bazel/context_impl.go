@@ -5,6 +5,7 @@ package bazel // import "src.tricot.io/public/bazel2x/bazel"
 
 import (
 	"fmt"
+	"os"
 
 	"go.starlark.net/starlark"
 
@@ -44,6 +45,16 @@ func (self *ContextImpl) BuildTargets() core.BuildTargets {
 	return self.build.BuildTargets
 }
 
+func (self *ContextImpl) ReadSourceDir(workspace core.WorkspaceName, dirPath string) (
+	[]os.FileInfo, error) {
+
+	return self.build.sourceDirReader(workspace, dirPath)
+}
+
+func (self *ContextImpl) RecordNotImplementedCall(call core.NotImplementedCall) error {
+	return self.build.recordNotImplementedCall(call)
+}
+
 // TODO(vtl): Maybe get rid of this. We only need this when we need to access the Build, which is
 // when we need it to do a load, but maybe that should be a part of Context.
 func GetContextImpl(thread *starlark.Thread) *ContextImpl {
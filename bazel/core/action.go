@@ -0,0 +1,82 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core // import "src.tricot.io/public/bazel2x/bazel/core"
+
+import (
+	"fmt"
+)
+
+// Action is a step that produces generated files (its outputs) from its inputs, e.g., by running a
+// command. Files are identified by their labels.
+type Action struct {
+	// Owner is the label of the target (of the rule) that registered the action.
+	Owner Label
+
+	// Mnemonic is the action's mnemonic (e.g., "Genrule").
+	Mnemonic string
+
+	Inputs  []Label
+	Outputs []Label
+
+	// Tools are the (additional) tool inputs.
+	Tools []Label
+
+	// Argv is the command line to execute (with the executable first), for actions that run
+	// commands. Files are given by their paths relative to the execution root.
+	Argv []string
+
+	// Command is the shell command (for actions that run shell commands), which is also
+	// included in Argv.
+	Command string
+
+	Env map[string]string
+
+	// Content is the content to write (for "FileWrite" actions).
+	Content string
+
+	// Substitutions are the substitutions to make (for "TemplateExpand" actions, whose only
+	// input is the template).
+	Substitutions map[string]string
+
+	IsExecutable    bool
+	ProgressMessage string
+}
+
+func (self *Action) String() string {
+	return fmt.Sprintf("%v action (owner %v) %v -> %v", self.Mnemonic, self.Owner, self.Inputs,
+		self.Outputs)
+}
+
+// BuildActions contains all the actions in a build.
+type BuildActions struct {
+	// ActionList contains the actions (in the order added).
+	ActionList []*Action
+
+	// ActionsByOutput maps the labels of generated files to the actions that produce them.
+	ActionsByOutput map[Label]*Action
+}
+
+// Add adds an action to the build. Each generated file may only be produced by one action.
+func (self *BuildActions) Add(action *Action) error {
+	for _, output := range action.Outputs {
+		if other, exists := self.ActionsByOutput[output]; exists {
+			return fmt.Errorf("output %v is generated by both %v and %v", output,
+				other.Owner, action.Owner)
+		}
+	}
+	if self.ActionsByOutput == nil {
+		self.ActionsByOutput = make(map[Label]*Action)
+	}
+	for _, output := range action.Outputs {
+		self.ActionsByOutput[output] = action
+	}
+	self.ActionList = append(self.ActionList, action)
+	return nil
+}
+
+// ForOutput returns the action that produces the generated file with the given label, or nil if
+// there is none.
+func (self *BuildActions) ForOutput(output Label) *Action {
+	return self.ActionsByOutput[output]
+}
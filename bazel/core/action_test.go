@@ -0,0 +1,61 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core_test
+
+import (
+	"testing"
+
+	. "src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func TestBuildActions_Add(t *testing.T) {
+	a := &Action{
+		Owner:    Label{"", "pkg", "a"},
+		Mnemonic: "Genrule",
+		Inputs:   []Label{{"", "pkg", "in.txt"}},
+		Outputs:  []Label{{"", "pkg", "a.h"}, {"", "pkg", "a.cc"}},
+	}
+	b := &Action{
+		Owner:    Label{"", "pkg", "b"},
+		Mnemonic: "FileWrite",
+		Outputs:  []Label{{"", "pkg", "b.txt"}},
+	}
+	conflicting := &Action{
+		Owner:    Label{"", "pkg", "c"},
+		Mnemonic: "FileWrite",
+		Outputs:  []Label{{"", "pkg", "c.txt"}, {"", "pkg", "a.cc"}},
+	}
+
+	actions := BuildActions{}
+	if err := actions.Add(a); err != nil {
+		t.Errorf("Add(a) failed: %v", err)
+	}
+	if err := actions.Add(b); err != nil {
+		t.Errorf("Add(b) failed: %v", err)
+	}
+	if err := actions.Add(conflicting); err == nil {
+		t.Errorf("Add(conflicting) unexpectedly succeeded")
+	}
+
+	if len(actions.ActionList) != 2 || actions.ActionList[0] != a ||
+		actions.ActionList[1] != b {
+		t.Errorf("unexpected ActionList: %v", actions.ActionList)
+	}
+	testCases := []struct {
+		output Label
+		action *Action
+	}{
+		{Label{"", "pkg", "a.h"}, a},
+		{Label{"", "pkg", "a.cc"}, a},
+		{Label{"", "pkg", "b.txt"}, b},
+		{Label{"", "pkg", "c.txt"}, nil},
+		{Label{"", "pkg", "in.txt"}, nil},
+	}
+	for _, testCase := range testCases {
+		if actual := actions.ForOutput(testCase.output); actual != testCase.action {
+			t.Errorf("ForOutput(%v) = %v; expected %v", testCase.output, actual,
+				testCase.action)
+		}
+	}
+}
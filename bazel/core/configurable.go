@@ -0,0 +1,190 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core // import "src.tricot.io/public/bazel2x/bazel/core"
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConditionsDefault is the special select() condition ("//conditions:default"), which matches if no
+// other condition matches.
+var ConditionsDefault = Label{MainWorkspaceName, "conditions", "default"}
+
+// ConditionMatcher determines which select() conditions (labels of config_setting targets, etc.)
+// match a given configuration.
+type ConditionMatcher interface {
+	// Matches returns whether the given condition matches.
+	Matches(condition Label) (bool, error)
+
+	// Specializes returns whether condition a is a specialization of condition b (i.e., a
+	// matching implies b matching, and a is strictly more specific). It is only called for
+	// conditions that both match.
+	Specializes(a Label, b Label) (bool, error)
+}
+
+// SelectBranch is a single branch (condition and value) of a select().
+type SelectBranch struct {
+	Condition Label
+
+	// Value is the value of the branch (of the same type as the attribute), or nil if the
+	// branch's value is None (in which case the attribute's default is used).
+	Value interface{}
+}
+
+// ConfigurablePart is a part of a Configurable: either a select() or a plain value.
+type ConfigurablePart struct {
+	// Branches are the branches of the select(), in the order given. It is nil if this is a
+	// plain value.
+	Branches []SelectBranch
+
+	// NoMatchError is the custom error message for a select() if no condition matches.
+	NoMatchError string
+
+	// Value is the plain value (only valid if Branches is nil).
+	Value interface{}
+}
+
+// IsSelect returns whether the part is a select() (as opposed to a plain value).
+func (self *ConfigurablePart) IsSelect() bool {
+	return self.Branches != nil
+}
+
+// resolve resolves the value of the part.
+func (self *ConfigurablePart) resolve(matcher ConditionMatcher) (interface{}, error) {
+	if !self.IsSelect() {
+		return self.Value, nil
+	}
+
+	var defaultBranch *SelectBranch
+	matched := []*SelectBranch{}
+	for i := range self.Branches {
+		branch := &self.Branches[i]
+		if branch.Condition == ConditionsDefault {
+			defaultBranch = branch
+			continue
+		}
+		matches, err := matcher.Matches(branch.Condition)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			matched = append(matched, branch)
+		}
+	}
+
+	if len(matched) == 0 {
+		if defaultBranch != nil {
+			return defaultBranch.Value, nil
+		}
+		if self.NoMatchError != "" {
+			return nil, fmt.Errorf("%v", self.NoMatchError)
+		}
+		conditions := make([]string, len(self.Branches))
+		for i, branch := range self.Branches {
+			conditions[i] = branch.Condition.String()
+		}
+		return nil, fmt.Errorf("configurable attribute doesn't match this configuration "+
+			"(would a default condition help?); conditions checked: %v",
+			strings.Join(conditions, ", "))
+	}
+
+	// If there are multiple matches, exactly one must be a specialization of all the others
+	// (unless they all have the same value).
+	var rv *SelectBranch
+	for _, candidate := range matched {
+		specializesAll := true
+		for _, other := range matched {
+			if other == candidate {
+				continue
+			}
+			specializes, err := matcher.Specializes(candidate.Condition,
+				other.Condition)
+			if err != nil {
+				return nil, err
+			}
+			if !specializes {
+				specializesAll = false
+				break
+			}
+		}
+		if specializesAll {
+			rv = candidate
+			break
+		}
+	}
+	if rv == nil {
+		for _, other := range matched[1:] {
+			if !reflect.DeepEqual(other.Value, matched[0].Value) {
+				return nil, fmt.Errorf("illegal ambiguous match on configurable "+
+					"attribute: %v and %v both match, but neither is a "+
+					"specialization of the other", matched[0].Condition,
+					other.Condition)
+			}
+		}
+		rv = matched[0]
+	}
+	return rv.Value, nil
+}
+
+// Configurable is the (unresolved) value of a configurable attribute that involves select(): a
+// concatenation of parts, each of which is either a select() or a plain value.
+type Configurable struct {
+	Parts []ConfigurablePart
+}
+
+// concatValues concatenates two (resolved) values of an attribute, either of which may be nil.
+func concatValues(x interface{}, y interface{}) (interface{}, error) {
+	if x == nil {
+		return y, nil
+	}
+	if y == nil {
+		return x, nil
+	}
+
+	xv := reflect.ValueOf(x)
+	yv := reflect.ValueOf(y)
+	if xv.Type() != yv.Type() {
+		return nil, fmt.Errorf("cannot concatenate values of types %v and %v", xv.Type(),
+			yv.Type())
+	}
+	switch xv.Kind() {
+	case reflect.Slice:
+		rv := reflect.MakeSlice(xv.Type(), 0, xv.Len()+yv.Len())
+		return reflect.AppendSlice(reflect.AppendSlice(rv, xv), yv).Interface(), nil
+	case reflect.String:
+		rv := reflect.ValueOf(xv.String() + yv.String()).Convert(xv.Type())
+		return rv.Interface(), nil
+	case reflect.Map:
+		rv := reflect.MakeMapWithSize(xv.Type(), xv.Len()+yv.Len())
+		for _, v := range []reflect.Value{xv, yv} {
+			iter := v.MapRange()
+			for iter.Next() {
+				rv.SetMapIndex(iter.Key(), iter.Value())
+			}
+		}
+		return rv.Interface(), nil
+	default:
+		return nil, fmt.Errorf("cannot concatenate values of type %v", xv.Type())
+	}
+}
+
+// Resolve resolves the value of the configurable attribute, for the configuration described by
+// matcher. The result is nil if the attribute is unset (which can happen if the matching branch's
+// value is None).
+func (self *Configurable) Resolve(matcher ConditionMatcher) (interface{}, error) {
+	var rv interface{}
+	for i := range self.Parts {
+		value, err := self.Parts[i].resolve(matcher)
+		if err != nil {
+			return nil, err
+		}
+		rv, err = concatValues(rv, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
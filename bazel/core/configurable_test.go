@@ -0,0 +1,117 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// fakeConditionMatcher matches the conditions in matching; a condition specializes another if it
+// is listed as such in specializations.
+type fakeConditionMatcher struct {
+	matching        map[Label]bool
+	specializations map[Label]Label
+}
+
+func (self *fakeConditionMatcher) Matches(condition Label) (bool, error) {
+	return self.matching[condition], nil
+}
+
+func (self *fakeConditionMatcher) Specializes(a Label, b Label) (bool, error) {
+	return self.specializations[a] == b, nil
+}
+
+func TestConfigurable_Resolve(t *testing.T) {
+	linux := Label{"", "build", "linux"}
+	linuxArm := Label{"", "build", "linux_arm"}
+	mac := Label{"", "build", "mac"}
+
+	matcher := &fakeConditionMatcher{
+		matching:        map[Label]bool{linux: true, linuxArm: true},
+		specializations: map[Label]Label{linuxArm: linux},
+	}
+
+	testCases := []struct {
+		in  Configurable
+		out interface{}
+	}{
+		{Configurable{[]ConfigurablePart{
+			{Branches: []SelectBranch{{mac, []string{"mac"}},
+				{ConditionsDefault, []string{"default"}}}},
+		}}, []string{"default"}},
+		{Configurable{[]ConfigurablePart{
+			{Branches: []SelectBranch{{linux, []string{"linux"}},
+				{ConditionsDefault, []string{"default"}}}},
+		}}, []string{"linux"}},
+		{Configurable{[]ConfigurablePart{
+			{Value: []string{"a"}},
+			{Branches: []SelectBranch{{linux, []string{"linux"}},
+				{linuxArm, []string{"linux_arm"}}}},
+			{Value: []string{"b"}},
+		}}, []string{"a", "linux_arm", "b"}},
+		{Configurable{[]ConfigurablePart{
+			{Value: "x"},
+			{Branches: []SelectBranch{{mac, "mac"}, {linux, "linux"}}},
+		}}, "xlinux"},
+		{Configurable{[]ConfigurablePart{
+			{Branches: []SelectBranch{{linux, nil}, {ConditionsDefault, true}}},
+		}}, nil},
+	}
+	for _, testCase := range testCases {
+		out, err := testCase.in.Resolve(matcher)
+		if err != nil {
+			t.Error(testCase.in, " should not have resulted in error: ", err)
+		} else if !reflect.DeepEqual(out, testCase.out) {
+			t.Error(testCase.in, " should have resulted in ", testCase.out,
+				", but resulted in ", out)
+		}
+	}
+
+	otherLinux := Label{"", "build", "other_linux"}
+	matcher.matching[otherLinux] = true
+	invalids := []struct {
+		in Configurable
+		// err is a substring of the expected error.
+		err string
+	}{
+		// No match.
+		{Configurable{[]ConfigurablePart{{Branches: []SelectBranch{
+			{mac, []string{"mac"}}}}}}, "doesn't match this configuration"},
+		// No match (with custom error).
+		{Configurable{[]ConfigurablePart{{Branches: []SelectBranch{{mac, true}},
+			NoMatchError: "oops"}}}, "oops"},
+		// Values that can't be concatenated.
+		{Configurable{[]ConfigurablePart{{Branches: []SelectBranch{{linux, true}}},
+			{Branches: []SelectBranch{{linux, false}}}}}, "cannot concatenate"},
+		// Ambiguous: two matching conditions (with different values), neither of which is a
+		// specialization of the other.
+		{Configurable{[]ConfigurablePart{{Branches: []SelectBranch{{linux, "linux"},
+			{otherLinux, "other_linux"}}}}}, "illegal ambiguous match"},
+		// Ambiguous, even though one of the conditions is specialized by a third.
+		{Configurable{[]ConfigurablePart{{Branches: []SelectBranch{{linux, "linux"},
+			{linuxArm, "linux_arm"}, {otherLinux, "other_linux"}}}}},
+			"illegal ambiguous match"},
+	}
+	for _, invalid := range invalids {
+		out, err := invalid.in.Resolve(matcher)
+		if err == nil {
+			t.Error(invalid.in, " should have resulted in error, but resulted in ", out)
+		} else if !strings.Contains(err.Error(), invalid.err) {
+			t.Error(invalid.in, " should have resulted in error containing ",
+				invalid.err, ", but resulted in ", err)
+		}
+	}
+
+	// Multiple matching conditions that aren't specializations of each other are allowed if
+	// they have the same value.
+	same := Configurable{[]ConfigurablePart{{Branches: []SelectBranch{{linux, "x"},
+		{otherLinux, "x"}}}}}
+	if out, err := same.Resolve(matcher); err != nil || out != "x" {
+		t.Error(same, " should have resulted in x, but resulted in ", out, ", ", err)
+	}
+}
@@ -4,6 +4,8 @@
 package core // import "src.tricot.io/public/bazel2x/bazel/core"
 
 import (
+	"os"
+
 	"go.starlark.net/starlark"
 )
 
@@ -23,6 +25,14 @@ type Context interface {
 	FileType() FileType
 
 	BuildTargets() BuildTargets
+
+	// ReadSourceDir reads the entries of the given source directory, specified by workspace and
+	// (slash-separated) path relative to the workspace's root.
+	ReadSourceDir(workspace WorkspaceName, dirPath string) ([]os.FileInfo, error)
+
+	// RecordNotImplementedCall records a call to a builtin that we haven't implemented (yet).
+	// It returns an error if such calls aren't allowed (in which case the call should fail).
+	RecordNotImplementedCall(call NotImplementedCall) error
 }
 
 const contextKey = "bazel2make-bazel-context"
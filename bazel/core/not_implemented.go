@@ -0,0 +1,29 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core // import "src.tricot.io/public/bazel2x/bazel/core"
+
+import (
+	"go.starlark.net/starlark"
+)
+
+// NotImplementedCall is a call to a builtin (function, rule, or workspace rule) that we haven't
+// implemented (yet).
+type NotImplementedCall struct {
+	// Builtin is the name of the builtin.
+	Builtin string
+
+	// Location is the location of the call (e.g., "//foo:BUILD:3:1").
+	Location string
+}
+
+// RecordNotImplementedCall records a call, made on the given thread, to the builtin (that we
+// haven't implemented) with the given name (see Context.RecordNotImplementedCall). The location
+// is that of the innermost starlark caller.
+func RecordNotImplementedCall(thread *starlark.Thread, builtinName string) error {
+	call := NotImplementedCall{Builtin: builtinName}
+	if thread.CallStackDepth() > 1 {
+		call.Location = thread.CallFrame(1).Pos.String()
+	}
+	return GetContext(thread).RecordNotImplementedCall(call)
+}
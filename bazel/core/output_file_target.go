@@ -0,0 +1,51 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core // import "src.tricot.io/public/bazel2x/bazel/core"
+
+import (
+	"fmt"
+)
+
+// GeneratingTarget is implemented by targets that have predeclared outputs (e.g., the outs of a
+// genrule), which get OutputFileTargets.
+type GeneratingTarget interface {
+	Target
+
+	// OutputLabels returns the labels of the target's predeclared outputs.
+	OutputLabels() []Label
+}
+
+// OutputFileTarget is a target for a (predeclared) generated file, which is owned by the target of
+// the rule that generates it. It is created at the end of loading the package.
+type OutputFileTarget struct {
+	label Label
+
+	// GeneratingRule is the target that generates the file.
+	GeneratingRule Target
+}
+
+var _ Target = (*OutputFileTarget)(nil)
+
+// NewOutputFileTarget creates a new OutputFileTarget, generated by the given target.
+func NewOutputFileTarget(label Label, generatingRule Target) *OutputFileTarget {
+	return &OutputFileTarget{label: label, GeneratingRule: generatingRule}
+}
+
+func (self *OutputFileTarget) Label() Label {
+	return self.label
+}
+
+func (self *OutputFileTarget) Kind() string {
+	return "generated file"
+}
+
+// Provenance returns the provenance of the generating target.
+func (self *OutputFileTarget) Provenance() *Provenance {
+	return self.GeneratingRule.Provenance()
+}
+
+func (self *OutputFileTarget) String() string {
+	return fmt.Sprintf("generated_file(name = %q, generating_rule = %q)",
+		string(self.label.Target), self.GeneratingRule.Label().String())
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core // import "src.tricot.io/public/bazel2x/bazel/core"
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// Provenance describes where a target was instantiated, including the macro (if any) that
+// instantiated it (like Bazel's generator_name, generator_function, and generator_location
+// attributes).
+type Provenance struct {
+	// CallStack is the starlark call stack (outermost first) at the time the rule was called
+	// (not including the rule itself). Its first frame is in the BUILD file.
+	CallStack starlark.CallStack
+
+	// GeneratorName is the name argument given to the macro (or the target's name if the
+	// macro has no name argument). It is empty if the target was not instantiated by a macro.
+	GeneratorName string
+
+	// GeneratorFunction is the name of the macro (called from the BUILD file) that instantiated
+	// the target. It is empty if the target was not instantiated by a macro.
+	GeneratorFunction string
+
+	// GeneratorLocation is the location (in the BUILD file) of the call to the macro. It is
+	// empty if the target was not instantiated by a macro.
+	GeneratorLocation string
+}
+
+// IsGenerated returns whether the target was instantiated by a macro.
+func (self *Provenance) IsGenerated() bool {
+	return self.GeneratorFunction != ""
+}
+
+// Location returns the location of the call in the BUILD file that resulted in the target (i.e.,
+// of the rule call itself or of the call to the macro).
+func (self *Provenance) Location() string {
+	if len(self.CallStack) == 0 {
+		return ""
+	}
+	return self.CallStack[0].Pos.String()
+}
+
+func (self *Provenance) String() string {
+	if self.IsGenerated() {
+		return fmt.Sprintf("%v() at %v", self.GeneratorFunction, self.GeneratorLocation)
+	}
+	return self.Location()
+}
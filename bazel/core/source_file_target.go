@@ -0,0 +1,64 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package core // import "src.tricot.io/public/bazel2x/bazel/core"
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceFileTarget is a target for a source file. It is created either explicitly by
+// exports_files() or implicitly (at the end of loading the package) for an existing file in the
+// package that is referenced by a rule in the package.
+type SourceFileTarget struct {
+	label Label
+
+	// Exported is true if the target was created by exports_files().
+	Exported bool
+
+	// Visibility is the visibility given to exports_files() (if any).
+	Visibility *[]Label
+
+	// Licenses are the licenses given to exports_files() (if any).
+	Licenses *[]string
+}
+
+var _ Target = (*SourceFileTarget)(nil)
+
+// NewSourceFileTarget creates a new (non-exported) SourceFileTarget.
+func NewSourceFileTarget(label Label) *SourceFileTarget {
+	return &SourceFileTarget{label: label}
+}
+
+func (self *SourceFileTarget) Label() Label {
+	return self.label
+}
+
+func (self *SourceFileTarget) Kind() string {
+	return "source file"
+}
+
+// Provenance returns nil, since the provenance of source files is not tracked.
+func (self *SourceFileTarget) Provenance() *Provenance {
+	return nil
+}
+
+func (self *SourceFileTarget) String() string {
+	attrs := []string{fmt.Sprintf("name = %q", string(self.label.Target))}
+	if self.Visibility != nil {
+		visibility := make([]string, len(*self.Visibility))
+		for i, label := range *self.Visibility {
+			visibility[i] = fmt.Sprintf("%q", label.String())
+		}
+		attrs = append(attrs, "visibility = ["+strings.Join(visibility, ", ")+"]")
+	}
+	if self.Licenses != nil {
+		licenses := make([]string, len(*self.Licenses))
+		for i, license := range *self.Licenses {
+			licenses[i] = fmt.Sprintf("%q", license)
+		}
+		attrs = append(attrs, "licenses = ["+strings.Join(licenses, ", ")+"]")
+	}
+	return "source_file(" + strings.Join(attrs, ", ") + ")"
+}
@@ -13,18 +13,41 @@ import (
 type Target interface {
 	fmt.Stringer
 	Label() Label
+
+	// Kind returns the kind of the target (e.g., the name of the rule, like "cc_library").
+	Kind() string
+
+	// Provenance returns where the target was instantiated (or nil if unknown).
+	Provenance() *Provenance
+}
+
+// PackageMetadata contains the package-level metadata given by package(). Unset fields are nil.
+type PackageMetadata struct {
+	DefaultVisibility         *[]Label
+	DefaultTestonly           *bool
+	DefaultDeprecation        *string
+	DefaultApplicableLicenses *[]Label
+	Features                  *[]string
 }
 
 // PackageTargets contains the targets in a package.
 type PackageTargets struct {
 	TargetList    []Target
 	TargetsByName map[TargetName]Target
+
+	// Metadata is the package's metadata, or nil if package() was not called.
+	Metadata *PackageMetadata
 }
 
 // Add adds a target to the package.
 func (self *PackageTargets) Add(target Target) error {
 	label := target.Label()
-	if _, alreadyExists := self.TargetsByName[label.Target]; alreadyExists {
+	if existing, alreadyExists := self.TargetsByName[label.Target]; alreadyExists {
+		if provenance := existing.Provenance(); provenance != nil &&
+			provenance.Location() != "" {
+			return fmt.Errorf("target %v already exists (defined at %v)", label,
+				provenance.Location())
+		}
 		return fmt.Errorf("target %v already exists", label)
 	}
 	self.TargetList = append(self.TargetList, target)
@@ -40,7 +63,10 @@ func (self WorkspaceTargets) AddPackage(packageName PackageName) {
 	if _, alreadyExists := self[packageName]; alreadyExists {
 		panic(packageName)
 	}
-	self[packageName] = &PackageTargets{[]Target{}, make(map[TargetName]Target)}
+	self[packageName] = &PackageTargets{
+		TargetList:    []Target{},
+		TargetsByName: make(map[TargetName]Target),
+	}
 }
 
 // Add adds a target to the workspace.
@@ -0,0 +1,470 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel // import "src.tricot.io/public/bazel2x/bazel"
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"src.tricot.io/public/bazel2x/bazel/builtins/rules"
+	"src.tricot.io/public/bazel2x/bazel/builtins/values"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+// MakeToken is a token of a string that is subject to "Make" variable expansion (e.g., a genrule's
+// cmd or a cc_library's copts): either literal text or a reference to a variable (e.g.,
+// "$(SRCS)" or "$@") or to a function (e.g., "$(location //foo:bar)").
+type MakeToken struct {
+	// Literal is the literal text (if Name is empty). Note that "$$" is tokenized as the
+	// literal "$".
+	Literal string
+
+	// Name is the name of the variable or function (e.g., "SRCS", "@", or "location").
+	Name string
+
+	// Arg is the argument of the function (e.g., "//foo:bar"), or empty for variables.
+	Arg string
+}
+
+// TokenizeMakeVariables tokenizes a string that is subject to "Make" variable expansion.
+func TokenizeMakeVariables(s string) ([]MakeToken, error) {
+	rv := []MakeToken{}
+	literal := strings.Builder{}
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			rv = append(rv, MakeToken{Literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			literal.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return nil, fmt.Errorf("unterminated $ at end of %q", s)
+		}
+
+		switch s[i+1] {
+		case '$':
+			literal.WriteByte('$')
+			i++
+		case '(':
+			end := strings.IndexByte(s[i+2:], ')')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated $( in %q", s)
+			}
+			content := strings.TrimSpace(s[i+2 : i+2+end])
+			if content == "" {
+				return nil, fmt.Errorf("empty $() in %q", s)
+			}
+			flushLiteral()
+			token := MakeToken{Name: content}
+			if j := strings.IndexAny(content, " \t"); j >= 0 {
+				token.Name = content[:j]
+				token.Arg = strings.TrimSpace(content[j+1:])
+			}
+			rv = append(rv, token)
+			i += 2 + end
+		default:
+			// A single-character variable (e.g., "$@").
+			flushLiteral()
+			rv = append(rv, MakeToken{Name: s[i+1 : i+2]})
+			i++
+		}
+	}
+	flushLiteral()
+	return rv, nil
+}
+
+// TokenizeShell splits a string into words like a Bourne shell (as Bazel does for, e.g., the copts
+// and linkopts of C++ rules, after "Make" variable expansion): words are separated by unquoted
+// whitespace, and single quotes, double quotes, and backslashes quote as in the shell.
+func TokenizeShell(s string) ([]string, error) {
+	rv := []string{}
+	word := strings.Builder{}
+	// inWord indicates that there's a word (possibly empty, e.g., from "''") being built.
+	inWord := false
+	quote := byte(0)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(s) &&
+				strings.IndexByte("\\\"$`", s[i+1]) >= 0 {
+				word.WriteByte(s[i+1])
+				i++
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("unterminated \\ at end of %q", s)
+			}
+			word.WriteByte(s[i+1])
+			inWord = true
+			i++
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				rv = append(rv, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quotation in %q", s)
+	}
+	if inWord {
+		rv = append(rv, word.String())
+	}
+	return rv, nil
+}
+
+// PathMapper maps files (given by their labels) to paths, for "Make" variable expansion. It is
+// supplied by the backend (e.g., for paths in the backend's build directory); see also
+// NewBazelPathMapper.
+type PathMapper interface {
+	// ExecPath returns the path of the file as seen by commands run by the build (as given by
+	// $(execpath)).
+	ExecPath(file core.Label) string
+
+	// RootPath returns the path of the file relative to the runfiles root of a binary (as given
+	// by $(rootpath)).
+	RootPath(file core.Label) string
+
+	// BinDir returns the root directory of generated files (as given by $(BINDIR)).
+	BinDir() string
+}
+
+// bazelPathMapper is a PathMapper that gives paths as Bazel would (e.g., "bazel-out/bin/foo/bar.h"
+// for the generated file //foo:bar.h).
+type bazelPathMapper struct {
+	build *Build
+}
+
+var _ PathMapper = (*bazelPathMapper)(nil)
+
+// NewBazelPathMapper creates a new PathMapper that gives paths as Bazel would.
+func NewBazelPathMapper(build *Build) PathMapper {
+	return &bazelPathMapper{build}
+}
+
+func (self *bazelPathMapper) file(label core.Label) *values.File {
+	if self.build.isGeneratedFile(label) {
+		return values.NewGeneratedFile(label, false)
+	}
+	return values.NewSourceFile(label)
+}
+
+func (self *bazelPathMapper) ExecPath(file core.Label) string {
+	return self.file(file).Path()
+}
+
+func (self *bazelPathMapper) RootPath(file core.Label) string {
+	return self.file(file).ShortPath()
+}
+
+func (self *bazelPathMapper) BinDir() string {
+	return values.BinDir
+}
+
+// isGeneratedFile returns whether the file with the given label is a generated file (i.e., has an
+// output file target or is produced by an action).
+func (self *Build) isGeneratedFile(label core.Label) bool {
+	if target, _ := self.getTarget(label); target != nil {
+		_, ok := target.(*core.OutputFileTarget)
+		return ok
+	}
+	return self.Actions.ForOutput(label) != nil
+}
+
+// targetFiles returns the files (as labels) of the target with the given label: the file itself
+// for file targets (or labels that don't refer to targets), the outputs for targets with
+// predeclared outputs, or the default outputs of analyzed targets of user-defined rules.
+func (self *Build) targetFiles(label core.Label) []core.Label {
+	target, _ := self.getTarget(label)
+	switch target := target.(type) {
+	case nil, *core.SourceFileTarget, *core.OutputFileTarget:
+		return []core.Label{label}
+	case *rules.StarlarkRuleTarget:
+		result := self.AnalysisResults[label]
+		if result == nil {
+			return nil
+		}
+		rv := []core.Label{}
+		for _, file := range result.Files() {
+			rv = append(rv, file.Label())
+		}
+		return rv
+	case core.GeneratingTarget:
+		return target.OutputLabels()
+	default:
+		return nil
+	}
+}
+
+// defaultMakeVariables are the default values of the predefined "Make" variables that don't
+// depend on the target (e.g., the toolchain variables "CC" and "JAVA"), as Bazel would give them
+// with its default (auto-configured) toolchains on a typical Linux host. They may be overridden by
+// the configuration (see Configuration.MakeVariables).
+var defaultMakeVariables = map[string]string{
+	"COMPILATION_MODE": "fastbuild",
+	"TARGET_CPU":       "k8",
+
+	// C++ toolchain variables.
+	"AR":         "/usr/bin/ar",
+	"CC":         "/usr/bin/gcc",
+	"CC_FLAGS":   "",
+	"C_COMPILER": "compiler",
+	"GCOV":       "/usr/bin/gcov",
+	"LD":         "/usr/bin/ld",
+	"NM":         "/usr/bin/nm",
+	"OBJCOPY":    "/usr/bin/objcopy",
+	"STRIP":      "/usr/bin/strip",
+
+	// Java runtime variables.
+	"JAVA":     "external/local_jdk/bin/java",
+	"JAVABASE": "external/local_jdk",
+}
+
+// MakeVariableExpander expands "Make" variables (e.g., "$(SRCS)") and location functions (e.g.,
+// "$(location //foo:bar)") in the attributes of a target. See Build.NewMakeVariableExpander.
+type MakeVariableExpander struct {
+	// Owner is the label of the target whose attributes are expanded.
+	Owner core.Label
+
+	// Prerequisites maps the labels of the target's declared prerequisites (the labels
+	// referred to by its attributes) to their files. Location functions may only refer to
+	// these.
+	Prerequisites map[core.Label][]core.Label
+
+	// Vars are the values of the variables (e.g., "SRCS" or "CC").
+	Vars map[string]string
+
+	Paths PathMapper
+
+	// LocationIsRootPath indicates that $(location) and $(locations) give root paths (as for
+	// the args of tests and binaries) instead of exec paths (as for a genrule's cmd).
+	LocationIsRootPath bool
+}
+
+// NewMakeVariableExpander creates a new MakeVariableExpander for the given target, with paths given
+// by paths. Its variables include defaults for the predefined variables (see
+// defaultMakeVariables), the --define values and make variables of Configuration (if set), and,
+// for genrules, the genrule-specific variables (e.g., "SRCS", "OUTS", and "@").
+func (self *Build) NewMakeVariableExpander(target core.Target,
+	paths PathMapper) *MakeVariableExpander {
+
+	label := target.Label()
+	rv := &MakeVariableExpander{
+		Owner:         label,
+		Prerequisites: map[core.Label][]core.Label{},
+		Vars:          map[string]string{},
+		Paths:         paths,
+	}
+	for _, prerequisite := range rules.TargetLabels(target) {
+		rv.Prerequisites[prerequisite] = self.targetFiles(prerequisite)
+	}
+
+	for name, value := range defaultMakeVariables {
+		rv.Vars[name] = value
+	}
+	if self.Configuration != nil {
+		for name, value := range self.Configuration.Defines {
+			rv.Vars[name] = value
+		}
+		for name, value := range self.Configuration.MakeVariables {
+			rv.Vars[name] = value
+		}
+		if cpu, ok := self.Configuration.Values["cpu"]; ok {
+			rv.Vars["TARGET_CPU"] = cpu
+		}
+		if compilationMode, ok := self.Configuration.Values["compilation_mode"]; ok {
+			rv.Vars["COMPILATION_MODE"] = compilationMode
+		}
+	}
+
+	ruleDir := path.Join(paths.BinDir(), string(label.Package))
+	if label.IsExternal() {
+		ruleDir = path.Join(paths.BinDir(), "external", string(label.Workspace),
+			string(label.Package))
+	}
+	rv.Vars["BINDIR"] = paths.BinDir()
+	rv.Vars["GENDIR"] = paths.BinDir()
+	rv.Vars["RULEDIR"] = ruleDir
+
+	if genrule, ok := target.(*rules.GenruleTarget); ok {
+		srcs := []string{}
+		for _, src := range genrule.GetSrcs() {
+			for _, file := range rv.Prerequisites[src] {
+				srcs = append(srcs, paths.ExecPath(file))
+			}
+		}
+		outs := []string{}
+		for _, out := range genrule.GetOuts() {
+			outs = append(outs, paths.ExecPath(out))
+		}
+
+		rv.Vars["SRCS"] = strings.Join(srcs, " ")
+		rv.Vars["^"] = rv.Vars["SRCS"]
+		rv.Vars["OUTS"] = strings.Join(outs, " ")
+		if len(srcs) == 1 {
+			rv.Vars["<"] = srcs[0]
+		}
+		if len(outs) == 1 {
+			rv.Vars["@"] = outs[0]
+			rv.Vars["@D"] = path.Dir(outs[0])
+		} else {
+			rv.Vars["@D"] = ruleDir
+		}
+	}
+	return rv
+}
+
+// locationPathKind is the kind of path given by a location function.
+type locationPathKind int
+
+const (
+	// locationPathDefault is for $(location) and $(locations) (see LocationIsRootPath).
+	locationPathDefault locationPathKind = iota
+	locationPathExec
+	locationPathRoot
+)
+
+// locationFunctions maps the names of location functions to whether they give multiple paths and
+// the kind of paths that they give.
+var locationFunctions = map[string]struct {
+	multiple bool
+	pathKind locationPathKind
+}{
+	"location":  {false, locationPathDefault},
+	"locations": {true, locationPathDefault},
+	"execpath":  {false, locationPathExec},
+	"execpaths": {true, locationPathExec},
+	"rootpath":  {false, locationPathRoot},
+	"rootpaths": {true, locationPathRoot},
+}
+
+// expandLocation expands a location function (e.g., $(location //foo:bar)).
+func (self *MakeVariableExpander) expandLocation(name string, arg string) (string, error) {
+	function := locationFunctions[name]
+	if arg == "" {
+		return "", fmt.Errorf("$(%v) requires a label", name)
+	}
+	s := arg
+	if !strings.HasPrefix(s, "//") && !strings.HasPrefix(s, "@") && !strings.Contains(s, ":") {
+		s = ":" + s
+	}
+	label, err := core.ParseLabel(self.Owner.Workspace, self.Owner.Package, s)
+	if err != nil {
+		return "", fmt.Errorf("invalid label in $(%v %v): %v", name, arg, err)
+	}
+	files, ok := self.Prerequisites[label]
+	if !ok {
+		return "", fmt.Errorf("label %v in $(%v) expression is not a declared "+
+			"prerequisite of this rule", label, name)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("label %v in $(%v) expression expands to no files", label,
+			name)
+	}
+	if !function.multiple && len(files) != 1 {
+		return "", fmt.Errorf("label %v in $(%v) expression expands to more than one "+
+			"file, please use $(%vs %v) instead", label, name, name, arg)
+	}
+
+	rootPath := function.pathKind == locationPathRoot ||
+		(function.pathKind == locationPathDefault && self.LocationIsRootPath)
+	paths := make([]string, len(files))
+	for i, file := range files {
+		if rootPath {
+			paths[i] = self.Paths.RootPath(file)
+		} else {
+			paths[i] = self.Paths.ExecPath(file)
+		}
+	}
+	return strings.Join(paths, " "), nil
+}
+
+// Expand expands the "Make" variables and location functions in s (the value of the given
+// attribute).
+func (self *MakeVariableExpander) Expand(attrName string, s string) (string, error) {
+	tokens, err := TokenizeMakeVariables(s)
+	if err != nil {
+		return "", fmt.Errorf("%v: attribute %v invalid: %v", self.Owner, attrName, err)
+	}
+
+	rv := strings.Builder{}
+	for _, token := range tokens {
+		if token.Name == "" {
+			rv.WriteString(token.Literal)
+			continue
+		}
+		if _, ok := locationFunctions[token.Name]; ok {
+			expanded, err := self.expandLocation(token.Name, token.Arg)
+			if err != nil {
+				return "", fmt.Errorf("%v: attribute %v invalid: %v", self.Owner,
+					attrName, err)
+			}
+			rv.WriteString(expanded)
+			continue
+		}
+		if token.Arg != "" {
+			return "", fmt.Errorf("%v: attribute %v invalid: unknown function $(%v)",
+				self.Owner, attrName, token.Name)
+		}
+		value, ok := self.Vars[token.Name]
+		if !ok {
+			return "", fmt.Errorf("%v: attribute %v invalid: $(%v) not defined",
+				self.Owner, attrName, token.Name)
+		}
+		rv.WriteString(value)
+	}
+	return rv.String(), nil
+}
+
+// ExpandList expands each of the values of the given (string list) attribute.
+func (self *MakeVariableExpander) ExpandList(attrName string, ss []string) ([]string, error) {
+	rv := make([]string, len(ss))
+	for i, s := range ss {
+		var err error
+		if rv[i], err = self.Expand(attrName, s); err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// ExpandTokenizedList expands each of the values of the given (string list) attribute and splits
+// the results into words (see TokenizeShell), as for the copts and linkopts of C++ rules.
+func (self *MakeVariableExpander) ExpandTokenizedList(attrName string,
+	ss []string) ([]string, error) {
+
+	rv := []string{}
+	for _, s := range ss {
+		expanded, err := self.Expand(attrName, s)
+		if err != nil {
+			return nil, err
+		}
+		words, err := TokenizeShell(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("%v: attribute %v invalid: %v", self.Owner, attrName,
+				err)
+		}
+		rv = append(rv, words...)
+	}
+	return rv, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package bazel_test
+
+import (
+	"path"
+	"reflect"
+	"testing"
+
+	. "src.tricot.io/public/bazel2x/bazel"
+	"src.tricot.io/public/bazel2x/bazel/core"
+)
+
+func TestTokenizeMakeVariables(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out []MakeToken
+	}{
+		{"", []MakeToken{}},
+		{"abc", []MakeToken{{Literal: "abc"}}},
+		{"a$$b", []MakeToken{{Literal: "a$b"}}},
+		{"cp $< $@", []MakeToken{
+			{Literal: "cp "}, {Name: "<"}, {Literal: " "}, {Name: "@"}}},
+		{"-I$(RULEDIR)/x", []MakeToken{
+			{Literal: "-I"}, {Name: "RULEDIR"}, {Literal: "/x"}}},
+		{"$( location  //foo:bar )", []MakeToken{{Name: "location", Arg: "//foo:bar"}}},
+		{"$(@D)$(SRCS)", []MakeToken{{Name: "@D"}, {Name: "SRCS"}}},
+	}
+	for _, testCase := range testCases {
+		actual, err := TokenizeMakeVariables(testCase.in)
+		if err != nil {
+			t.Errorf("TokenizeMakeVariables(%q) failed: %v", testCase.in, err)
+		} else if !reflect.DeepEqual(actual, testCase.out) {
+			t.Errorf("TokenizeMakeVariables(%q) = %v; expected %v", testCase.in, actual,
+				testCase.out)
+		}
+	}
+
+	for _, in := range []string{"abc$", "$(abc", "$()"} {
+		if _, err := TokenizeMakeVariables(in); err == nil {
+			t.Errorf("TokenizeMakeVariables(%q) unexpectedly succeeded", in)
+		}
+	}
+}
+
+func TestTokenizeShell(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out []string
+	}{
+		{"", []string{}},
+		{"  ", []string{}},
+		{"-Wall", []string{"-Wall"}},
+		{" -include  foo.h\t-O2 ", []string{"-include", "foo.h", "-O2"}},
+		{`-DX="a b"`, []string{"-DX=a b"}},
+		{`-DX='"a" b'`, []string{`-DX="a" b`}},
+		{`-DX=\"a\"`, []string{`-DX="a"`}},
+		{`"a\"b\\c\d"`, []string{`a"b\c\d`}},
+		{`a\ b c`, []string{"a b", "c"}},
+		{`'' ""`, []string{"", ""}},
+	}
+	for _, testCase := range testCases {
+		actual, err := TokenizeShell(testCase.in)
+		if err != nil {
+			t.Errorf("TokenizeShell(%q) failed: %v", testCase.in, err)
+		} else if !reflect.DeepEqual(actual, testCase.out) {
+			t.Errorf("TokenizeShell(%q) = %q; expected %q", testCase.in, actual,
+				testCase.out)
+		}
+	}
+
+	for _, in := range []string{`"abc`, `'abc`, `abc\`} {
+		if _, err := TokenizeShell(in); err == nil {
+			t.Errorf("TokenizeShell(%q) unexpectedly succeeded", in)
+		}
+	}
+}
+
+func newLabel(packageName string, targetName string) core.Label {
+	return core.Label{
+		Package: core.PackageName(packageName),
+		Target:  core.TargetName(targetName),
+	}
+}
+
+// fakePathMapper gives exec paths under "exec" and root paths under "root".
+type fakePathMapper struct{}
+
+func (fakePathMapper) ExecPath(file core.Label) string {
+	return path.Join("exec", string(file.Package), string(file.Target))
+}
+
+func (fakePathMapper) RootPath(file core.Label) string {
+	return path.Join("root", string(file.Package), string(file.Target))
+}
+
+func (fakePathMapper) BinDir() string {
+	return "out"
+}
+
+func TestMakeVariableExpander_Expand(t *testing.T) {
+	expander := &MakeVariableExpander{
+		Owner: newLabel("pkg", "gen"),
+		Prerequisites: map[core.Label][]core.Label{
+			newLabel("pkg", "a.txt"):  {newLabel("pkg", "a.txt")},
+			newLabel("other", "tool"): {newLabel("other", "tool.sh")},
+			newLabel("other", "files"): {
+				newLabel("other", "x"),
+				newLabel("other", "y"),
+			},
+		},
+		Vars:  map[string]string{"CC": "gcc", "@": "exec/pkg/out.h"},
+		Paths: fakePathMapper{},
+	}
+
+	testCases := []struct {
+		in  string
+		out string
+	}{
+		{"$(CC) -o $@", "gcc -o exec/pkg/out.h"},
+		{"$(location a.txt)", "exec/pkg/a.txt"},
+		{"$(location :a.txt)", "exec/pkg/a.txt"},
+		{"$(execpath //pkg:a.txt)", "exec/pkg/a.txt"},
+		{"$(rootpath //other:tool)", "root/other/tool.sh"},
+		{"$(locations //other:files)", "exec/other/x exec/other/y"},
+		{"$(rootpaths //other:files)", "root/other/x root/other/y"},
+		{"echo $$HOME", "echo $HOME"},
+	}
+	for _, testCase := range testCases {
+		actual, err := expander.Expand("cmd", testCase.in)
+		if err != nil {
+			t.Errorf("Expand(%q) failed: %v", testCase.in, err)
+		} else if actual != testCase.out {
+			t.Errorf("Expand(%q) = %q; expected %q", testCase.in, actual, testCase.out)
+		}
+	}
+
+	for _, in := range []string{
+		"$(UNDEFINED)",
+		"$(location //pkg:undeclared.txt)",
+		"$(location //other:files)",
+		"$(location)",
+		"$(unknown //pkg:a.txt)",
+		"echo $HOME",
+	} {
+		if _, err := expander.Expand("cmd", in); err == nil {
+			t.Errorf("Expand(%q) unexpectedly succeeded", in)
+		}
+	}
+
+	expected := []string{"-include", "exec/pkg/a.txt", "-DCC=gcc x", "-lm"}
+	if actual, err := expander.ExpandTokenizedList("copts", []string{
+		"-include $(location a.txt)", "'-DCC=$(CC) x'", "-lm"}); err != nil ||
+		!reflect.DeepEqual(actual, expected) {
+		t.Errorf("ExpandTokenizedList() = %q, %v; expected %q", actual, err, expected)
+	}
+	if _, err := expander.ExpandTokenizedList("copts", []string{"'$(CC)"}); err == nil {
+		t.Errorf("ExpandTokenizedList() unexpectedly succeeded")
+	}
+
+	expander.LocationIsRootPath = true
+	if actual, err := expander.Expand("args", "$(location a.txt)"); err != nil ||
+		actual != "root/pkg/a.txt" {
+		t.Errorf("Expand(\"$(location a.txt)\") = %q, %v; expected \"root/pkg/a.txt\"",
+			actual, err)
+	}
+}
+
+func TestBuild_NewMakeVariableExpander(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "",
+		"//pkg:BUILD":  "genrule(name = \"gen\", outs = [\"out.h\"], cmd = \"touch $@\")\n",
+	}
+	build, err := execFakeBuild(files, "//pkg:BUILD")
+	if err != nil {
+		t.Fatalf("executing failed: %v", err)
+	}
+	target := build.BuildTargets[""]["pkg"].TargetsByName["gen"]
+	in := "$(CC) $(CC_FLAGS) -march=$(TARGET_CPU) -c$(COMPILATION_MODE) $(JAVA) $(SRCS) > $@"
+
+	testCases := []struct {
+		name          string
+		configuration *Configuration
+		out           string
+	}{
+		{
+			name:          "no configuration",
+			configuration: nil,
+			out: "/usr/bin/gcc  -march=k8 -cfastbuild external/local_jdk/bin/java " +
+				" > exec/pkg/out.h",
+		},
+		{
+			name: "overridden",
+			configuration: &Configuration{
+				Values: map[string]string{
+					"cpu":              "arm",
+					"compilation_mode": "opt",
+				},
+				MakeVariables: map[string]string{"CC": "clang", "CC_FLAGS": "-O2"},
+			},
+			out: "clang -O2 -march=arm -copt external/local_jdk/bin/java  " +
+				"> exec/pkg/out.h",
+		},
+	}
+	for _, testCase := range testCases {
+		build.Configuration = testCase.configuration
+		expander := build.NewMakeVariableExpander(target, fakePathMapper{})
+		actual, err := expander.Expand("cmd", in)
+		if err != nil {
+			t.Errorf("%v: Expand() failed: %v", testCase.name, err)
+		} else if actual != testCase.out {
+			t.Errorf("%v: Expand() = %q; expected %q", testCase.name, actual,
+				testCase.out)
+		}
+	}
+}
@@ -5,9 +5,11 @@ package bazel // import "src.tricot.io/public/bazel2x/bazel"
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"src.tricot.io/public/bazel2x/bazel/core"
+	"src.tricot.io/public/bazel2x/bazel/utils"
 )
 
 type SourceFileReader func(sourceFileLabel core.Label) ([]byte, error)
@@ -19,3 +21,56 @@ func GetSourceFileReader(workspaceDir string, outputBase string) SourceFileReade
 		return ioutil.ReadFile(sourceFilePath)
 	}
 }
+
+// SourceDirReader reads the entries of a source directory, specified by workspace and a
+// (slash-separated) path relative to the workspace's root (which is empty for the root itself).
+// Entries ignored by the workspace's .bazelignore file are omitted.
+type SourceDirReader func(workspace core.WorkspaceName, dirPath string) ([]os.FileInfo, error)
+
+func GetSourceDirReader(workspaceDir string, outputBase string) SourceDirReader {
+	externalDir := filepath.Join(outputBase, "external")
+	// ignorePathsSets caches the (sets of) ignored paths for each workspace.
+	ignorePathsSets := map[core.WorkspaceName]map[string]struct{}{}
+	return func(workspace core.WorkspaceName, dirPath string) ([]os.FileInfo, error) {
+		rootDir := workspaceDir
+		if workspace.IsExternal() {
+			rootDir = filepath.Join(externalDir, string(workspace))
+		}
+
+		ignorePathsSet, ok := ignorePathsSets[workspace]
+		if !ok {
+			ignorePathsSet = map[string]struct{}{}
+			for _, ignorePath := range utils.ReadBazelIgnore(rootDir) {
+				ignorePathsSet[filepath.ToSlash(ignorePath)] = struct{}{}
+			}
+			ignorePathsSets[workspace] = ignorePathsSet
+		}
+
+		absDirPath := filepath.Join(rootDir, filepath.FromSlash(dirPath))
+		entries, err := ioutil.ReadDir(absDirPath)
+		if err != nil {
+			return nil, err
+		}
+
+		rv := make([]os.FileInfo, 0, len(entries))
+		for _, entry := range entries {
+			entryPath := entry.Name()
+			if dirPath != "" {
+				entryPath = dirPath + "/" + entryPath
+			}
+			if _, shouldIgnore := ignorePathsSet[entryPath]; shouldIgnore {
+				continue
+			}
+
+			// ioutil.ReadDir doesn't follow symlinks, but we want to (if possible).
+			if entry.Mode()&os.ModeSymlink != 0 {
+				info, err := os.Stat(filepath.Join(absDirPath, entry.Name()))
+				if err == nil {
+					entry = info
+				}
+			}
+			rv = append(rv, entry)
+		}
+		return rv, nil
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package utils // import "src.tricot.io/public/bazel2x/bazel/utils"
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirReader reads the entries of the directory specified by a (slash-separated) path, which is
+// relative to some root (the empty path being the root itself).
+type DirReader func(dirPath string) ([]os.FileInfo, error)
+
+// GlobOptions contains the options for Glob (other than the patterns).
+type GlobOptions struct {
+	// ExcludeDirectories indicates that directories should not be matched.
+	ExcludeDirectories bool
+
+	// AllowEmpty indicates that it's not an error for an include pattern to not match anything
+	// (or for everything to be excluded).
+	AllowEmpty bool
+}
+
+// ValidateGlobPattern checks that pattern is a valid Bazel glob pattern.
+func ValidateGlobPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("pattern cannot be empty")
+	}
+	if pattern[0] == '/' {
+		return fmt.Errorf("pattern cannot be absolute: %v", pattern)
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		switch {
+		case segment == "":
+			return fmt.Errorf("empty segment not permitted: %v", pattern)
+		case segment == "." || segment == "..":
+			return fmt.Errorf("segment %q not permitted: %v", segment, pattern)
+		case strings.Contains(segment, "**") && segment != "**":
+			return fmt.Errorf("recursive wildcard must be its own segment: %v", pattern)
+		}
+	}
+	return nil
+}
+
+// matchSegment matches a single path segment (i.e., filename) against a single pattern segment,
+// which may contain the wildcards '*' (any sequence of characters) and '?' (any one character).
+func matchSegment(pattern string, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Collapse consecutive '*'s.
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegment(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(name) == 0 {
+				return false
+			}
+		default:
+			if len(name) == 0 || name[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchSegments matches a path (given as segments) against a pattern (given as segments).
+func matchSegments(patternSegments []string, pathSegments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(pathSegments) == 0
+	}
+	if patternSegments[0] == "**" {
+		for i := 0; i <= len(pathSegments); i++ {
+			if matchSegments(patternSegments[1:], pathSegments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pathSegments) == 0 || !matchSegment(patternSegments[0], pathSegments[0]) {
+		return false
+	}
+	return matchSegments(patternSegments[1:], pathSegments[1:])
+}
+
+// MatchGlobPattern returns whether the given (slash-separated, relative) path matches the given
+// glob pattern (which should be valid).
+func MatchGlobPattern(pattern string, p string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// globber evaluates glob patterns within a single package directory, caching directory listings.
+type globber struct {
+	readDir DirReader
+	pkgDir  string
+	options GlobOptions
+
+	entriesCache map[string][]os.FileInfo
+}
+
+func (self *globber) entries(dirPath string) ([]os.FileInfo, error) {
+	if entries, ok := self.entriesCache[dirPath]; ok {
+		return entries, nil
+	}
+	entries, err := self.readDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	self.entriesCache[dirPath] = entries
+	return entries, nil
+}
+
+// isSubpackage returns whether the given directory (which should be below the package
+// directory) is the root of another package, i.e., contains a BUILD[.bazel] file.
+func (self *globber) isSubpackage(dirPath string) (bool, error) {
+	entries, err := self.entries(dirPath)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && (entry.Name() == "BUILD" || entry.Name() == "BUILD.bazel") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// match adds to matches all the entries under relDir (relative to the package directory) that
+// match the given pattern segments.
+func (self *globber) match(relDir string, patternSegments []string,
+	matches map[string]struct{}) error {
+
+	if len(patternSegments) == 0 {
+		return nil
+	}
+
+	if patternSegments[0] == "**" {
+		// "**" may match no segments at all.
+		if len(patternSegments) == 1 {
+			if relDir != "" && !self.options.ExcludeDirectories {
+				matches[relDir] = struct{}{}
+			}
+		} else if err := self.match(relDir, patternSegments[1:], matches); err != nil {
+			return err
+		}
+	}
+
+	entries, err := self.entries(path.Join(self.pkgDir, relDir))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		relPath := path.Join(relDir, entry.Name())
+		isDir := entry.IsDir()
+
+		// Determine whether the entry is matched and, if it's a directory, the pattern
+		// segments (if any) to match under it.
+		matched := false
+		var subpatternSegments []string
+		if patternSegments[0] == "**" {
+			matched = len(patternSegments) == 1
+			if isDir {
+				subpatternSegments = patternSegments
+			}
+		} else if matchSegment(patternSegments[0], entry.Name()) {
+			matched = len(patternSegments) == 1
+			if isDir && len(patternSegments) > 1 {
+				subpatternSegments = patternSegments[1:]
+			}
+		}
+		matched = matched && (!isDir || !self.options.ExcludeDirectories)
+		if !matched && subpatternSegments == nil {
+			continue
+		}
+
+		// Globs never cross package boundaries. (Only directories that would be matched
+		// or descended into are checked, since checking requires reading them.)
+		if isDir {
+			isSubpackage, err := self.isSubpackage(path.Join(self.pkgDir, relPath))
+			if err != nil {
+				return err
+			}
+			if isSubpackage {
+				continue
+			}
+		}
+
+		if matched {
+			matches[relPath] = struct{}{}
+		}
+		if subpatternSegments != nil {
+			if err := self.match(relPath, subpatternSegments, matches); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Glob implements Bazel's glob: it returns the sorted paths (relative to the package directory
+// pkgDir) of the entries under pkgDir that match any of the include patterns but none of the
+// exclude patterns. It does not descend into subpackages (directories containing a BUILD[.bazel]
+// file). Any files to be ignored (per .bazelignore) should be omitted by readDir.
+func Glob(readDir DirReader, pkgDir string, include []string, exclude []string,
+	options GlobOptions) ([]string, error) {
+
+	for _, pattern := range include {
+		if err := ValidateGlobPattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range exclude {
+		if err := ValidateGlobPattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	g := &globber{
+		readDir:      readDir,
+		pkgDir:       pkgDir,
+		options:      options,
+		entriesCache: make(map[string][]os.FileInfo),
+	}
+
+	isExcluded := func(p string) bool {
+		for _, pattern := range exclude {
+			if MatchGlobPattern(pattern, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	resultSet := map[string]struct{}{}
+	for _, pattern := range include {
+		matches := map[string]struct{}{}
+		if err := g.match("", strings.Split(pattern, "/"), matches); err != nil {
+			return nil, err
+		}
+
+		numMatches := 0
+		for p := range matches {
+			if !isExcluded(p) {
+				resultSet[p] = struct{}{}
+				numMatches++
+			}
+		}
+		if numMatches == 0 && !options.AllowEmpty {
+			return nil, fmt.Errorf("glob pattern %q didn't match anything, but "+
+				"allow_empty is set to False", pattern)
+		}
+	}
+
+	rv := make([]string, 0, len(resultSet))
+	for p := range resultSet {
+		rv = append(rv, p)
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package utils_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	. "src.tricot.io/public/bazel2x/bazel/utils"
+)
+
+type fakeFileInfo struct {
+	name  string
+	isDir bool
+}
+
+func (self fakeFileInfo) Name() string       { return self.name }
+func (self fakeFileInfo) Size() int64        { return 0 }
+func (self fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (self fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (self fakeFileInfo) IsDir() bool        { return self.isDir }
+func (self fakeFileInfo) Sys() interface{}   { return nil }
+
+// fakeDirReader returns a DirReader for a fake tree containing the given files (and their parent
+// directories).
+func fakeDirReader(files ...string) DirReader {
+	tree := map[string]map[string]bool{"": {}}
+	for _, file := range files {
+		isDir := false
+		for p := file; p != "."; p = path.Dir(p) {
+			dir := path.Dir(p)
+			if dir == "." {
+				dir = ""
+			}
+			if tree[dir] == nil {
+				tree[dir] = map[string]bool{}
+			}
+			tree[dir][path.Base(p)] = isDir
+			isDir = true
+		}
+	}
+	return func(dirPath string) ([]os.FileInfo, error) {
+		entries, ok := tree[dirPath]
+		if !ok {
+			return nil, fmt.Errorf("no such directory: %v", dirPath)
+		}
+		rv := []os.FileInfo{}
+		for name, isDir := range entries {
+			rv = append(rv, fakeFileInfo{name, isDir})
+		}
+		sort.Slice(rv, func(i, j int) bool { return rv[i].Name() < rv[j].Name() })
+		return rv, nil
+	}
+}
+
+func TestMatchGlobPattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		out     bool
+	}{
+		{"*.cc", "foo.cc", true},
+		{"*.cc", "foo.h", false},
+		{"*.cc", "a/foo.cc", false},
+		{"f?o.cc", "foo.cc", true},
+		{"f?o.cc", "fooo.cc", false},
+		{"**/*.cc", "foo.cc", true},
+		{"**/*.cc", "a/b/foo.cc", true},
+		{"a/**", "a", true},
+		{"a/**", "a/b/c", true},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/b/c", true},
+		{"a/**/c", "b/c", false},
+		{"*", ".hidden", true},
+	}
+	for _, testCase := range testCases {
+		if out := MatchGlobPattern(testCase.pattern, testCase.path); out != testCase.out {
+			t.Error(testCase.pattern, " against ", testCase.path,
+				" should have resulted in ", testCase.out, ", but resulted in ",
+				out)
+		}
+	}
+}
+
+func TestValidateGlobPattern(t *testing.T) {
+	valids := []string{"*", "**", "a/**/b", "*.cc", "a/b/c.h", "f?o"}
+	for _, valid := range valids {
+		if err := ValidateGlobPattern(valid); err != nil {
+			t.Error(valid, " should be valid, but resulted in error: ", err)
+		}
+	}
+
+	invalids := []string{"", "/a", "a//b", "a/", "../a", "a/./b", "a**", "**b/c"}
+	for _, invalid := range invalids {
+		if err := ValidateGlobPattern(invalid); err == nil {
+			t.Error(invalid, " should be invalid")
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	readDir := fakeDirReader(
+		"WORKSPACE",
+		"pkg/BUILD",
+		"pkg/a.cc",
+		"pkg/a.h",
+		"pkg/b.cc",
+		"pkg/b_test.cc",
+		"pkg/internal/c.cc",
+		"pkg/internal/deep/d.cc",
+		"pkg/sub/BUILD.bazel",
+		"pkg/sub/e.cc",
+	)
+
+	testCases := []struct {
+		include []string
+		exclude []string
+		options GlobOptions
+		out     []string
+	}{
+		{[]string{"*.cc"}, nil, GlobOptions{true, true},
+			[]string{"a.cc", "b.cc", "b_test.cc"}},
+		{[]string{"*.cc"}, []string{"*_test.cc"}, GlobOptions{true, true},
+			[]string{"a.cc", "b.cc"}},
+		{[]string{"*.h", "*.cc"}, nil, GlobOptions{true, true},
+			[]string{"a.cc", "a.h", "b.cc", "b_test.cc"}},
+		{[]string{"**/*.cc"}, []string{"**/*_test.cc"}, GlobOptions{true, true},
+			[]string{"a.cc", "b.cc", "internal/c.cc", "internal/deep/d.cc"}},
+		{[]string{"**/*.cc"}, []string{"internal/**"}, GlobOptions{true, true},
+			[]string{"a.cc", "b.cc", "b_test.cc"}},
+		{[]string{"*"}, nil, GlobOptions{true, true},
+			[]string{"BUILD", "a.cc", "a.h", "b.cc", "b_test.cc"}},
+		{[]string{"*"}, []string{"*.*"}, GlobOptions{false, true},
+			[]string{"BUILD", "internal"}},
+		{[]string{"internal/**"}, nil, GlobOptions{false, true},
+			[]string{"internal", "internal/c.cc", "internal/deep",
+				"internal/deep/d.cc"}},
+		{[]string{"*.py"}, nil, GlobOptions{true, true}, []string{}},
+	}
+	for _, testCase := range testCases {
+		out, err := Glob(readDir, "pkg", testCase.include, testCase.exclude,
+			testCase.options)
+		if err != nil {
+			t.Error(testCase.include, " should not have resulted in error: ", err)
+		} else if !reflect.DeepEqual(out, testCase.out) {
+			t.Error(testCase.include, " excluding ", testCase.exclude,
+				" should have resulted in ", testCase.out, ", but resulted in ",
+				out)
+		}
+	}
+
+	errorCases := []struct {
+		include []string
+		exclude []string
+	}{
+		{[]string{"*.py"}, nil},
+		{[]string{"*.cc", "*.py"}, nil},
+		{[]string{"*.h"}, []string{"*.h"}},
+		{[]string{"../*.cc"}, nil},
+	}
+	for _, errorCase := range errorCases {
+		out, err := Glob(readDir, "pkg", errorCase.include, errorCase.exclude,
+			GlobOptions{ExcludeDirectories: true, AllowEmpty: false})
+		if err == nil {
+			t.Error(strings.Join(errorCase.include, ","),
+				" should have resulted in error, but resulted in ", out)
+		}
+	}
+}
+
+func TestGlob_DirectoriesRead(t *testing.T) {
+	readDir := fakeDirReader(
+		"pkg/BUILD",
+		"pkg/a.cc",
+		"pkg/internal/c.cc",
+		"pkg/other/d.h",
+		"pkg/sub/BUILD",
+		"pkg/sub/e.cc",
+	)
+
+	testCases := []struct {
+		include []string
+		options GlobOptions
+		// dirsRead are the directories expected to be read (in order).
+		dirsRead []string
+	}{
+		// Directories that can't match or contain matches aren't read (not even to check
+		// whether they're subpackages).
+		{[]string{"*.cc"}, GlobOptions{true, true}, []string{"pkg"}},
+		{[]string{"*"}, GlobOptions{true, true}, []string{"pkg"}},
+		{[]string{"internal/*.cc"}, GlobOptions{true, true},
+			[]string{"pkg", "pkg/internal"}},
+		// Directories that would be matched are checked.
+		{[]string{"*"}, GlobOptions{false, true},
+			[]string{"pkg", "pkg/internal", "pkg/other", "pkg/sub"}},
+		// Subpackages are read (to determine that they're subpackages), but not descended
+		// into.
+		{[]string{"**/*.cc"}, GlobOptions{true, true},
+			[]string{"pkg", "pkg/internal", "pkg/other", "pkg/sub"}},
+	}
+	for _, testCase := range testCases {
+		dirsRead := []string{}
+		countingReadDir := func(dirPath string) ([]os.FileInfo, error) {
+			dirsRead = append(dirsRead, dirPath)
+			return readDir(dirPath)
+		}
+		if _, err := Glob(countingReadDir, "pkg", testCase.include, nil,
+			testCase.options); err != nil {
+			t.Error(testCase.include, " should not have resulted in error: ", err)
+		} else if !reflect.DeepEqual(dirsRead, testCase.dirsRead) {
+			t.Error(testCase.include, " should have read ", testCase.dirsRead,
+				", but read ", dirsRead)
+		}
+	}
+}
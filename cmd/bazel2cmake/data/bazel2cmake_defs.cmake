@@ -17,7 +17,10 @@ function(bazel2cmake_cc_library name)
         return()
     endif()
 
-    cmake_parse_arguments("arg" "" "" "SRCS;HDRS;DEPS" ${ARGN})
+    cmake_parse_arguments("arg" "TESTONLY" "" "SRCS;HDRS;DEPS;COPTS;LINKOPTS" ${ARGN})
+    if(arg_TESTONLY AND BAZEL2CMAKE_SKIP_TESTONLY)
+        return()
+    endif()
     if(arg_SRCS)
         set(scope "PUBLIC")
     else()
@@ -30,6 +33,12 @@ function(bazel2cmake_cc_library name)
     add_library("${name}" ${arg_SRCS})
     _bazel2cmake_cc_config("${name}" "${scope}")
     target_link_libraries("${name}" "${scope}" ${arg_DEPS})
+    if(scope STREQUAL "PUBLIC")
+        # Like Bazel, only use the copts to compile the library's own sources.
+        target_compile_options("${name}" PRIVATE ${arg_COPTS})
+    endif()
+    # Like Bazel, also use the linkopts to link dependents.
+    target_link_libraries("${name}" "${scope}" ${arg_LINKOPTS})
 endfunction() 
 
 function(bazel2cmake_cc_binary name)
@@ -37,17 +46,22 @@ function(bazel2cmake_cc_binary name)
         return()
     endif()
 
-    cmake_parse_arguments("arg" "" "" "SRCS;DEPS" ${ARGN})
+    cmake_parse_arguments("arg" "" "" "SRCS;DEPS;COPTS;LINKOPTS" ${ARGN})
 
     add_executable("${name}" ${arg_SRCS})
     _bazel2cmake_cc_config("${name}" PRIVATE)
-    target_link_libraries("${name}" ${arg_DEPS})
+    target_link_libraries("${name}" ${arg_DEPS} ${arg_LINKOPTS})
+    target_compile_options("${name}" PRIVATE ${arg_COPTS})
 endfunction() 
 
 function(bazel2cmake_cc_test name)
     if(DEFINED BAZEL2CMAKE_SKIP_TARGET_REGEX AND name MATCHES "${BAZEL2CMAKE_SKIP_TARGET_REGEX}")
         return()
     endif()
+    # Tests are implicitly testonly.
+    if(BAZEL2CMAKE_SKIP_TESTONLY)
+        return()
+    endif()
 
     bazel2cmake_cc_binary("${name}" ${ARGN})
     add_test(NAME "${name}" COMMAND "${name}")
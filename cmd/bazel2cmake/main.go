@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"src.tricot.io/public/bazel2x/bazel"
 	"src.tricot.io/public/bazel2x/bazel/core"
@@ -20,9 +21,20 @@ import (
 
 var bazelOutputBaseFlag = flag.String("bazel_output_base", "", "Bazel output base directory")
 var configFileFlag = flag.String("config_file", "", "configuration file (e.g., bazel2cmake.json)")
+var configFlag = flag.String("config", "",
+	"build configuration to use (from the configuration file's configurations)")
 
 var onlyPrintTargetsFlag = flag.Bool("only_print_targets", false, "print targets and exit")
 var outDirFlag = flag.String("out_dir", "", "(root) output directory")
+var strictFlag = flag.Bool("strict", false,
+	"fail on calls to Bazel functions and rules that aren't implemented (or modelled)")
+
+// buildConfig contains the parts of the configuration file for the frontend (the rest is for the
+// converter).
+type buildConfig struct {
+	// Configurations are the named build configurations (selected using -config).
+	Configurations map[string]*bazel.Configuration `json:"configurations"`
+}
 
 func printTargets(build *bazel.Build) {
 	for workspaceName, workspaceTargets := range build.BuildTargets {
@@ -33,11 +45,57 @@ func printTargets(build *bazel.Build) {
 			for _, target := range packageTargets.TargetList {
 				fmt.Printf("    Target %v\n", target.Label().Target)
 				fmt.Printf("      %v\n", target)
+				if provenance := target.Provenance(); provenance != nil &&
+					provenance.IsGenerated() {
+					fmt.Printf("      (generated by %v)\n", provenance)
+				}
+				result := build.AnalysisResults[target.Label()]
+				if result == nil {
+					continue
+				}
+				for _, action := range result.Actions {
+					fmt.Printf("      Action %v: %v -> %v\n", action.Mnemonic,
+						action.Argv, action.Outputs)
+				}
 			}
 		}
 	}
 }
 
+// printNotImplementedSummary prints a summary of the uses (calls made, including instantiations of
+// native rules that we don't model, during execution, and dependencies on targets of unmodelled
+// kinds, during analysis) of builtins that aren't implemented (by builtin, most used first), if
+// there were any.
+func printNotImplementedSummary(build *bazel.Build) {
+	if len(build.NotImplementedCalls) == 0 {
+		return
+	}
+
+	locations := map[string][]string{}
+	for _, call := range build.NotImplementedCalls {
+		locations[call.Builtin] = append(locations[call.Builtin], call.Location)
+	}
+	builtins := make([]string, 0, len(locations))
+	for builtin := range locations {
+		builtins = append(builtins, builtin)
+	}
+	sort.Slice(builtins, func(i, j int) bool {
+		if len(locations[builtins[i]]) != len(locations[builtins[j]]) {
+			return len(locations[builtins[i]]) > len(locations[builtins[j]])
+		}
+		return builtins[i] < builtins[j]
+	})
+
+	fmt.Printf("WARNING: %v use(s) of unimplemented builtins (ignored; use -strict to "+
+		"fail):\n", len(build.NotImplementedCalls))
+	for _, builtin := range builtins {
+		fmt.Printf("  %v: %v use(s)\n", builtin, len(locations[builtin]))
+		for _, location := range locations[builtin] {
+			fmt.Printf("    %v\n", location)
+		}
+	}
+}
+
 func main() {
 	flag.Parse()
 
@@ -133,7 +191,9 @@ func main() {
 		}
 	}
 
-	build := bazel.NewBuild(bazel.GetSourceFileReader(workspaceDir, outputBase))
+	build := bazel.NewBuild(bazel.GetSourceFileReader(workspaceDir, outputBase),
+		bazel.GetSourceDirReader(workspaceDir, outputBase))
+	build.Strict = *strictFlag
 
 	err = build.ExecWorkspaceFile()
 	if err != nil {
@@ -156,6 +216,31 @@ func main() {
 		}
 	}
 
+	if *configFlag != "" {
+		var config buildConfig
+		if bazel2cmakeConfig != nil {
+			if err := json.Unmarshal(bazel2cmakeConfig, &config); err != nil {
+				fmt.Printf("ERROR: error parsing configuration: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		configuration, ok := config.Configurations[*configFlag]
+		if !ok {
+			fmt.Printf("ERROR: unknown build configuration %v\n", *configFlag)
+			os.Exit(1)
+		}
+		build.Configuration = configuration
+		fmt.Printf("Build configuration: %v\n", *configFlag)
+	}
+
+	err = build.Analyze()
+	if err != nil {
+		fmt.Printf("ERROR: analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	printNotImplementedSummary(build)
+
 	if *onlyPrintTargetsFlag {
 		printTargets(build)
 		os.Exit(0)
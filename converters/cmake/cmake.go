@@ -25,6 +25,37 @@ func dashJoin(parts ...string) string {
 	return strings.Join(parts, "-")
 }
 
+// cmakeQuote quotes a string as a CMake (quoted) argument. Variable references (e.g.,
+// "${PROJECT_SOURCE_DIR}") are left as is, so that CMake expands them.
+func cmakeQuote(s string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s) + "\""
+}
+
+// cmakePathMapper is a bazel.PathMapper that gives paths in the (root) project's source and
+// binary directories (e.g., "${PROJECT_BINARY_DIR}/foo/bar.h" for the generated file //foo:bar.h),
+// for "Make" variable expansion.
+type cmakePathMapper struct {
+	bazelPaths bazel.PathMapper
+}
+
+var _ bazel.PathMapper = (*cmakePathMapper)(nil)
+
+func (self *cmakePathMapper) ExecPath(file core.Label) string {
+	p := self.bazelPaths.ExecPath(file)
+	if rel := strings.TrimPrefix(p, self.bazelPaths.BinDir()+"/"); rel != p {
+		return self.BinDir() + "/" + rel
+	}
+	return "${PROJECT_SOURCE_DIR}/" + p
+}
+
+func (self *cmakePathMapper) RootPath(file core.Label) string {
+	return self.bazelPaths.RootPath(file)
+}
+
+func (self *cmakePathMapper) BinDir() string {
+	return "${PROJECT_BINARY_DIR}"
+}
+
 type CmakeConverter struct {
 	// MinimumVersion is the minimum CMake version (e.g., "3.10.0"). If empty, "3.10.0" will be
 	// used.
@@ -73,12 +104,16 @@ type CmakeConverter struct {
 
 	build *bazel.Build
 
+	// paths maps files to paths for "Make" variable expansion (e.g., in copts).
+	paths bazel.PathMapper
+
 	skipPackagesSet map[string]struct{}
 	skipTargetsSet  map[string]struct{}
 }
 
 func (self *CmakeConverter) Init(build *bazel.Build) error {
 	self.build = build
+	self.paths = &cmakePathMapper{bazel.NewBazelPathMapper(build)}
 
 	if self.MinimumVersion == "" {
 		self.MinimumVersion = "3.10.0"
@@ -151,6 +186,30 @@ func (self *CmakeConverter) writeHeader(packageName core.PackageName, w io.Write
 	return nil
 }
 
+// writeOpts writes the values of a copts or linkopts attribute (if set), with "Make" variables
+// expanded and split into words (as Bazel does), as the arguments of the given keyword.
+func (self *CmakeConverter) writeOpts(target core.Target, keyword string, attrName string,
+	opts *[]string, w io.Writer) error {
+
+	if opts == nil {
+		return nil
+	}
+	expander := self.build.NewMakeVariableExpander(target, self.paths)
+	expanded, err := expander.ExpandTokenizedList(attrName, *opts)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "    %v\n", keyword); err != nil {
+		return err
+	}
+	for _, opt := range expanded {
+		if _, err := fmt.Fprintf(w, "        %v\n", cmakeQuote(opt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (self *CmakeConverter) writeTarget(targetName core.TargetName, target core.Target,
 	w io.Writer) error {
 
@@ -168,6 +227,11 @@ func (self *CmakeConverter) writeTarget(targetName core.TargetName, target core.
 		if _, err := fmt.Fprintf(w, "    %v\n", name); err != nil {
 			return err
 		}
+		if t.Testonly != nil && *t.Testonly {
+			if _, err := fmt.Fprintf(w, "    TESTONLY\n"); err != nil {
+				return err
+			}
+		}
 		if t.Srcs != nil {
 			if _, err := fmt.Fprintf(w, "    SRCS\n"); err != nil {
 				return err
@@ -208,6 +272,12 @@ func (self *CmakeConverter) writeTarget(targetName core.TargetName, target core.
 				}
 			}
 		}
+		if err := self.writeOpts(t, "COPTS", "copts", t.Copts, w); err != nil {
+			return err
+		}
+		if err := self.writeOpts(t, "LINKOPTS", "linkopts", t.Linkopts, w); err != nil {
+			return err
+		}
 		if _, err := fmt.Fprintf(w, ")\n"); err != nil {
 			return err
 		}
@@ -246,6 +316,12 @@ func (self *CmakeConverter) writeTarget(targetName core.TargetName, target core.
 				}
 			}
 		}
+		if err := self.writeOpts(t, "COPTS", "copts", t.Copts, w); err != nil {
+			return err
+		}
+		if err := self.writeOpts(t, "LINKOPTS", "linkopts", t.Linkopts, w); err != nil {
+			return err
+		}
 		if _, err := fmt.Fprintf(w, ")\n"); err != nil {
 			return err
 		}
@@ -284,9 +360,30 @@ func (self *CmakeConverter) writeTarget(targetName core.TargetName, target core.
 				}
 			}
 		}
+		if err := self.writeOpts(t, "COPTS", "copts", t.Copts, w); err != nil {
+			return err
+		}
+		if err := self.writeOpts(t, "LINKOPTS", "linkopts", t.Linkopts, w); err != nil {
+			return err
+		}
 		if _, err := fmt.Fprintf(w, ")\n"); err != nil {
 			return err
 		}
+	case *rules.GenruleTarget:
+		// TODO(vtl): Convert genrules to add_custom_command()s (using the analyzed action's
+		// command). For now, note that the target (and its outputs) were skipped.
+		t := target.(*rules.GenruleTarget)
+		if _, err := fmt.Fprintf(w, "\n# Skipped %v (genrule not supported; outs: %v).\n",
+			target.Label(), t.GetOuts()); err != nil {
+			return err
+		}
+	case *rules.GenericRuleTarget:
+		// The rule isn't modelled, so we can't convert the target; note that it was
+		// skipped.
+		if _, err := fmt.Fprintf(w, "\n# Skipped %v (%v rule not supported).\n",
+			target.Label(), target.Kind()); err != nil {
+			return err
+		}
 	}
 
 	return nil
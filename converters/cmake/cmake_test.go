@@ -0,0 +1,124 @@
+// Copyright 2019 Tricot Inc.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package cmake_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"src.tricot.io/public/bazel2x/bazel"
+	"src.tricot.io/public/bazel2x/bazel/core"
+	. "src.tricot.io/public/bazel2x/converters/cmake"
+)
+
+// fakeFileInfo is an os.FileInfo for the entries given by the fake source directory reader.
+type fakeFileInfo struct {
+	name  string
+	isDir bool
+}
+
+func (self fakeFileInfo) Name() string       { return self.name }
+func (self fakeFileInfo) Size() int64        { return 0 }
+func (self fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (self fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (self fakeFileInfo) IsDir() bool        { return self.isDir }
+func (self fakeFileInfo) Sys() interface{}   { return nil }
+
+// newFakeBuild creates a build (of the main workspace) whose source files are given by files
+// (keyed by label) and whose directories' entries are given by dirs (keyed by path, mapping names
+// to whether they're directories).
+func newFakeBuild(files map[string]string, dirs map[string]map[string]bool) *bazel.Build {
+	return bazel.NewBuild(
+		func(sourceFileLabel core.Label) ([]byte, error) {
+			data, ok := files[sourceFileLabel.String()]
+			if !ok {
+				return nil, fmt.Errorf("%v not found", sourceFileLabel)
+			}
+			return []byte(data), nil
+		},
+		func(workspace core.WorkspaceName, dirPath string) ([]os.FileInfo, error) {
+			entries, ok := dirs[dirPath]
+			if workspace != core.MainWorkspaceName || !ok {
+				return nil, &os.PathError{Op: "open", Path: dirPath,
+					Err: os.ErrNotExist}
+			}
+			rv := []os.FileInfo{}
+			for name, isDir := range entries {
+				rv = append(rv, fakeFileInfo{name, isDir})
+			}
+			sort.Slice(rv, func(i, j int) bool { return rv[i].Name() < rv[j].Name() })
+			return rv, nil
+		})
+}
+
+func TestCmakeConverter_Testonly(t *testing.T) {
+	files := map[string]string{
+		"//:WORKSPACE": "workspace(name = \"ws\")\n",
+		"//foo:BUILD": `
+package(default_testonly = True)
+cc_library(name = "a", srcs = ["a.cc"])
+cc_library(name = "b", srcs = ["b.cc"], testonly = False)
+`,
+		"//foo:a.cc": "",
+		"//foo:b.cc": "",
+	}
+	dirs := map[string]map[string]bool{
+		"":    {"WORKSPACE": false, "foo": true},
+		"foo": {"BUILD": false, "a.cc": false, "b.cc": false},
+	}
+	build := newFakeBuild(files, dirs)
+	if err := build.ExecWorkspaceFile(); err != nil {
+		t.Fatalf("ExecWorkspaceFile() failed: %v", err)
+	}
+	if err := build.ExecBuildFile(core.Label{Package: "foo", Target: "BUILD"}); err != nil {
+		t.Fatalf("ExecBuildFile() failed: %v", err)
+	}
+	build.Configuration = &bazel.Configuration{}
+	if err := build.Analyze(); err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+
+	outDir, err := ioutil.TempDir("", "cmake_test")
+	if err != nil {
+		t.Fatalf("TempDir() failed: %v", err)
+	}
+	defer os.RemoveAll(outDir)
+	converter := &CmakeConverter{}
+	if err := converter.Init(build); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := converter.Convert(outDir); err != nil {
+		t.Fatalf("Convert() failed: %v", err)
+	}
+
+	actual, err := ioutil.ReadFile(filepath.Join(outDir, "foo", "CMakeLists.txt"))
+	if err != nil {
+		t.Fatalf("failed to read foo/CMakeLists.txt: %v", err)
+	}
+	expected := `# Code generated by bazel2cmake. DO NOT EDIT.
+
+cmake_minimum_required(VERSION 3.10.0)
+
+bazel2cmake_cc_library(
+    ws-foo-a
+    TESTONLY
+    SRCS
+        a.cc
+)
+
+bazel2cmake_cc_library(
+    ws-foo-b
+    SRCS
+        b.cc
+)
+`
+	if string(actual) != expected {
+		t.Errorf("foo/CMakeLists.txt is\n%s\nexpected\n%s", actual, expected)
+	}
+}